@@ -1,6 +1,15 @@
 package cmd
 
 import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
 	"github.com/kujtimiihoxha/kit/generator"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -15,10 +24,35 @@ var genDTOCommand = &cobra.Command{
 		var (
 			service            = viper.GetString("targetService")
 			targetPBStructName = viper.GetString("targetPBStruct")
+			format             = viper.GetString("g_dto_format")
 		)
 
+		if viper.GetBool("g_dto_force") && cmd.Flags().Changed("merge") && viper.GetBool("g_dto_merge") {
+			logrus.Error("--force overwrites the generated files without merging the methods added to the dto types, it cannot be used with --merge")
+			return
+		}
+		if viper.GetBool("g_dto_stdout") && format == "json" {
+			logrus.Error("--stdout and --format json both write to stdout, use only one of them")
+			return
+		}
+		// stdout is kept for the generated source, or the json report
+		logrus.SetOutput(os.Stderr)
+
+		switch format {
+		case "text":
+		case "json":
+			// the report printed on stdout replaces the logs
+			logrus.SetOutput(ioutil.Discard)
+		default:
+			logrus.Errorf("unknown format %s, expected text or json", format)
+			return
+		}
+
 		if len(service) == 0 {
 			logrus.Error("you must provide a name for the service")
+			if format == "json" {
+				printDTOResult(generator.DTOResult{Error: "you must provide a name for the service"})
+			}
 			return
 		}
 
@@ -35,18 +69,261 @@ var genDTOCommand = &cobra.Command{
 			logrus.Info("no target struct is specified, will generate for all *Request/*Response structs in pb.go")
 		}
 
-		g := generator.NewGenerateDTOFromProto(service, targetPBStructName)
-		if err := g.Generate(); err != nil {
-			logrus.Error(err)
+		options := generator.DTOOptions{
+			TypePrefix:           viper.GetString("g_dto_type_prefix"),
+			TypeSuffix:           viper.GetString("g_dto_type_suffix"),
+			Split:                viper.GetBool("g_dto_split"),
+			WithConstructors:     viper.GetBool("g_dto_with_constructors"),
+			EnumAsString:         viper.GetBool("g_dto_enum_as_string"),
+			Exclude:              viper.GetStringSlice("g_dto_exclude"),
+			FallibleBindings:     viper.GetBool("g_dto_fallible_bindings"),
+			Formatter:            viper.GetString("g_dto_formatter"),
+			AnnotateFieldNumbers: viper.GetBool("g_dto_annotate_field_numbers"),
+			Proto3ZeroOmit:       viper.GetBool("g_dto_proto3_zero_omit"),
+			CopySlices:           viper.GetBool("g_dto_copy_slices"),
+			GRPCBindings:         viper.GetBool("g_dto_grpc_bindings"),
+			MappingSpec:          viper.GetString("g_dto_mapping_spec"),
+			AnyAsRaw:             viper.GetBool("g_dto_any_as_raw"),
+			WithRegistry:         viper.GetBool("g_dto_with_registry"),
+			ValueNested:          viper.GetBool("g_dto_value_nested"),
+			WithDriftCheck:       viper.GetBool("g_dto_with_drift_check"),
+			Int64AsString:        viper.GetBool("g_dto_int64_as_string"),
+			WithBenchmarks:       viper.GetBool("g_dto_with_benchmarks"),
+			JSONSchema:           viper.GetBool("g_dto_json_schema"),
+			FlattenWrappers:      viper.GetBool("g_dto_flatten_wrappers"),
+			InPBPackage:          viper.GetBool("g_dto_in_pb_package"),
+			MapperInterface:      viper.GetBool("g_dto_mapper_interface"),
+			WithRoundTripTests:   viper.GetBool("g_dto_with_roundtrip_tests"),
+			AnnotatePBTypes:      viper.GetBool("g_dto_annotate_pb_types"),
+			CtxBindings:          viper.GetBool("g_dto_ctx_bindings"),
+			Stdout:               viper.GetBool("g_dto_stdout"),
+			Force:                viper.GetBool("g_dto_force"),
+			NoMerge:              !viper.GetBool("g_dto_merge"),
+			ReflectFallback:      viper.GetBool("g_dto_reflect_fallback"),
+			DTOPackageName:       viper.GetString("g_dto_package_name"),
+			WithBuilder:          viper.GetBool("g_dto_with_builder"),
+			WithStringer:         viper.GetBool("g_dto_with_stringer"),
+			BuildTags:            viper.GetString("g_dto_build_tags"),
+			FromPBTemplate:       viper.GetString("g_dto_from_pb_template"),
+			ToPBTemplate:         viper.GetString("g_dto_to_pb_template"),
+			WithJSONHelpers:      viper.GetBool("g_dto_with_json_helpers"),
+			MigrateFrom:          viper.GetString("g_dto_migrate_from"),
+			WithGetters:          viper.GetBool("g_dto_with_getters"),
+			JSONFloatMode:        viper.GetString("g_dto_json_float_mode"),
+			Manifest:             viper.GetBool("g_dto_manifest"),
+			EmbedBase:            viper.GetString("g_dto_embed_base"),
+			OpenAPI:              viper.GetBool("g_dto_openapi"),
+			IfNewer:              viper.GetBool("g_dto_if_newer"),
+			WithFieldConstants:   viper.GetBool("g_dto_with_field_constants"),
+			IncludeParents:       viper.GetBool("g_dto_include_parents"),
+			MaxStructsPerFile:    viper.GetInt("g_dto_max_structs_per_file"),
+			ToPBNilCollections:   viper.GetBool("g_dto_topb_nil_collections"),
+			PropagateDeprecation: viper.GetBool("g_dto_propagate_deprecation"),
+			WithSamples:          viper.GetBool("g_dto_with_samples"),
+			MaxDepth:             viper.GetInt("g_dto_max_depth"),
+			OutFileName:          viper.GetString("g_dto_out_file_name"),
+			FollowImports:        viper.GetBool("g_dto_follow_imports"),
+			UnknownTypePolicy:    viper.GetString("g_dto_unknown_type_policy"),
+		}
+		generate := func() {
+			// a generator accumulates the generated code, so each generation needs a new one
+			g, err := generator.NewGen("dto", generator.GenArgs{Name: service, Target: targetPBStructName, Options: options})
+			if err == nil {
+				err = g.Generate()
+			}
+			if err != nil {
+				logrus.Error(err)
+			}
+			if format != "json" {
+				return
+			}
+			result := generator.DTOResult{}
+			if r, ok := g.(interface{ Result() generator.DTOResult }); ok {
+				result = r.Result()
+			}
+			if err != nil {
+				result.Error = err.Error()
+			}
+			printDTOResult(result)
+		}
+		generate()
+
+		if viper.GetBool("g_dto_watch") {
+			pbGoFilePath := filepath.Join(viper.GetString("gk_folder"), generator.DTOSourcePath(service))
+			if err := watchDTOSource(pbGoFilePath, generate); err != nil {
+				logrus.Error(err)
+			}
 		}
 	},
 }
 
+// printDTOResult prints the report of a dto generation as a line of json on stdout, i.e. one line per generation in watch mode
+func printDTOResult(result generator.DTOResult) {
+	if err := json.NewEncoder(os.Stdout).Encode(result); err != nil {
+		logrus.Error(err)
+	}
+}
+
+// watchDTOSource calls generate whenever the modification time of the pb.go file changes, until SIGINT / SIGTERM,
+// the folder of pb.go is watched rather than the file itself as protoc replaces the file
+func watchDTOSource(pbGoFilePath string, generate func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	if err := watcher.Add(filepath.Dir(pbGoFilePath)); err != nil {
+		return err
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	lastModTime := time.Time{}
+	if info, err := os.Stat(pbGoFilePath); err == nil {
+		lastModTime = info.ModTime()
+	}
+	logrus.Info("watching ", pbGoFilePath, " for changes, press ctrl+c to stop")
+	for {
+		select {
+		case event := <-watcher.Events:
+			if filepath.Clean(event.Name) != filepath.Clean(pbGoFilePath) {
+				continue
+			}
+			info, err := os.Stat(pbGoFilePath)
+			if err != nil || info.ModTime().Equal(lastModTime) {
+				continue
+			}
+			lastModTime = info.ModTime()
+			logrus.Info(pbGoFilePath, " changed, regenerating dto")
+			generate()
+		case err := <-watcher.Errors:
+			logrus.Error(err)
+		case <-signals:
+			logrus.Info("stopped watching ", pbGoFilePath)
+			return nil
+		}
+	}
+}
+
 func init() {
 	generateCmd.AddCommand(genDTOCommand)
 	genDTOCommand.Flags().StringP("targetService", "s", "", "Name of the service")
 	genDTOCommand.Flags().StringP("targetPBStruct", "x", "", "Name of the target struct in pb.go that you want to generate dto for")
+	genDTOCommand.Flags().String("type-prefix", "", "Prefix added to the name of every generated dto type")
+	genDTOCommand.Flags().String("type-suffix", "", "Suffix added to the name of every generated dto type, e.g. DTO")
+	genDTOCommand.Flags().Bool("split", false, "Write the FromPB/ToPB bindings into a separate z_<service>_dto_bindings.go file")
+	genDTOCommand.Flags().Bool("with-constructors", false, "Generate a New<Dto> constructor taking the required (non-pointer, non-collection or validate:\"required\") fields")
+	genDTOCommand.Flags().Bool("enum-as-string", false, "Marshal dto enums to json by name instead of number")
+	genDTOCommand.Flags().Bool("fallible-bindings", false, "Make every FromPB/ToPB binding return (value, error) and propagate nested conversion errors")
+	genDTOCommand.Flags().Bool("annotate-field-numbers", false, "Annotate each dto field with its proto field number, e.g. // proto field 3")
+	genDTOCommand.Flags().Bool("proto3-zero-omit", false, "Leave scalar pb fields unset in ToPB bindings when the dto field is zero")
+	genDTOCommand.Flags().Bool("copy-slices", false, "Copy slices of primitives, e.g. []string, in the bindings instead of sharing them between pb and dto")
+	genDTOCommand.Flags().Bool("grpc-bindings", false, "Also generate the transport/grpc encode/decode funcs of the service methods into z_<service>_grpc_bindings.go")
+	genDTOCommand.Flags().String("mapping-spec", "", "Path to a yaml file overriding the type and/or the FromPB/ToPB conversions of dto fields keyed by <Struct>.<Field>")
+	genDTOCommand.Flags().Bool("any-as-raw", false, "Map *anypb.Any fields to json.RawMessage holding their type url and undecoded payload")
+	genDTOCommand.Flags().Bool("with-registry", false, "Also generate FromPBRegistry, a map of the FromPB bindings keyed by pb struct name")
+	genDTOCommand.Flags().Bool("value-nested", false, "Embed single nested structs by value instead of by pointer in dto, a nil pb struct becomes a zero dto struct")
+	genDTOCommand.Flags().Bool("with-drift-check", false, "Also generate z_<service>_dto_drift_test.go, failing when a pb struct has a field its dto does not cover")
+	genDTOCommand.Flags().Bool("int64-as-string", false, "Map int64/uint64 (sint64, fixed64...) fields to strings in dto as protojson does, so json clients do not lose precision")
+	genDTOCommand.Flags().Bool("with-benchmarks", false, "Also generate z_<service>_dto_bench_test.go, benchmarking the FromPB/ToPB bindings of every dto")
+	genDTOCommand.Flags().Bool("json-schema", false, "Also generate z_<service>_dto.schema.json, the json schema of the dto")
+	genDTOCommand.Flags().Bool("flatten-wrappers", false, "Flatten the pb structs having a single field, referenced by a single pointer field and not generated on their own, into that field")
+	genDTOCommand.Flags().Bool("in-pb-package", false, "Generate the dto into the pb package instead of the dto package, requires --type-prefix or --type-suffix")
+	genDTOCommand.Flags().Bool("mapper-interface", false, "Also generate a <Dto>Mapper interface per dto and its Default<Dto>Mapper implementation calling the bindings")
+	genDTOCommand.Flags().Bool("with-roundtrip-tests", false, "Also generate z_<service>_dto_roundtrip_test.go, converting a sample of every pb struct to its dto and back")
+	genDTOCommand.Flags().Bool("annotate-pb-types", false, "Annotate each dto field whose type is changed by the mapping with its pb type, e.g. // pb: *durationpb.Duration")
+	genDTOCommand.Flags().Bool("follow-imports", false, "Also generate the dto of the pb structs of the pb packages of the project imported by pb.go, named after their package when another dto has their name, e.g. CommonMetadata")
+	genDTOCommand.Flags().String("out-file-name", "", "The template of the name of the dto file, e.g. {{.Service}}_dto.gen.go, z_<service>_dto.go if empty")
+	genDTOCommand.Flags().Int("max-depth", 0, "Fail when a chain of nested structs is deeper than this, 1000 if 0")
+	genDTOCommand.Flags().Bool("with-samples", false, "Also generate a Sample<Dto> func per dto returning a dto filled with deterministic non zero values for tests")
+	genDTOCommand.Flags().Bool("propagate-deprecation", false, "Copy the Deprecated: notice of the deprecated pb fields onto their dto fields")
+	genDTOCommand.Flags().Bool("topb-nil-collections", false, "Leave the pb maps and slices of the ToPB bindings nil when the dto ones are, instead of empty")
+	genDTOCommand.Flags().Int("max-structs-per-file", 0, "Generate the dto into z_<service>_dto_1.go, _2.go, ... of up to this many structs when they exceed it, a top level message staying with the messages it references even past it, a single file if 0")
+	genDTOCommand.Flags().Bool("include-parents", false, "With --targetPBStruct, also generate the structs referencing the target struct, directly or not")
+	genDTOCommand.Flags().Bool("with-field-constants", false, "Generate a <Dto>Field<Field> constant per dto field holding its json name, e.g. for field mask paths")
+	genDTOCommand.Flags().Bool("if-newer", false, "Skip the generation when the dto file is newer than pb.go, as a make rule")
+	genDTOCommand.Flags().Bool("openapi", false, "Also write z_<service>_dto.openapi.yaml holding the openapi 3 component schemas of the dto")
+	genDTOCommand.Flags().String("embed-base", "", "Struct embedded into every dto, e.g. example.com/common/model.BaseModel, skipped by the bindings unless mapped by the mapping spec as <Struct>.<BaseType>")
+	genDTOCommand.Flags().Bool("manifest", false, "Also write z_<service>_dto.manifest.json listing the generated files and symbols with the pb struct or enum they are generated from")
+	genDTOCommand.Flags().String("json-float-mode", "", "How the dto marshal NaN and infinite floats to json, which encoding/json fails to: null, error or string, as is if empty")
+	genDTOCommand.Flags().Bool("with-getters", false, "Generate a nil-safe Get<Field> method per dto field as the pb getters, returning the zero value when the dto or an optional scalar is nil")
+	genDTOCommand.Flags().String("migrate-from", "", "Name of the service holding the previous version of the pb, also generate the <Dto>V1ToV2 / <Dto>V2ToV1 converters between its dto and the dto")
+	genDTOCommand.Flags().Bool("with-json-helpers", false, "Generate a <Dto>FromJSON function and a ToJSON method per dto, (un)marshalling it with encoding/json")
+	genDTOCommand.Flags().String("from-pb-template", "{{.Name}}FromPB", "Template of the names of the bindings converting from pb, {{.Name}} being the name of the dto, e.g. FromProto{{.Name}}")
+	genDTOCommand.Flags().String("to-pb-template", "{{.Name}}ToPB", "Template of the names of the bindings converting to pb, {{.Name}} being the name of the dto, e.g. ToProto{{.Name}}")
+	genDTOCommand.Flags().String("build-tags", "", "Build constraint of the generated files written as a //go:build line, e.g. \"dto && !lite\"")
+	genDTOCommand.Flags().Bool("with-stringer", false, "Generate a String method per dto listing its fields, e.g. for logging, a nil dto prints <nil>")
+	genDTOCommand.Flags().Bool("with-builder", false, "Generate a builder per dto with a chainable setter per field, the setters of the slices and maps add to them")
+	genDTOCommand.Flags().String("dto-package-name", "", "Name of the dto package in the package clause of the generated files, e.g. models, guessed from the dto folder if empty")
+	genDTOCommand.Flags().Bool("reflect-fallback", false, "Convert the messages having fields of unknown type, e.g. a oneof, through json with protojson, their bindings then return an error")
+	genDTOCommand.Flags().Bool("force", false, "Overwrite the generated files unconditionally, even if they are not generated files, the methods added to the dto types are not preserved, it cannot be used with --merge")
+	genDTOCommand.Flags().Bool("merge", true, "Merge the regenerated files with the methods added by hand to the dto types, the default, disabled by --merge=false or --force")
+	genDTOCommand.Flags().String("unknown-type-policy", generator.UnknownTypePassThrough, "What to do with the fields of unknown type, e.g. the interface of a oneof: pass-through, warn-and-skip or error")
+	genDTOCommand.Flags().Bool("stdout", false, "Write the generated files to stdout instead of the dto package, the logs are written to stderr")
+	genDTOCommand.Flags().Bool("ctx-bindings", false, "Give the FromPB / ToPB bindings a context.Context first argument, threaded through the nested bindings")
+	genDTOCommand.Flags().String("format", "text", "Output format, text logs or a json report of the files written, structs generated and warnings on stdout")
+	genDTOCommand.Flags().String("formatter", "", "Command formatting the generated files through stdin/stdout, e.g. gofumpt, gofmt is used if it is not found on PATH")
+	genDTOCommand.Flags().Bool("watch", false, "Keep running and regenerate the dto whenever pb.go changes, until ctrl+c")
+	genDTOCommand.Flags().StringSlice("exclude", []string{}, "Comma separated names or glob patterns of the structs in pb.go to skip, e.g. *Internal")
 
 	viper.BindPFlag("targetService", genDTOCommand.Flags().Lookup("targetService"))
 	viper.BindPFlag("targetPBStruct", genDTOCommand.Flags().Lookup("targetPBStruct"))
+	viper.BindPFlag("g_dto_type_prefix", genDTOCommand.Flags().Lookup("type-prefix"))
+	viper.BindPFlag("g_dto_type_suffix", genDTOCommand.Flags().Lookup("type-suffix"))
+	viper.BindPFlag("g_dto_split", genDTOCommand.Flags().Lookup("split"))
+	viper.BindPFlag("g_dto_with_constructors", genDTOCommand.Flags().Lookup("with-constructors"))
+	viper.BindPFlag("g_dto_enum_as_string", genDTOCommand.Flags().Lookup("enum-as-string"))
+	viper.BindPFlag("g_dto_exclude", genDTOCommand.Flags().Lookup("exclude"))
+	viper.BindPFlag("g_dto_fallible_bindings", genDTOCommand.Flags().Lookup("fallible-bindings"))
+	viper.BindPFlag("g_dto_annotate_field_numbers", genDTOCommand.Flags().Lookup("annotate-field-numbers"))
+	viper.BindPFlag("g_dto_proto3_zero_omit", genDTOCommand.Flags().Lookup("proto3-zero-omit"))
+	viper.BindPFlag("g_dto_copy_slices", genDTOCommand.Flags().Lookup("copy-slices"))
+	viper.BindPFlag("g_dto_grpc_bindings", genDTOCommand.Flags().Lookup("grpc-bindings"))
+	viper.BindPFlag("g_dto_mapping_spec", genDTOCommand.Flags().Lookup("mapping-spec"))
+	viper.BindPFlag("g_dto_any_as_raw", genDTOCommand.Flags().Lookup("any-as-raw"))
+	viper.BindPFlag("g_dto_with_registry", genDTOCommand.Flags().Lookup("with-registry"))
+	viper.BindPFlag("g_dto_value_nested", genDTOCommand.Flags().Lookup("value-nested"))
+	viper.BindPFlag("g_dto_with_drift_check", genDTOCommand.Flags().Lookup("with-drift-check"))
+	viper.BindPFlag("g_dto_int64_as_string", genDTOCommand.Flags().Lookup("int64-as-string"))
+	viper.BindPFlag("g_dto_with_benchmarks", genDTOCommand.Flags().Lookup("with-benchmarks"))
+	viper.BindPFlag("g_dto_json_schema", genDTOCommand.Flags().Lookup("json-schema"))
+	viper.BindPFlag("g_dto_flatten_wrappers", genDTOCommand.Flags().Lookup("flatten-wrappers"))
+	viper.BindPFlag("g_dto_in_pb_package", genDTOCommand.Flags().Lookup("in-pb-package"))
+	viper.BindPFlag("g_dto_mapper_interface", genDTOCommand.Flags().Lookup("mapper-interface"))
+	viper.BindPFlag("g_dto_with_roundtrip_tests", genDTOCommand.Flags().Lookup("with-roundtrip-tests"))
+	viper.BindPFlag("g_dto_annotate_pb_types", genDTOCommand.Flags().Lookup("annotate-pb-types"))
+	viper.BindPFlag("g_dto_follow_imports", genDTOCommand.Flags().Lookup("follow-imports"))
+	viper.BindPFlag("g_dto_out_file_name", genDTOCommand.Flags().Lookup("out-file-name"))
+	viper.BindPFlag("g_dto_max_depth", genDTOCommand.Flags().Lookup("max-depth"))
+	viper.BindPFlag("g_dto_with_samples", genDTOCommand.Flags().Lookup("with-samples"))
+	viper.BindPFlag("g_dto_propagate_deprecation", genDTOCommand.Flags().Lookup("propagate-deprecation"))
+	viper.BindPFlag("g_dto_topb_nil_collections", genDTOCommand.Flags().Lookup("topb-nil-collections"))
+	viper.BindPFlag("g_dto_max_structs_per_file", genDTOCommand.Flags().Lookup("max-structs-per-file"))
+	viper.BindPFlag("g_dto_include_parents", genDTOCommand.Flags().Lookup("include-parents"))
+	viper.BindPFlag("g_dto_with_field_constants", genDTOCommand.Flags().Lookup("with-field-constants"))
+	viper.BindPFlag("g_dto_if_newer", genDTOCommand.Flags().Lookup("if-newer"))
+	viper.BindPFlag("g_dto_openapi", genDTOCommand.Flags().Lookup("openapi"))
+	viper.BindPFlag("g_dto_embed_base", genDTOCommand.Flags().Lookup("embed-base"))
+	viper.BindPFlag("g_dto_manifest", genDTOCommand.Flags().Lookup("manifest"))
+	viper.BindPFlag("g_dto_json_float_mode", genDTOCommand.Flags().Lookup("json-float-mode"))
+	viper.BindPFlag("g_dto_with_getters", genDTOCommand.Flags().Lookup("with-getters"))
+	viper.BindPFlag("g_dto_migrate_from", genDTOCommand.Flags().Lookup("migrate-from"))
+	viper.BindPFlag("g_dto_with_json_helpers", genDTOCommand.Flags().Lookup("with-json-helpers"))
+	viper.BindPFlag("g_dto_from_pb_template", genDTOCommand.Flags().Lookup("from-pb-template"))
+	viper.BindPFlag("g_dto_to_pb_template", genDTOCommand.Flags().Lookup("to-pb-template"))
+	viper.BindPFlag("g_dto_build_tags", genDTOCommand.Flags().Lookup("build-tags"))
+	viper.BindPFlag("g_dto_with_stringer", genDTOCommand.Flags().Lookup("with-stringer"))
+	viper.BindPFlag("g_dto_with_builder", genDTOCommand.Flags().Lookup("with-builder"))
+	viper.BindPFlag("g_dto_package_name", genDTOCommand.Flags().Lookup("dto-package-name"))
+	viper.BindPFlag("g_dto_reflect_fallback", genDTOCommand.Flags().Lookup("reflect-fallback"))
+	viper.BindPFlag("g_dto_force", genDTOCommand.Flags().Lookup("force"))
+	viper.BindPFlag("g_dto_merge", genDTOCommand.Flags().Lookup("merge"))
+	viper.BindPFlag("g_dto_unknown_type_policy", genDTOCommand.Flags().Lookup("unknown-type-policy"))
+	viper.BindPFlag("g_dto_stdout", genDTOCommand.Flags().Lookup("stdout"))
+	viper.BindPFlag("g_dto_ctx_bindings", genDTOCommand.Flags().Lookup("ctx-bindings"))
+	viper.BindPFlag("g_dto_format", genDTOCommand.Flags().Lookup("format"))
+	viper.BindPFlag("g_dto_formatter", genDTOCommand.Flags().Lookup("formatter"))
+	viper.BindPFlag("g_dto_watch", genDTOCommand.Flags().Lookup("watch"))
 }
@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/kujtimiihoxha/kit/generator"
+	"github.com/spf13/cobra"
+)
+
+var genListCommand = &cobra.Command{
+	Use:   "list",
+	Short: "List the available generators",
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, name := range generator.Registered() {
+			fmt.Println(name)
+		}
+	},
+}
+
+func init() {
+	generateCmd.AddCommand(genListCommand)
+}
@@ -55,6 +55,7 @@ var initserviceCmd = &cobra.Command{
 			pbImportPath,
 			smw,
 			emw,
+			viper.GetBool("g_s_strict"),
 			methods,
 		)
 		if err := g.Generate(); err != nil {
@@ -73,6 +74,7 @@ func init() {
 	initserviceCmd.Flags().StringArrayVarP(&methods, "methods", "m", []string{}, "Specify methods to be generated")
 	initserviceCmd.Flags().Bool("svc-mdw", false, "If set a default Logging and Instrumental middleware will be created and attached to the service")
 	initserviceCmd.Flags().Bool("endpoint-mdw", false, "If set a default Logging and Tracking middleware will be created and attached to the endpoint")
+	initserviceCmd.Flags().Bool("strict", false, "Fail instead of warning when a service method does not take a context.Context as its first parameter")
 	viper.BindPFlag("g_s_transport", initserviceCmd.Flags().Lookup("transport"))
 	viper.BindPFlag("g_s_pb_path", initserviceCmd.Flags().Lookup("pb_path"))
 	viper.BindPFlag("g_s_pb_import_path", initserviceCmd.Flags().Lookup("pb_import_path"))
@@ -80,4 +82,5 @@ func init() {
 	viper.BindPFlag("g_s_gorilla", initserviceCmd.Flags().Lookup("gorilla"))
 	viper.BindPFlag("g_s_svc_mdw", initserviceCmd.Flags().Lookup("svc-mdw"))
 	viper.BindPFlag("g_s_endpoint_mdw", initserviceCmd.Flags().Lookup("endpoint-mdw"))
+	viper.BindPFlag("g_s_strict", initserviceCmd.Flags().Lookup("strict"))
 }
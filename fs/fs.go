@@ -3,6 +3,7 @@ package fs
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/Songmu/prompter"
 	"github.com/sirupsen/logrus"
@@ -76,6 +77,53 @@ func (f *KitFs) Exists(path string) (bool, error) {
 	return afero.Exists(f.Fs, path)
 }
 
+// ReadDir returns the names of the entries of the directory at `path`, sorted by name.
+func (f *KitFs) ReadDir(path string) ([]string, error) {
+	infos, err := afero.ReadDir(f.Fs, path)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, info := range infos {
+		names = append(names, info.Name())
+	}
+	return names, nil
+}
+
+// ModTime returns the modification time of the file at `path`.
+func (f *KitFs) ModTime(path string) (time.Time, error) {
+	info, err := f.Fs.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// Chtimes changes the modification time of the file at `path`, e.x to touch it or to age it in tests.
+func (f *KitFs) Chtimes(path string, mtime time.Time) error {
+	return f.Fs.Chtimes(path, mtime, mtime)
+}
+
+// Glob returns the paths of the files matching `pattern`, with the same semantics as filepath.Glob,
+// e.x test/pkg/grpc/pb/*.pb.go
+func (f *KitFs) Glob(pattern string) ([]string, error) {
+	return afero.Glob(f.Fs, pattern)
+}
+
+// Dump returns the content of all the files in the fs keyed by their path,
+// it is meant to debug the in-memory fs used in tests, e.x to print the generated tree when a test fails.
+func (f *KitFs) Dump() map[string]string {
+	files := map[string]string{}
+	afero.Walk(f.Fs, "", func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		files[path], _ = f.ReadFile(path)
+		return nil
+	})
+	return files
+}
+
 // NewDefaultFs creates a KitFs with `dir` as root.
 func NewDefaultFs(dir string) *KitFs {
 	dfs := &KitFs{}
@@ -0,0 +1,77 @@
+package fs
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/spf13/viper"
+)
+
+func TestKitFs_Dump(t *testing.T) {
+	viper.Set("gk_testing", true)
+	f := NewDefaultFs("")
+	f.MkdirAll("test/pkg/service")
+	f.WriteFile("test/pkg/service/service.go", "package service", true)
+	f.WriteFile("test/go.mod", "module test", true)
+	Convey("Test if dump lists all the files with their content", t, func() {
+		So(f.Dump(), ShouldResemble, map[string]string{
+			"test/pkg/service/service.go": "package service",
+			"test/go.mod":                 "module test",
+		})
+	})
+}
+
+func TestKitFs_ReadDir(t *testing.T) {
+	viper.Set("gk_testing", true)
+	f := NewDefaultFs("")
+	f.MkdirAll("test/pkg/service")
+	f.WriteFile("test/pkg/service/service.go", "package service", true)
+	f.WriteFile("test/pkg/service/middleware.go", "package service", true)
+	Convey("Test if read dir lists the entries of a directory sorted by name", t, func() {
+		names, err := f.ReadDir("test/pkg")
+		So(err, ShouldBeNil)
+		So(names, ShouldResemble, []string{"service"})
+		names, err = f.ReadDir("test/pkg/service")
+		So(err, ShouldBeNil)
+		So(names, ShouldResemble, []string{"middleware.go", "service.go"})
+		_, err = f.ReadDir("test/missing")
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestKitFs_Glob(t *testing.T) {
+	viper.Set("gk_testing", true)
+	f := NewDefaultFs("")
+	f.MkdirAll("hello/pkg/grpc/pb")
+	f.MkdirAll("bye/pkg/grpc/pb")
+	f.WriteFile("hello/pkg/grpc/pb/z_hello.pb.go", "package pb", true)
+	f.WriteFile("hello/pkg/grpc/pb/hello.proto", "syntax = \"proto3\";", true)
+	f.WriteFile("bye/pkg/grpc/pb/z_bye.pb.go", "package pb", true)
+	Convey("Test if glob matches files like filepath.Glob", t, func() {
+		matches, err := f.Glob("*/pkg/grpc/pb/*.pb.go")
+		So(err, ShouldBeNil)
+		So(matches, ShouldResemble, []string{"bye/pkg/grpc/pb/z_bye.pb.go", "hello/pkg/grpc/pb/z_hello.pb.go"})
+		matches, err = f.Glob("hello/pkg/grpc/pb/*.yaml")
+		So(err, ShouldBeNil)
+		So(matches, ShouldBeEmpty)
+		_, err = f.Glob("[")
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestKitFs_ModTime(t *testing.T) {
+	viper.Set("gk_testing", true)
+	f := NewDefaultFs("")
+	f.MkdirAll("test/pkg/grpc/pb")
+	f.WriteFile("test/pkg/grpc/pb/z_test.pb.go", "package pb", true)
+	Convey("Test if the modification time of a file can be read and changed", t, func() {
+		yesterday := time.Now().Add(-24 * time.Hour).Truncate(time.Second)
+		So(f.Chtimes("test/pkg/grpc/pb/z_test.pb.go", yesterday), ShouldBeNil)
+		mtime, err := f.ModTime("test/pkg/grpc/pb/z_test.pb.go")
+		So(err, ShouldBeNil)
+		So(mtime.Equal(yesterday), ShouldBeTrue)
+		_, err = f.ModTime("test/missing.go")
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -1,10 +1,25 @@
 package generator
 
 import (
+	"encoding/json"
 	"fmt"
+	"go/ast"
+	"go/build/constraint"
+	goparser "go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"io/ioutil"
+	"os"
 	"path"
+	"reflect"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"text/template"
+	"time"
+	"unicode"
 
 	"github.com/dave/jennifer/jen"
 	"github.com/kujtimiihoxha/kit/fs"
@@ -22,6 +37,10 @@ const (
 
 	// name of the dto file, e.g. z_helloService_dto.go
 	formatAutoGenDTOFileName = `z_%s_dto.go`
+
+	// suffix inserted before the extension of the dto file name to get the bindings file name when they are split,
+	// e.g. z_helloService_dto_bindings.go, or helloService_dto_bindings.gen.go for helloService_dto.gen.go
+	dtoBindingsFileNameSuffix = `_bindings`
 )
 
 // structState records if a certain struct has been visited
@@ -31,22 +50,336 @@ type structState struct {
 	Visited bool
 }
 
+// the policies of options.UnknownTypePolicy for the fields whose type is unknown, see isUnknownType
+const (
+	// UnknownTypePassThrough assigns the field as is, the generated dto may not compile
+	UnknownTypePassThrough = "pass-through"
+	// UnknownTypeWarnAndSkip leaves the field out of its dto and warns
+	UnknownTypeWarnAndSkip = "warn-and-skip"
+	// UnknownTypeError fails the generation
+	UnknownTypeError = "error"
+)
+
+// defaultMaxDepth is the depth of nested structs the generation fails beyond when options.MaxDepth is not set
+const defaultMaxDepth = 1000
+
 // fieldState records information of a field in a struct
 // todo eric.wang currently this does not support nesting such as []map[string]SomeType, consider use reflect
 type fieldState struct {
+	Name string
+	// PBType is the type of the field in pb.go, e.g. []*Address, or the equivalent map type for the entries of a legacy map
+	PBType       string
 	TypeName     string
 	IsStructType bool
 	IsMap        bool
 	MapKeyType   string
 	IsSlice      bool
+
+	// MapKeyImportPath qualifies MapKeyType when the map key is a pb enum or a type of another package, see mapKeyType
+	MapKeyImportPath string
+
+	// ArrayLen is the length of a fixed-size array field, e.g. 16 for [16]byte, TypeName is then the element type,
+	// an array of structs, enums or well known types is converted element-wise, other arrays are copied as is
+	ArrayLen string
+
+	// ImportPath is set when the field type is declared outside of the pb package, e.g. *commonpb.Metadata
+	ImportPath string
+
+	// WellKnown is set when the field is a protobuf well known type that is mapped to a go type in dto
+	WellKnown *wellKnownType
+
+	// IsEnum is set when the field type is a pb enum, which is mirrored as a dto enum type
+	IsEnum bool
+
+	// IsOptionalEnum is set when the field is a pointer to a pb enum, i.e. a proto3 optional enum, e.g. *ForeignEnum,
+	// which is mirrored as a pointer to the dto enum so that its presence is kept, a nil pointer converting to nil
+	IsOptionalEnum bool
+
+	// Required is set for fields that must be provided to the dto constructor, see isRequiredField
+	Required bool
+
+	// Override is set when the mapping of the field is given by the mapping spec, see mappingSpec
+	Override *fieldOverride
+
+	// MapEntry is the pb struct of the entries when the field holds the entries of a legacy map, e.g. HelloRequest_LabelsEntry,
+	// such a field is mapped to a map in dto, see mapEntry
+	MapEntry string
+
+	// IsValueNested is set when a single nested struct is embedded by value in dto with options.ValueNested, e.g. Address Address
+	IsValueNested bool
+
+	// JSONName is the name of the dto field in json, i.e. its json tag without the options, e.g. custom_name
+	JSONName string
+
+	// Wrapper is the pb struct flattened into the field with options.FlattenWrappers, e.g. NameWrapper for Name *NameWrapper,
+	// the field then has the type of WrappedField, the single field of the wrapper, see findFlattenedWrappers
+	Wrapper      string
+	WrappedField string
+
+	// Redacted is set for the sensitive fields masked in the String method and the json of their dto, see isRedactedField
+	Redacted bool
+
+	// IsCollectionPtr is set when the pb field is a pointer to a collection, e.g. *[]string, PBType is then the collection type,
+	// which is the dto type, a nil pointer converting to a nil collection, see collectionPtrType
+	IsCollectionPtr bool
+}
+
+// isNilable tells if the pb field can be nil, i.e. a pointer, a slice or a map, e.g. the field of a flattened wrapper
+func (f fieldState) isNilable() bool {
+	return f.IsSlice || f.IsMap || strings.HasPrefix(f.PBType, "*")
+}
+
+// isConverted tells if the field needs a conversion in the bindings, other fields are assigned directly
+func (f fieldState) isConverted() bool {
+	return f.IsStructType || f.WellKnown != nil || f.IsEnum || f.Override != nil || f.Wrapper != ""
+}
+
+// mapKeyType returns the key type of a map field, qualified when it is a pb enum or a type of another package, e.g. pb.Status
+func (f fieldState) mapKeyType() jen.Code {
+	if f.MapKeyImportPath != "" {
+		return jen.Qual(f.MapKeyImportPath, f.MapKeyType)
+	}
+	return jen.Id(f.MapKeyType)
+}
+
+// pbTypeChanged returns whether the dto type of the field differs from its pb type other than by the package of the types,
+// i.e. the field is a well known type, a legacy map, a flattened wrapper, a pointer to a collection or its type is overridden
+func (f fieldState) pbTypeChanged() bool {
+	return f.WellKnown != nil || f.MapEntry != "" || f.Wrapper != "" || f.IsCollectionPtr || (f.Override != nil && f.Override.Type != "")
+}
+
+// wellKnownType describes how a protobuf well known type is mapped to a go type in dto
+type wellKnownType struct {
+	// importPath and name of the well known type, e.g. google.golang.org/protobuf/types/known/structpb and Struct
+	importPath string
+	name       string
+
+	// dtoType returns the type used in dto, e.g. map[string]interface{}
+	dtoType func() jen.Code
+
+	// fromPB and toPB return the expression converting src from pb to dto and back
+	fromPB func(src jen.Code) jen.Code
+	toPB   func(src jen.Code) jen.Code
+
+	// toPBReturnsError is set when toPB returns (value, error), e.g. structpb.NewStruct
+	toPBReturnsError bool
+}
+
+const (
+	structpbImportPath    = "google.golang.org/protobuf/types/known/structpb"
+	anypbImportPath       = "google.golang.org/protobuf/types/known/anypb"
+	durationpbImportPath  = "google.golang.org/protobuf/types/known/durationpb"
+	timestamppbImportPath = "google.golang.org/protobuf/types/known/timestamppb"
+	wrapperspbImportPath  = "google.golang.org/protobuf/types/known/wrapperspb"
+	emptypbImportPath     = "google.golang.org/protobuf/types/known/emptypb"
+	fieldmaskpbImportPath = "google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// wellKnownTypes maps the protobuf well known types, keyed by their import path and type name, to their dto representation
+var wellKnownTypes = map[string]wellKnownType{
+	// *structpb.Struct <-> map[string]interface{}
+	structpbImportPath + ".Struct": {
+		importPath: structpbImportPath,
+		name:       "Struct",
+		dtoType: func() jen.Code {
+			return jen.Map(jen.String()).Interface()
+		},
+		fromPB: func(src jen.Code) jen.Code {
+			return jen.Add(src).Dot("AsMap").Call()
+		},
+		toPB: func(src jen.Code) jen.Code {
+			return jen.Qual(structpbImportPath, "NewStruct").Call(src)
+		},
+		toPBReturnsError: true,
+	},
+	// *structpb.Value <-> interface{}
+	structpbImportPath + ".Value": {
+		importPath: structpbImportPath,
+		name:       "Value",
+		dtoType: func() jen.Code {
+			return jen.Interface()
+		},
+		fromPB: func(src jen.Code) jen.Code {
+			return jen.Add(src).Dot("AsInterface").Call()
+		},
+		toPB: func(src jen.Code) jen.Code {
+			return jen.Qual(structpbImportPath, "NewValue").Call(src)
+		},
+		toPBReturnsError: true,
+	},
+	// *durationpb.Duration <-> time.Duration, AsDuration converts a nil duration to zero
+	durationpbImportPath + ".Duration": {
+		importPath: durationpbImportPath,
+		name:       "Duration",
+		dtoType: func() jen.Code {
+			return jen.Qual("time", "Duration")
+		},
+		fromPB: func(src jen.Code) jen.Code {
+			return jen.Add(src).Dot("AsDuration").Call()
+		},
+		toPB: func(src jen.Code) jen.Code {
+			return jen.Qual(durationpbImportPath, "New").Call(src)
+		},
+	},
+	// *timestamppb.Timestamp <-> time.Time, a nil timestamp being the zero time, see genTimestampHelpers
+	timestamppbImportPath + ".Timestamp": {
+		importPath: timestamppbImportPath,
+		name:       "Timestamp",
+		dtoType: func() jen.Code {
+			return jen.Qual("time", "Time")
+		},
+		fromPB: func(src jen.Code) jen.Code {
+			return jen.Id("timestampFromPB").Call(src)
+		},
+		toPB: func(src jen.Code) jen.Code {
+			return jen.Id("timestampToPB").Call(src)
+		},
+	},
+	// *emptypb.Empty <-> struct{}, so the dto does not import emptypb, see genEmptyHelpers
+	emptypbImportPath + ".Empty": {
+		importPath: emptypbImportPath,
+		name:       "Empty",
+		dtoType: func() jen.Code {
+			return jen.Struct()
+		},
+		fromPB: func(src jen.Code) jen.Code {
+			return jen.Id("emptyFromPB").Call(src)
+		},
+		toPB: func(src jen.Code) jen.Code {
+			return jen.Id("emptyToPB").Call(src)
+		},
+	},
+	// *fieldmaskpb.FieldMask <-> []string, the paths of the mask, e.g. of a partial update, see genFieldMaskHelpers
+	fieldmaskpbImportPath + ".FieldMask": {
+		importPath: fieldmaskpbImportPath,
+		name:       "FieldMask",
+		dtoType: func() jen.Code {
+			return jen.Index().String()
+		},
+		fromPB: func(src jen.Code) jen.Code {
+			return jen.Add(src).Dot("GetPaths").Call()
+		},
+		toPB: func(src jen.Code) jen.Code {
+			return jen.Id("fieldMaskToPB").Call(src)
+		},
+	},
+}
+
+// wrapperTypes lists the wrappers of optional scalars, with their go type and their wrapperspb constructor,
+// they are mapped to pointers in dto, e.g. *wrapperspb.StringValue <-> *string, except BytesValue mapped to []byte
+var wrapperTypes = []struct {
+	name        string
+	goType      string
+	constructor string
+}{
+	{"DoubleValue", "float64", "Double"},
+	{"FloatValue", "float32", "Float"},
+	{"Int64Value", "int64", "Int64"},
+	{"UInt64Value", "uint64", "UInt64"},
+	{"Int32Value", "int32", "Int32"},
+	{"UInt32Value", "uint32", "UInt32"},
+	{"BoolValue", "bool", "Bool"},
+	{"StringValue", "string", "String"},
+	{"BytesValue", "[]byte", "Bytes"},
+}
+
+func init() {
+	for _, wrapper := range wrapperTypes {
+		name, goType := wrapper.name, wrapper.goType
+		wellKnownTypes[wrapperspbImportPath+"."+name] = wellKnownType{
+			importPath: wrapperspbImportPath,
+			name:       name,
+			dtoType: func() jen.Code {
+				if goType == "[]byte" {
+					return jen.Index().Byte()
+				}
+				return jen.Id("*").Id(goType)
+			},
+			fromPB: func(src jen.Code) jen.Code {
+				return jen.Id(utils.ToLowerFirstCamelCase(name) + "FromPB").Call(src)
+			},
+			toPB: func(src jen.Code) jen.Code {
+				return jen.Id(utils.ToLowerFirstCamelCase(name) + "ToPB").Call(src)
+			},
+		}
+	}
+}
+
+// int64AsStringTypes maps the 64-bit integers to strings when options.Int64AsString is set, see genInt64AsStringHelpers
+var int64AsStringTypes = map[string]wellKnownType{
+	"int64": {
+		name: "int64",
+		dtoType: func() jen.Code {
+			return jen.String()
+		},
+		fromPB: func(src jen.Code) jen.Code {
+			return jen.Qual("strconv", "FormatInt").Call(src, jen.Lit(10))
+		},
+		toPB: func(src jen.Code) jen.Code {
+			return jen.Id("int64FromString").Call(src)
+		},
+		toPBReturnsError: true,
+	},
+	"uint64": {
+		name: "uint64",
+		dtoType: func() jen.Code {
+			return jen.String()
+		},
+		fromPB: func(src jen.Code) jen.Code {
+			return jen.Qual("strconv", "FormatUint").Call(src, jen.Lit(10))
+		},
+		toPB: func(src jen.Code) jen.Code {
+			return jen.Id("uint64FromString").Call(src)
+		},
+		toPBReturnsError: true,
+	},
+}
+
+// optionalInt64AsStringTypes maps the proto3 optional 64-bit integers to pointers to strings when options.Int64AsString is set,
+// e.g. *int64 <-> *string, a nil pointer staying nil, see genInt64AsStringHelpers
+var optionalInt64AsStringTypes = map[string]wellKnownType{
+	"int64": {
+		name: "int64",
+		dtoType: func() jen.Code {
+			return jen.Id("*").String()
+		},
+		fromPB: func(src jen.Code) jen.Code {
+			return jen.Id("optionalInt64ToString").Call(src)
+		},
+		toPB: func(src jen.Code) jen.Code {
+			return jen.Id("optionalInt64FromString").Call(src)
+		},
+		toPBReturnsError: true,
+	},
+	"uint64": {
+		name: "uint64",
+		dtoType: func() jen.Code {
+			return jen.Id("*").String()
+		},
+		fromPB: func(src jen.Code) jen.Code {
+			return jen.Id("optionalUint64ToString").Call(src)
+		},
+		toPB: func(src jen.Code) jen.Code {
+			return jen.Id("optionalUint64FromString").Call(src)
+		},
+		toPBReturnsError: true,
+	},
 }
 
-// pbNativeFields contains the name of the pb native fields for each struct in pb.go file
-// these fields will be skipped during dto generation
-var pbNativeFields = map[string]interface{}{
-	"state":         nil,
-	"sizeCache":     nil,
-	"unknownFields": nil,
+// anyAsRawType maps *anypb.Any to json.RawMessage when options.AnyAsRaw is set, see genAnyAsRawHelpers
+var anyAsRawType = wellKnownType{
+	importPath: anypbImportPath,
+	name:       "Any",
+	dtoType: func() jen.Code {
+		return jen.Qual("encoding/json", "RawMessage")
+	},
+	fromPB: func(src jen.Code) jen.Code {
+		return jen.Id("anyToRawMessage").Call(src)
+	},
+	toPB: func(src jen.Code) jen.Code {
+		return jen.Id("rawMessageToAny").Call(src)
+	},
+	toPBReturnsError: true,
 }
 
 // GenerateDTOFromProtoGo generates dto structs and grpc bindings for *Request / *Response structs in a pb.go file
@@ -59,42 +392,434 @@ type GenerateDTOFromProtoGo struct {
 	serviceName         string
 	protoGoFileFullPath string
 
-	// used to qualify pb package, e.g. pb.SomeStruct
+	// used to qualify pb package, e.g. pb.SomeStruct, prefixed by the module path when the project has a go.mod
 	pbPackagePath string
 
-	// used to qualify dto package, e.g. dto.GeneratedStruct
+	// folder of the dto package, relative to the project folder
 	dtoPackagePath  string
 	dtoFileFullPath string
 
+	// used to qualify dto package, e.g. dto.GeneratedStruct, prefixed by the module path when the project has a go.mod
+	dtoImportPath string
+
+	// FromPB / ToPB bindings are written here, this is the dto file itself unless options.Split is set
+	bindingsSrcFile *jen.File
+	bindingsCode    *PartialGenerator
+
 	// used when generating dto for a specific struct in pb.go
 	targetPBStructName string
+
+	// pb structs whose ToPB binding returns an error, see markFallibleBindings
+	fallibleToPB map[string]bool
+
+	// pb structs whose FromPB binding returns an error, see markFallibleBindings
+	fallibleFromPB map[string]bool
+
+	// pb structs converted through json with options.ReflectFallback, and the ones whose dto is (un)marshalled
+	// by their bindings as they are reachable from them, see markReflectFallbacks
+	reflectFallbacks    map[string]bool
+	reflectFallbackJSON map[string]bool
+
+	// the pb structs of the imported pb packages followed with options.FollowImports keyed by their qualified type,
+	// e.g. commonpb.Metadata, see loadImportedPBStructs
+	importedPBStructs map[string]importedPBStruct
+
+	// member structs of the oneofs of pb.go keyed by the interface of the oneof, see findPBOneofs
+	pbOneofs map[string][]string
+
+	// result types of the getters of the pb structs keyed by <pb struct name>.<field name>, e.g. HelloRequest.Name
+	// for GetName, used by the FromPB bindings instead of reading the fields, see pbField
+	pbGetters map[string]string
+
+	// pb enums, i.e. int32 types having a <Enum>_name map in pb.go, and whether their dto enum has been generated
+	pbEnums map[string]bool
+
+	// import aliases used in the generated files keyed by import path, e.g. helloservicepb for the pb package
+	importAliases map[string]string
+
+	// import paths keyed by the package names used in pb.go, e.g. commonpb -> example.com/common/pb
+	pbImports map[string]string
+
+	// manual overrides of the dto fields, read from options.MappingSpec
+	mappingSpec *mappingSpec
+
+	// the patterns of the .kitignore of the project, if any, see loadKitignore
+	kitignore *kitignore
+
+	// report of the generation, see Result
+	result DTOResult
+
+	// where the generated files are written with options.Stdout
+	stdout io.Writer
+
+	// set when a *anypb.Any field is mapped to json.RawMessage, see genAnyAsRawHelpers
+	usesAnyAsRaw bool
+
+	// set when a *emptypb.Empty field is mapped to struct{}, see genEmptyHelpers
+	usesEmpty bool
+
+	// set when a *fieldmaskpb.FieldMask field is mapped to []string, see genFieldMaskHelpers
+	usesFieldMask bool
+
+	// set when a *timestamppb.Timestamp field is mapped to time.Time, see genTimestampHelpers
+	usesTimestamp bool
+
+	// set when a fallible binding checks the error of a conversion, see genConversionError
+	usesConversionError bool
+
+	// set when a MarshalJSON method handles the NaN and infinite floats with options.JSONFloatMode, see genJSONFloatHelpers
+	usesJSONFloat bool
+
+	// the 64-bit integer types mapped to strings, prefixed with * for the proto3 optional ones, see genInt64AsStringHelpers
+	usesInt64AsString map[string]bool
+
+	// the wrappers of optional scalars used by the fields, see genWrapperHelpers
+	usesWrappers map[string]bool
+
+	// the field manifest of every generated dto keyed by pb struct name, see genJSONSchema
+	fieldManifests map[string][]fieldState
+
+	// the pb fields excluded from their dto with @dto:skip or .kitignore, e.g. HelloRequest.AuditedAt, see isSkippedField,
+	// or because of their unknown type with options.UnknownTypePolicy warn-and-skip
+	skippedFields []string
+
+	// the pb fields of unknown type with options.UnknownTypePolicy error, e.g. HelloRequest.Value (isHelloRequest_Value)
+	unknownTypeFields []string
+
+	// the pb map fields whose key type is not supported, e.g. HelloRequest.Homes (map[Address]string), see mapKeyType
+	invalidMapKeyFields []string
+
+	// the wrappers flattened with options.FlattenWrappers keyed by pb struct name, with their single field
+	flattenedWrappers map[string]parser.NamedTypeValue
+
+	// the numbered dto files of options.MaxStructsPerFile, see dtoChunks
+	chunkFiles []*jen.File
+
+	// the methods added by hand to the dto types of the existing dto files, see collectUserMethods
+	userMethods []*userMethod
+
+	// the names declared in the dto package, for the field constants to be unique, see genFieldConstants
+	declaredNames map[string]bool
+
+	// the pointer types of the samples of the file being generated, see samplePtrs
+	samplePtrs samplePtrs
+
+	// the content of the generated files keyed by path, the dto file without the preserved user methods, see genManifest
+	generatedSources map[string]string
+
+	// the templates of the binding names of options.FromPBTemplate / options.ToPBTemplate, see bindingNameTemplate
+	fromPBTemplate, toPBTemplate *template.Template
+
+	options DTOOptions
+}
+
+// DTOOptions holds the optional settings of the dto generator.
+type DTOOptions struct {
+	// TypePrefix and TypeSuffix are added to the name of every generated dto type, e.g. User -> UserDTO
+	TypePrefix string
+	TypeSuffix string
+
+	// Split writes the FromPB / ToPB bindings into z_<service>_dto_bindings.go instead of the dto file
+	Split bool
+
+	// WithConstructors generates a NewSomething constructor per dto taking its required fields as parameters
+	WithConstructors bool
+
+	// EnumAsString generates MarshalJSON / UnmarshalJSON on dto enums to use their names instead of numbers in json
+	EnumAsString bool
+
+	// Exclude lists the names or glob patterns (e.g. *Internal) of pb structs to skip
+	Exclude []string
+
+	// FallibleBindings makes every FromPB / ToPB binding return an error along with the converted value,
+	// e.g. func HelloRequestFromPB(pb *pb.HelloRequest) (*HelloRequest, error)
+	FallibleBindings bool
+
+	// Formatter is the command formatting the generated files, e.g. gofumpt, see formatGeneratedSource,
+	// the files are left as formatted by jennifer if empty
+	Formatter string
+
+	// FollowImports also generates the dto of the pb structs of the pb packages of the project imported by pb.go,
+	// e.g. the pb package of another service, named after their package when another dto has their name,
+	// e.g. CommonMetadata, see loadImportedPBStructs
+	FollowImports bool
+
+	// OutFileName is the text/template of the name of the dto file, the name of the service being {{.Service}},
+	// e.g. {{.Service}}_dto.gen.go, z_<service>_dto.go if empty, see dtoFileName
+	OutFileName string
+
+	// MaxDepth is the depth of nested structs the generation fails beyond, e.g. for a pathological chain of messages,
+	// defaultMaxDepth if 0
+	MaxDepth int
+
+	// WithSamples also generates z_<service>_dto_samples.go, a Sample<Dto> func per dto returning a dto filled with
+	// deterministic non zero values for tests, see genSamples
+	WithSamples bool
+
+	// PropagateDeprecation copies the Deprecated: notice of the deprecated pb fields onto their dto fields,
+	// for the IDEs and linters to flag their use, see deprecationNotice
+	PropagateDeprecation bool
+
+	// ToPBNilCollections leaves the pb maps and slices of the ToPB bindings nil when the dto ones are, instead of empty
+	ToPBNilCollections bool
+
+	// MaxStructsPerFile generates the dto into numbered files of about this many structs, z_<service>_dto_1.go, ...
+	// when they exceed it, a top level struct staying in the file of the structs it references, the helpers staying
+	// in the dto file, see dtoChunks, a single dto file is generated if zero
+	MaxStructsPerFile int
+
+	// IncludeParents also generates the pb structs referencing the target struct, directly or not, see parentStructs
+	IncludeParents bool
+
+	// WithFieldConstants generates a <Dto>Field<Field> constant per dto field holding its json name, see genFieldConstants
+	WithFieldConstants bool
+
+	// IfNewer skips the generation when the dto file is newer than pb.go, as a make rule, see isUpToDate
+	IfNewer bool
+
+	// OpenAPI also writes the openapi 3 component schemas of the dto into z_<service>_dto.openapi.yaml, see genOpenAPI
+	OpenAPI bool
+
+	// EmbedBase is a struct embedded into every dto, e.g. example.com/common/model.BaseModel holding the audit fields,
+	// the bindings skip it unless the mapping spec maps it, see embedBaseMapping
+	EmbedBase string
+
+	// Manifest also writes z_<service>_dto.manifest.json listing the generated files and symbols
+	// with the pb struct or enum they are generated from, see genManifest
+	Manifest bool
+
+	// JSONFloatMode is how the MarshalJSON method of the dto having float fields handles NaN and infinite floats,
+	// which encoding/json fails to marshal, one of JSONFloatNull, JSONFloatError or JSONFloatString, see jsonFloatFields,
+	// the floats are marshalled as is if empty
+	JSONFloatMode string
+
+	// WithGetters generates a nil-safe GetField method per dto field, as the getters of the pb structs, see genGetters
+	WithGetters bool
+
+	// MigrateFrom is the name of the service holding the previous version of the pb, e.g. helloServiceV1, the converters
+	// between its dto and the dto also generated into z_<service>_migrate.go, see genMigrations
+	MigrateFrom string
+
+	// WithJSONHelpers generates a SomethingFromJSON function and a ToJSON method per dto, see genJSONHelpers
+	WithJSONHelpers bool
+
+	// FromPBTemplate / ToPBTemplate are the text/template of the names of the bindings, the name of the dto being {{.Name}},
+	// e.g. FromProto{{.Name}}, {{.Name}}FromPB / {{.Name}}ToPB if empty
+	FromPBTemplate string
+	ToPBTemplate   string
+
+	// BuildTags is the build constraint of the generated files, e.g. dto && !lite, written as a //go:build line, see genHeader
+	BuildTags string
+
+	// WithStringer generates a String method per dto listing its fields, e.g. for logging, see genStringer
+	WithStringer bool
+
+	// WithBuilder generates a SomethingBuilder per dto with a chainable WithField setter per field, see genBuilder
+	WithBuilder bool
+
+	// DTOPackageName is the name of the dto package in the package clause of the generated files, e.g. models,
+	// the name is guessed from the dto package path if empty, see newDTOFile
+	DTOPackageName string
+
+	// ReflectFallback converts the structs having fields of unknown type, e.g. the interface of a oneof, through json
+	// with protojson instead of the static bindings, which then return an error, see genReflectFallbackBindings,
+	// the members of their oneofs are inlined into their dto and the 64-bit integers of the dto reachable from them are quoted
+	ReflectFallback bool
+
+	// Force overwrites the generated files unconditionally, even if they are not generated files,
+	// and drops the methods added to the dto types instead of preserving them, see preserveUserMethods
+	Force bool
+
+	// NoMerge drops the methods added to the dto types instead of preserving them, the generated files being merged with
+	// them by default, the files which are not generated are still not overwritten, unlike with Force, which implies it
+	NoMerge bool
+
+	// UnknownTypePolicy is what to do with the fields whose type is unknown, i.e. neither a pb struct, a pb enum,
+	// a well known type, a type of another package nor a builtin type, e.g. the interface of a oneof,
+	// one of UnknownTypePassThrough (the default if empty), UnknownTypeWarnAndSkip or UnknownTypeError
+	UnknownTypePolicy string
+
+	// Stdout writes the generated files to stdout instead of the dto package, e.g. to pipe them into gofmt,
+	// nothing is written to the file system
+	Stdout bool
+
+	// CtxBindings gives the FromPB / ToPB bindings a context.Context first argument, threaded through the nested bindings,
+	// for future hooks such as tracing spans or field audits
+	CtxBindings bool
+
+	// AnnotatePBTypes adds the original pb type as a comment to each dto field whose type is changed by the mapping,
+	// e.g. Timeout string // pb: *durationpb.Duration, see pbTypeChanged
+	AnnotatePBTypes bool
+
+	// WithRoundTripTests also generates z_<service>_dto_roundtrip_test.go, converting a sample of every pb struct
+	// to its dto and back, see genRoundTripTests
+	WithRoundTripTests bool
+
+	// MapperInterface also generates a <Dto>Mapper interface per dto, with a Default<Dto>Mapper implementation
+	// calling the FromPB / ToPB bindings, to inject the conversions, see genMapper
+	MapperInterface bool
+
+	// InPBPackage generates the dto into the pb package, next to pb.go, instead of the dto package,
+	// the dto types need a TypePrefix or a TypeSuffix not to collide with the pb types
+	InPBPackage bool
+
+	// FlattenWrappers replaces the fields referencing a wrapper, i.e. a pb struct having a single field, by that field,
+	// e.g. Name *NameWrapper -> Name string, the wrapper has no dto, see findFlattenedWrappers for the wrappers flattened
+	FlattenWrappers bool
+
+	// JSONSchema also generates z_<service>_dto.schema.json, the json schema of the dto for their json clients
+	JSONSchema bool
+
+	// WithBenchmarks also generates z_<service>_dto_bench_test.go, benchmarking the FromPB / ToPB bindings of every dto
+	WithBenchmarks bool
+
+	// Int64AsString maps the 64-bit integer fields, i.e. int64 / uint64 (sint64, fixed64...) to strings in dto as protojson does,
+	// so that json clients, e.g. javascript ones, do not lose precision, the proto3 optional ones, e.g. *int64, to *string
+	Int64AsString bool
+
+	// WithDriftCheck also generates z_<service>_dto_drift_test.go, failing when a pb struct has a field its dto does not cover
+	WithDriftCheck bool
+
+	// ValueNested embeds single nested structs by value instead of by pointer in dto, e.g. Address Address,
+	// a nil pb struct is converted to a zero dto struct, fields referencing their own struct are kept as pointers
+	ValueNested bool
+
+	// WithRegistry also generates FromPBRegistry, mapping the name of every pb struct having a dto to its FromPB binding
+	WithRegistry bool
+
+	// AnyAsRaw maps *anypb.Any fields to json.RawMessage holding their type url and payload, see genAnyAsRawHelpers
+	AnyAsRaw bool
+
+	// MappingSpec is the path of a yaml file overriding the type and / or the conversions of some dto fields,
+	// see mappingSpec for its format
+	MappingSpec string
+
+	// GRPCBindings also generates the go-kit transport/grpc encode / decode funcs of the service methods,
+	// calling the FromPB / ToPB bindings, into z_<service>_grpc_bindings.go
+	GRPCBindings bool
+
+	// CopySlices makes the bindings copy slices of primitives, e.g. []string, instead of sharing them between pb and dto
+	CopySlices bool
+
+	// Proto3ZeroOmit makes ToPB bindings leave scalar fields (strings, bools, numbers and enums) unset when they are zero,
+	// instead of assigning them unconditionally, messages and collections are always assigned
+	Proto3ZeroOmit bool
+
+	// AnnotateFieldNumbers adds the original proto field number as a comment to each dto field, e.g. // proto field 3
+	AnnotateFieldNumbers bool
+}
+
+// DTOSourcePath returns the path of the pb.go file the dto of a service are generated from, relative to the project folder
+func DTOSourcePath(serviceName string) string {
+	return fmt.Sprintf(formatPBGoFileFullPath, serviceName, serviceName)
+}
+
+func init() {
+	Register("dto", func(args GenArgs) Gen {
+		options, _ := args.Options.(DTOOptions)
+		return NewGenerateDTOFromProto(args.Name, args.Target, options)
+	})
 }
 
 // NewGenerateDTOFromProto ...
-func NewGenerateDTOFromProto(serviceName string, targetPBStructName string) Gen {
+func NewGenerateDTOFromProto(serviceName string, targetPBStructName string, options DTOOptions) Gen {
 	i := &GenerateDTOFromProtoGo{
 		serviceName:         serviceName,
-		protoGoFileFullPath: fmt.Sprintf(formatPBGoFileFullPath, serviceName, serviceName),
+		protoGoFileFullPath: DTOSourcePath(serviceName),
 		dtoPackagePath:      fmt.Sprintf(formatDTOPackagePath, serviceName, serviceName),
-		dtoFileFullPath:     path.Join(fmt.Sprintf(formatDTOPackagePath, serviceName, serviceName), fmt.Sprintf(formatAutoGenDTOFileName, serviceName)),
 		targetPBStructName:  targetPBStructName,
 		pbPackagePath:       fmt.Sprintf(path.Join("%s", "pkg", "grpc", "pb"), serviceName),
+		options:             options,
+	}
+	if options.InPBPackage {
+		i.dtoPackagePath = i.pbPackagePath
+	}
+	i.dtoFileFullPath = path.Join(i.dtoPackagePath, fmt.Sprintf(formatAutoGenDTOFileName, serviceName))
+	i.dtoImportPath = i.dtoPackagePath
+
+	// resolve the import paths against the module path of the project, so the generated imports build
+	if pbImportPath, err := utils.GetModuleImportPath(serviceName, i.pbPackagePath); err != nil {
+		i.warnf("could not read the go.mod of %s, the generated imports are relative, err: %v", serviceName, err)
+	} else {
+		i.pbPackagePath = pbImportPath
+		i.dtoImportPath, _ = utils.GetModuleImportPath(serviceName, i.dtoPackagePath)
 	}
 
 	// init base generator stuff
-	i.srcFile = jen.NewFilePath(i.dtoPackagePath)
+	i.srcFile = i.newDTOFile()
 	i.InitPg()
 	i.fs = fs.Get()
+	i.stdout = os.Stdout
 	return i
 }
 
 func (g *GenerateDTOFromProtoGo) Generate() (err error) {
-	// create dto directory if not exist
-	if err = g.CreateFolderStructure(g.dtoPackagePath); err != nil {
-		logrus.Errorf("failed to create dto directory: %s", err)
+	// in the pb package, the pb types are referred to unqualified, so the dto types must be named differently
+	if g.dtoImportPath == g.pbPackagePath && g.options.TypePrefix == "" && g.options.TypeSuffix == "" {
+		return fmt.Errorf("dto generated into the pb package need a type prefix or suffix not to collide with the pb types")
+	}
+
+	if name := g.options.DTOPackageName; name != "" && (!token.IsIdentifier(name) || name == "_") {
+		return fmt.Errorf("invalid dto package name %s, expected a go identifier", name)
+	}
+	if g.options.BuildTags != "" {
+		if _, err := constraint.Parse("//go:build " + g.options.BuildTags); err != nil {
+			return fmt.Errorf("invalid build tags %s: %v", g.options.BuildTags, err)
+		}
+	}
+
+	if g.options.OutFileName != "" {
+		fileName, err := dtoFileName(g.options.OutFileName, g.serviceName)
+		if err != nil {
+			return err
+		}
+		g.dtoFileFullPath = path.Join(g.dtoPackagePath, fileName)
+	}
+
+	if g.fromPBTemplate, err = bindingNameTemplate("from pb", g.options.FromPBTemplate, defaultFromPBTemplate); err != nil {
+		return err
+	}
+	if g.toPBTemplate, err = bindingNameTemplate("to pb", g.options.ToPBTemplate, defaultToPBTemplate); err != nil {
+		return err
+	}
+	if g.fromPBFuncName("A") == g.toPBFuncName("A") {
+		return fmt.Errorf("the from pb and to pb templates give the same binding name %s", g.fromPBFuncName("A"))
+	}
+
+	switch g.options.JSONFloatMode {
+	case "", JSONFloatNull, JSONFloatError, JSONFloatString:
+	default:
+		return fmt.Errorf("unknown json float mode %s, expected one of %s, %s, %s",
+			g.options.JSONFloatMode, JSONFloatNull, JSONFloatError, JSONFloatString)
+	}
+
+	switch g.options.UnknownTypePolicy {
+	case "", UnknownTypePassThrough, UnknownTypeWarnAndSkip, UnknownTypeError:
+	default:
+		return fmt.Errorf("unknown type policy %s, expected one of %s, %s, %s",
+			g.options.UnknownTypePolicy, UnknownTypePassThrough, UnknownTypeWarnAndSkip, UnknownTypeError)
+	}
+	if err = g.validateEmbedBase(); err != nil {
 		return err
 	}
 
+	if g.options.IfNewer && !g.options.Stdout {
+		if upToDate, err := g.isUpToDate(); err != nil {
+			return err
+		} else if upToDate {
+			logrus.Info("skipping the generation, the dto file is newer than pb.go: ", g.dtoFileFullPath)
+			return nil
+		}
+	}
+
+	// create dto directory if not exist, nothing is written to the file system with options.Stdout
+	if !g.options.Stdout {
+		if err = g.CreateFolderStructure(g.dtoPackagePath); err != nil {
+			logrus.Errorf("failed to create dto directory: %s", err)
+			return err
+		}
+	}
+
 	// ensure pb.go file exists
 	if b, err := g.fs.Exists(g.protoGoFileFullPath); err != nil {
 		return fmt.Errorf("err checking existing pb.go file path: %s, err: %v", g.protoGoFileFullPath, err)
@@ -111,11 +836,39 @@ func (g *GenerateDTOFromProtoGo) Generate() (err error) {
 	if err != nil {
 		return fmt.Errorf("err parsing pb go file at: %s, err: %v", g.protoGoFileFullPath, err)
 	}
+	// the pb package is always imported with an explicit alias, so a package name that does not match its folder is
+	// supported, but is most likely a mistake in the go_package option of the proto file
+	if pbGoFile.Package != path.Base(g.pbPackagePath) {
+		g.warnf("pb go file at: %s declares package %s, expected %s", g.protoGoFileFullPath, pbGoFile.Package, path.Base(g.pbPackagePath))
+	}
+	if g.dtoImportPath == g.pbPackagePath && g.options.DTOPackageName != "" && g.options.DTOPackageName != pbGoFile.Package {
+		return fmt.Errorf("dto generated into the pb package must be named %s, not %s", pbGoFile.Package, g.options.DTOPackageName)
+	}
 
 	// handle header comment
-	g.srcFile.PackageComment("THIS FILE IS AUTO GENERATED, DO NOT EDIT!!")
+	g.genHeader(g.srcFile)
 	g.code.NewLine()
 
+	g.bindingsSrcFile, g.bindingsCode = g.srcFile, g.code
+	if g.options.Split {
+		g.bindingsSrcFile = g.newDTOFile()
+		g.bindingsCode = NewPartialGenerator(g.bindingsSrcFile.Empty())
+		g.genHeader(g.bindingsSrcFile)
+		g.bindingsCode.NewLine()
+	}
+	g.registerImportAliases(pbGoFile)
+
+	// generic structs have no dto, the fields referring to them are of unknown type, see isUnknownType
+	pbStructs := []parser.Struct{}
+	for _, pbStruct := range pbGoFile.Structures {
+		if len(pbStruct.TypeParams) > 0 {
+			g.warnf("skipping generic struct %s at %s, generic types are not supported", pbStruct.Name, g.pbPosition(pbStruct.Position))
+			continue
+		}
+		pbStructs = append(pbStructs, pbStruct)
+	}
+	pbGoFile.Structures = pbStructs
+
 	// generate a manifest of all structs in pb.go file
 	// used to avoid generating duplicate dto struct
 	pbStructManifest := map[string]*structState{}
@@ -126,8 +879,40 @@ func (g *GenerateDTOFromProtoGo) Generate() (err error) {
 		}
 		logrus.Debug("pb struct manifest: ", pbStruct)
 	}
+	g.importedPBStructs = map[string]importedPBStruct{}
+	if g.options.FollowImports {
+		if err = g.loadImportedPBStructs(pbStructManifest); err != nil {
+			return err
+		}
+	}
+	if err = g.loadKitignore(); err != nil {
+		return err
+	}
+	if g.options.MappingSpec != "" {
+		if err = g.loadMappingSpec(pbStructManifest); err != nil {
+			return err
+		}
+	}
+	g.usesInt64AsString = map[string]bool{}
+	g.usesWrappers = map[string]bool{}
+	g.fieldManifests = map[string][]fieldState{}
+	g.generatedSources = map[string]string{}
+	g.reserveDeclaredNames(pbGoFile)
+	g.pbEnums = findPBEnums(pbGoFile)
+	g.pbGetters = findPBGetters(pbGoFile)
+	g.pbOneofs = findPBOneofs(pbGoFile)
+	g.markReflectFallbacks(pbStructManifest)
+	g.markFallibleBindings(pbStructManifest)
+
+	// validate exclusion patterns before using them
+	for _, pattern := range g.options.Exclude {
+		if _, err = path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid exclude pattern: %s, err: %v", pattern, err)
+		}
+	}
 
 	// loop over all structs in pb.go and generate dto struct for all *Request / *Response as well as their child struct
+	roots := []string{}
 	for _, pbStruct := range pbGoFile.Structures {
 		logrus.Debug("inspecting pb.go struct: ", pbStruct.Name)
 		if g.targetPBStructName != "" {
@@ -142,91 +927,1202 @@ func (g *GenerateDTOFromProtoGo) Generate() (err error) {
 			}
 		}
 
-		g.genDTORecursive(pbStruct, pbStructManifest)
-	}
-
-	return g.fs.WriteFile(g.dtoFileFullPath, g.srcFile.GoString(), true)
-}
-
-// genDTORecursive is the main func to generate dto structs
-// given an input pb struct, do a post-order traverse to generate dto for all its child structs before generating its own
-func (g *GenerateDTOFromProtoGo) genDTORecursive(currentPBStruct parser.Struct, pbStructManifest map[string]*structState) {
-	if structState, _ := pbStructManifest[currentPBStruct.Name]; structState.Visited {
-		logrus.Debug("skip pb struct as it is already visited: ", currentPBStruct)
-		return
-	}
-
-	logrus.Info("generating dto for: ", currentPBStruct)
-
-	// maintain a manifest for all fields of currentPBStruct
-	fieldManifest := map[string]fieldState{}
-
-	dtoFields := []jen.Code{}
-
-	// loop over all fields of pb struct
-	for _, field := range currentPBStruct.Vars {
-		if _, ok := pbNativeFields[field.Name]; ok {
-			logrus.Debug("skipping ", field)
+		if g.isExcluded(pbStruct.Name) {
+			logrus.Info("skipping excluded struct: ", pbStruct.Name)
+			continue
+		}
+		if isMapEntry(pbStruct) {
+			logrus.Info("skipping map entry struct: ", pbStruct.Name, " it is folded into the maps using it")
 			continue
 		}
 
-		logrus.Debug("inspecting field: ", field)
-		jsonTagKey, jsonTagVal := utils.JsonTag(field.Name)
-		dtoFields = append(dtoFields, jen.Id(field.Name).Id(field.Type).Tag(map[string]string{jsonTagKey: jsonTagVal}))
-
-		fieldType, isSlice, isMap, mapKeyType := parseFieldType(field.Type)
-		logrus.Debug("fieldType: ", fieldType, " isSlice: ", isSlice, " isMap: ", isMap, " mapKeyType: ", mapKeyType)
+		roots = append(roots, pbStruct.Name)
+	}
+	if g.targetPBStructName != "" && g.options.IncludeParents {
+		roots = append(roots, g.parentStructs(roots, pbStructManifest)...)
+	}
+	if g.targetPBStructName == "" {
+		roots = append(roots, g.unionVariants(roots)...)
+	}
 
-		structState, ok := pbStructManifest[fieldType]
-		if !ok {
-			// fieldType is not a struct, but can be a map / slice of primitive types, e.g. map[string]string, []string
-			fieldManifest[field.Name] = fieldState{
-				TypeName:     fieldType,
-				IsStructType: false,
-				IsSlice:      isSlice,
-				IsMap:        isMap,
-				MapKeyType:   mapKeyType,
-			}
-		} else {
-			// fieldType is a struct, generate it first then backtrack to current
-			fieldManifest[field.Name] = fieldState{
-				TypeName:     fieldType,
-				IsStructType: true,
-				IsSlice:      isSlice,
-				IsMap:        isMap,
-				MapKeyType:   mapKeyType,
-			}
+	g.flattenedWrappers = map[string]parser.NamedTypeValue{}
+	if g.options.FlattenWrappers {
+		g.flattenedWrappers = g.findFlattenedWrappers(roots, pbStructManifest)
+	}
 
-			if !structState.Visited {
-				logrus.Debug("recursively gen struct field: ", structState.Struct)
-				g.genDTORecursive(structState.Struct, pbStructManifest)
-				pbStructManifest[fieldType].Visited = true
-			}
+	order, err := g.dtoStructOrder(roots, pbStructManifest)
+	if err != nil {
+		return err
+	}
+	// the helpers shared by the dto are generated into the dto file, even when the dto are generated into numbered files
+	dtoSrcFile, dtoCode := g.srcFile, g.code
+	g.chunkFiles = nil
+	chunks := g.dtoChunks(order, roots, pbStructManifest)
+	for _, chunk := range chunks {
+		if len(chunks) > 1 {
+			g.useDTOChunkFile()
+		}
+		for _, pbStructName := range chunk {
+			g.genDTO(pbStructManifest[pbStructName].Struct, pbStructManifest)
+			g.result.Structs = append(g.result.Structs, g.dtoTypeName(pbStructName))
 		}
 	}
-
-	// dto struct name is the same as pb go struct name
-	g.code.appendStruct(currentPBStruct.Name, dtoFields...)
-	pbStructManifest[currentPBStruct.Name].Visited = true
-
-	g.genBindingFromPB(currentPBStruct.Name, fieldManifest)
-	g.genBindingToPB(currentPBStruct.Name, fieldManifest)
-}
-
-func (g *GenerateDTOFromProtoGo) genBindingFromPB(currentPBStructName string, fieldManifest map[string]fieldState) {
-	funcBodyForFromPB := []jen.Code{
-		jen.If(jen.Id("pb").Id("==").Nil()).
-			Block(jen.Return(jen.Nil())).Line(),
+	if g.srcFile != dtoSrcFile && !g.options.Split {
+		g.bindingsSrcFile, g.bindingsCode = dtoSrcFile, dtoCode
 	}
-	assignmentsForFromPB := jen.Dict{}
+	g.srcFile, g.code = dtoSrcFile, dtoCode
+	if len(g.unknownTypeFields) > 0 {
+		return fmt.Errorf("fields of unknown type: %s, see --unknown-type-policy", strings.Join(g.unknownTypeFields, ", "))
+	}
+	if len(g.invalidMapKeyFields) > 0 {
+		return fmt.Errorf("map fields of unsupported key type: %s, the keys must be strings, bools, numbers or enums", strings.Join(g.invalidMapKeyFields, ", "))
+	}
+	if err = g.genUnions(order); err != nil {
+		return err
+	}
+	if g.usesAnyAsRaw {
+		g.genAnyAsRawHelpers()
+	}
+	if len(g.usesInt64AsString) > 0 {
+		g.genInt64AsStringHelpers()
+	}
+	if len(g.usesWrappers) > 0 {
+		g.genWrapperHelpers()
+	}
+	if g.usesEmpty {
+		g.genEmptyHelpers()
+	}
+	if g.usesFieldMask {
+		g.genFieldMaskHelpers()
+	}
+	if g.usesTimestamp {
+		g.genTimestampHelpers()
+	}
+	if g.usesConversionError {
+		g.genConversionError()
+	}
+	if g.usesJSONFloat {
+		g.genJSONFloatHelpers()
+	}
+	if g.options.WithRegistry {
+		g.genRegistry(order)
+	}
+
+	dtoContent := g.srcFile.GoString()
+	// the manifest lists the generated symbols only
+	g.generatedSources[g.dtoFileFullPath] = dtoContent
+	if g.mergesUserMethods() {
+		generatedSources := []string{dtoContent, g.bindingsSrcFile.GoString()}
+		for _, chunkFile := range g.chunkFiles {
+			generatedSources = append(generatedSources, chunkFile.GoString())
+		}
+		if err = g.collectUserMethods(generatedSources); err != nil {
+			return err
+		}
+		if dtoContent, err = g.preserveUserMethods(g.dtoFileFullPath, dtoContent); err != nil {
+			return err
+		}
+	}
+	if err = g.writeGeneratedFile(g.dtoFileFullPath, dtoContent); err != nil {
+		return err
+	}
+
+	if err = g.writeDTOChunkFiles(); err != nil {
+		return err
+	}
+
+	if g.options.Split {
+		if err = g.writeGeneratedFile(g.dtoBindingsFileFullPath(), g.bindingsSrcFile.GoString()); err != nil {
+			return err
+		}
+	}
+
+	if g.options.WithDriftCheck {
+		if err = g.genDriftCheck(order); err != nil {
+			return err
+		}
+	}
+
+	if g.options.WithBenchmarks {
+		if err = g.genBenchmarks(order); err != nil {
+			return err
+		}
+	}
+
+	if g.options.JSONSchema {
+		if err = g.genJSONSchema(order); err != nil {
+			return err
+		}
+	}
+
+	if g.options.OpenAPI {
+		if err = g.genOpenAPI(order, pbGoFile); err != nil {
+			return err
+		}
+	}
+
+	if g.options.WithRoundTripTests {
+		if err = g.genRoundTripTests(order); err != nil {
+			return err
+		}
+	}
+
+	if g.options.WithSamples {
+		if err = g.genSamples(order); err != nil {
+			return err
+		}
+	}
+
+	if g.options.MigrateFrom != "" {
+		if err = g.genMigrations(order, pbStructManifest); err != nil {
+			return err
+		}
+	}
+
+	if g.options.GRPCBindings {
+		if err = g.genGRPCBindings(pbStructManifest); err != nil {
+			return err
+		}
+	}
+
+	if g.options.Manifest {
+		return g.genManifest(order)
+	}
+	return nil
+}
+
+// writeGeneratedFile writes a generated file into the dto package, which can also hold hand-written files
+// so only z_ prefixed files are written, or the dto file named by options.OutFileName, and an existing file is only
+// overwritten if it is generated as well
+func (g *GenerateDTOFromProtoGo) writeGeneratedFile(filePath, content string) error {
+	if !strings.HasPrefix(path.Base(filePath), "z_") && !g.isOutFile(filePath) {
+		return fmt.Errorf("refusing to write %s, generated file names must start with z_", filePath)
+	}
+	isGoSource := path.Ext(filePath) == ".go"
+	if isGoSource {
+		formatted, err := g.formatGeneratedSource(content)
+		if err != nil {
+			return fmt.Errorf("err formatting %s, err: %v", filePath, err)
+		}
+		content = formatted
+	}
+	if _, ok := g.generatedSources[filePath]; !ok {
+		g.generatedSources[filePath] = content
+	}
+	if g.options.Stdout {
+		_, err := io.WriteString(g.stdout, content)
+		return err
+	}
+
+	if g.options.Force {
+		logrus.Warn("forcing the overwrite of: ", filePath)
+	} else if b, err := g.fs.Exists(filePath); err != nil {
+		return err
+	} else if b {
+		existing, err := g.fs.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		generated := isGeneratedSource(existing)
+		switch path.Ext(filePath) {
+		case ".json":
+			generated = isGeneratedJSON(existing)
+		case ".yaml":
+			generated = isGeneratedYAML(existing)
+		}
+		if !generated {
+			return fmt.Errorf("refusing to overwrite %s, it is not a generated file", filePath)
+		}
+		// leave unchanged files untouched, e.g. to not trigger the watchers of the dto package,
+		// but the dto file is touched with options.IfNewer so that it is newer than pb.go, see isUpToDate
+		if existing == content {
+			logrus.Debug("skipping unchanged file: ", filePath)
+			if g.options.IfNewer && filePath == g.dtoFileFullPath {
+				return g.fs.Chtimes(filePath, time.Now())
+			}
+			return nil
+		}
+	}
+	if err := g.fs.WriteFile(filePath, content, true); err != nil {
+		return err
+	}
+	g.result.Files = append(g.result.Files, filePath)
+	return nil
+}
+
+// isUpToDate tells if the dto file exists and is newer than pb.go, i.e. was generated after pb.go last changed
+func (g *GenerateDTOFromProtoGo) isUpToDate() (bool, error) {
+	if exists, err := g.fs.Exists(g.dtoFileFullPath); err != nil || !exists {
+		return false, err
+	}
+	pbModTime, err := g.fs.ModTime(g.protoGoFileFullPath)
+	if err != nil {
+		return false, fmt.Errorf("err reading the modification time of pb.go file at: %s, err: %v", g.protoGoFileFullPath, err)
+	}
+	dtoModTime, err := g.fs.ModTime(g.dtoFileFullPath)
+	if err != nil {
+		return false, fmt.Errorf("err reading the modification time of dto file at: %s, err: %v", g.dtoFileFullPath, err)
+	}
+	return dtoModTime.After(pbModTime), nil
+}
+
+// isGeneratedSource tells if a go source starts with a generated code marker, either the canonical one
+// or the one used before it
+func isGeneratedSource(src string) bool {
+	for _, line := range strings.Split(src, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "//") {
+			return false
+		}
+		if generatedCodeMarker.MatchString(line) || line == "// THIS FILE IS AUTO GENERATED, DO NOT EDIT!!" {
+			return true
+		}
+	}
+	return false
+}
+
+// isGeneratedJSON tells if a json document holds the generated code marker as its $comment, e.g. a json schema
+func isGeneratedJSON(src string) bool {
+	document := struct {
+		Comment string `json:"$comment"`
+	}{}
+	if err := json.Unmarshal([]byte(src), &document); err != nil {
+		return false
+	}
+	return generatedCodeMarker.MatchString("// " + document.Comment)
+}
+
+// isGeneratedYAML tells if a yaml document starts with the generated code marker as a comment, e.g. an openapi document
+func isGeneratedYAML(src string) bool {
+	for _, line := range strings.Split(src, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "#") {
+			return false
+		}
+		if generatedCodeMarker.MatchString("// " + strings.TrimSpace(strings.TrimPrefix(line, "#"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// generatedCodeMarker matches the generated code marker line recognized by go tooling
+var generatedCodeMarker = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// genHeader writes the canonical generated code marker recognized by go tooling, i.e. ^// Code generated .* DO NOT EDIT\.$
+// followed by the pb.go file the code is generated from, preceded by the //go:build line of options.BuildTags if set
+func (g *GenerateDTOFromProtoGo) genHeader(file *jen.File) {
+	if g.options.BuildTags != "" {
+		file.HeaderComment("//go:build " + g.options.BuildTags)
+	}
+	file.PackageComment("Code generated by kit g dto. DO NOT EDIT.")
+	file.PackageComment("source: " + g.protoGoFileFullPath)
+}
+
+// newDTOFile returns a file of the dto package, named options.DTOPackageName if set
+func (g *GenerateDTOFromProtoGo) newDTOFile() *jen.File {
+	if g.options.DTOPackageName != "" {
+		return jen.NewFilePathName(g.dtoImportPath, g.options.DTOPackageName)
+	}
+	return jen.NewFilePath(g.dtoImportPath)
+}
+
+// dtoBindingsFileFullPath returns the full path of the bindings file used when options.Split is set
+func (g *GenerateDTOFromProtoGo) dtoBindingsFileFullPath() string {
+	dir, name := path.Split(g.dtoFileFullPath)
+	dot := strings.Index(name, ".")
+	return dir + name[:dot] + dtoBindingsFileNameSuffix + name[dot:]
+}
+
+// isOutFile tells if a file is the dto file named by options.OutFileName, or its bindings file
+func (g *GenerateDTOFromProtoGo) isOutFile(filePath string) bool {
+	return g.options.OutFileName != "" && (filePath == g.dtoFileFullPath || filePath == g.dtoBindingsFileFullPath())
+}
+
+// dtoStructOrder returns the pb structs to generate a dto for, i.e. the roots and all the structs they reference,
+// in a stable topological order: a struct always comes after the structs it references, and ties are broken
+// alphabetically, so the order depends neither on the order of the structs nor on the order of the fields in pb.go
+// structs referencing each other (e.g. A->B->A) are ordered alphabetically, a struct referencing itself is not a cycle,
+// it fails when a chain of nested structs is deeper than options.MaxDepth, rather than overflowing the stack
+func (g *GenerateDTOFromProtoGo) dtoStructOrder(roots []string, pbStructManifest map[string]*structState) ([]string, error) {
+	maxDepth := g.options.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = defaultMaxDepth
+	}
+	// references of each struct to generate
+	references := map[string]map[string]bool{}
+	var collect func(pbStructName string, chain []string) error
+	collect = func(pbStructName string, chain []string) error {
+		if _, ok := references[pbStructName]; ok {
+			return nil
+		}
+		if chain = append(chain, pbStructName); len(chain) > maxDepth {
+			return fmt.Errorf("nested structs %s exceed the max depth of %d, see --max-depth", strings.Join(chain, " -> "), maxDepth)
+		}
+		references[pbStructName] = map[string]bool{}
+		for _, field := range pbStructManifest[pbStructName].Struct.Vars {
+			referenced, ok := g.referencedStruct(pbStructName, field, pbStructManifest)
+			if !ok {
+				continue
+			}
+			if g.isExcluded(referenced) {
+				return fmt.Errorf("struct %s is excluded but referenced by field %s.%s at %s", referenced, pbStructName, field.Name, g.pbPosition(field.Position))
+			}
+			if referenced != pbStructName {
+				references[pbStructName][referenced] = true
+			}
+			if err := collect(referenced, chain); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, root := range roots {
+		if err := collect(root, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	order := []string{}
+	ordered := map[string]bool{}
+	for len(order) < len(references) {
+		ready, remaining := []string{}, []string{}
+		for pbStructName, referenced := range references {
+			if ordered[pbStructName] {
+				continue
+			}
+			remaining = append(remaining, pbStructName)
+			isReady := true
+			for r := range referenced {
+				isReady = isReady && ordered[r]
+			}
+			if isReady {
+				ready = append(ready, pbStructName)
+			}
+		}
+		// only structs of reference cycles remain
+		if len(ready) == 0 {
+			ready = remaining
+		}
+		sort.Strings(ready)
+		order = append(order, ready[0])
+		ordered[ready[0]] = true
+	}
+	return order, nil
+}
+
+// parentStructs returns the pb structs referencing the given ones, directly or through other structs, sorted by name,
+// the excluded structs and the map entries are skipped, as are the structs only referencing them
+func (g *GenerateDTOFromProtoGo) parentStructs(pbStructNames []string, pbStructManifest map[string]*structState) []string {
+	// the structs referencing each struct
+	referrers := map[string][]string{}
+	for pbStructName, structState := range pbStructManifest {
+		for _, field := range structState.Struct.Vars {
+			if referenced, ok := g.referencedStruct(pbStructName, field, pbStructManifest); ok && referenced != pbStructName {
+				referrers[referenced] = append(referrers[referenced], pbStructName)
+			}
+		}
+	}
+
+	visited := map[string]bool{}
+	for _, pbStructName := range pbStructNames {
+		visited[pbStructName] = true
+	}
+	parents := []string{}
+	for queue := pbStructNames; len(queue) > 0; queue = queue[1:] {
+		for _, referrer := range referrers[queue[0]] {
+			if visited[referrer] {
+				continue
+			}
+			visited[referrer] = true
+			if g.isExcluded(referrer) || isMapEntry(pbStructManifest[referrer].Struct) {
+				logrus.Info("skipping parent struct: ", referrer, " it is excluded or a map entry")
+				continue
+			}
+			logrus.Info("including parent struct: ", referrer, " of ", queue[0])
+			parents = append(parents, referrer)
+			queue = append(queue, referrer)
+		}
+	}
+	sort.Strings(parents)
+	return parents
+}
+
+// referencedStruct returns the pb struct referenced by a field of a pb struct, e.g. Address for Addresses []*Address
+func (g *GenerateDTOFromProtoGo) referencedStruct(pbStructName string, field parser.NamedTypeValue, pbStructManifest map[string]*structState) (string, bool) {
+	if !ast.IsExported(field.Name) || g.isSkippedField(pbStructName, field) || g.mappingSpec.override(pbStructName, field.Name) != nil {
+		return "", false
+	}
+	if entry, ok := g.mapEntry(field.Type, pbStructManifest); ok {
+		// the entries are folded into a map, only the struct of their values is referenced
+		for _, entryField := range entry.Vars {
+			if entryField.Name == "Value" {
+				return g.referencedStruct(entry.Name, entryField, pbStructManifest)
+			}
+		}
+	}
+	fieldType, _, _, _ := parseFieldType(field.TypeInfo)
+	fieldType, importPath := g.resolveFieldType(fieldType)
+	if _, ok := pbStructManifest[fieldType]; !ok || importPath != "" {
+		return "", false
+	}
+	// a flattened wrapper has no dto
+	if _, ok := g.flattenedWrappers[fieldType]; ok {
+		return "", false
+	}
+	return fieldType, true
+}
+
+// findFlattenedWrappers returns the wrappers to flatten with options.FlattenWrappers keyed by pb struct name, with their single field,
+// a wrapper is a pb struct having a single field assigned as is in the bindings, e.g. a string, which is not generated
+// on its own, i.e. it is not a root, and is referenced by a single field of pb.go, a pointer to it, e.g. Name *NameWrapper
+func (g *GenerateDTOFromProtoGo) findFlattenedWrappers(roots []string, pbStructManifest map[string]*structState) map[string]parser.NamedTypeValue {
+	isRoot := map[string]bool{}
+	for _, root := range roots {
+		isRoot[root] = true
+	}
+
+	// the single field of the candidate wrappers
+	wrappers := map[string]parser.NamedTypeValue{}
+	for name, structState := range pbStructManifest {
+		fields := []parser.NamedTypeValue{}
+		for _, field := range structState.Struct.Vars {
+			if ast.IsExported(field.Name) && !g.isSkippedField(name, field) {
+				fields = append(fields, field)
+			}
+		}
+		if len(fields) != 1 || isRoot[name] || isMapEntry(structState.Struct) || g.mappingSpec.override(name, fields[0].Name) != nil {
+			continue
+		}
+		fieldType, _, _, _ := parseFieldType(fields[0].TypeInfo)
+		fieldType, importPath := g.resolveFieldType(fieldType)
+		_, isStructType := pbStructManifest[fieldType]
+		_, isEnum := g.pbEnums[fieldType]
+		_, isWellKnown := g.wellKnownType(importPath, fieldType, fields[0].Type)
+		if isStructType || isEnum || isWellKnown || importPath != "" || strings.Contains(fieldType, ".") {
+			continue
+		}
+		wrappers[name] = fields[0]
+	}
+
+	// a wrapper referenced more than once, or not through a pointer, is kept as a standalone message
+	references := map[string]int{}
+	for name, structState := range pbStructManifest {
+		for _, field := range structState.Struct.Vars {
+			fieldType, _, _, _ := parseFieldType(field.TypeInfo)
+			fieldType, importPath := g.resolveFieldType(fieldType)
+			if _, ok := wrappers[fieldType]; !ok || importPath != "" {
+				continue
+			}
+			references[fieldType]++
+			if field.Type != "*"+fieldType || isMapEntry(structState.Struct) || g.mappingSpec.override(name, field.Name) != nil {
+				references[fieldType]++
+			}
+		}
+	}
+	for name := range wrappers {
+		if references[name] != 1 {
+			delete(wrappers, name)
+			continue
+		}
+		logrus.Debug("flattening wrapper: ", name, " into its field: ", wrappers[name].Name)
+	}
+	return wrappers
+}
+
+// genDTO is the main func to generate dto structs
+// given an input pb struct, generate its dto struct and FromPB / ToPB bindings, the dto of the structs it references
+// are generated separately, see dtoStructOrder
+func (g *GenerateDTOFromProtoGo) genDTO(currentPBStruct parser.Struct, pbStructManifest map[string]*structState) {
+	pbStructManifest[currentPBStruct.Name].Visited = true
+
+	logrus.Info("generating dto for: ", currentPBStruct)
+
+	// maintain a manifest for all fields of currentPBStruct, in the same order as they are declared in pb.go
+	fieldManifest := []fieldState{}
+
+	dtoFields := []jen.Code{}
+	if g.options.EmbedBase != "" {
+		dtoFields = append(dtoFields, g.embedBaseField())
+	}
+
+	// loop over all fields of pb struct
+	for _, field := range currentPBStruct.Vars {
+		// unexported fields, e.g. the pb native fields state, sizeCache and unknownFields, cannot be set from another package
+		if !ast.IsExported(field.Name) {
+			logrus.Debug("skipping unexported field: ", field)
+			continue
+		}
+		if g.isSkippedField(currentPBStruct.Name, field) {
+			logrus.Debug("skipping field annotated with @dto:skip or ignored by .kitignore: ", field)
+			g.skippedFields = append(g.skippedFields, currentPBStruct.Name+"."+field.Name)
+			continue
+		}
+
+		logrus.Debug("inspecting field: ", field)
+		override := g.mappingSpec.override(currentPBStruct.Name, field.Name)
+		pbFieldType, mapEntryName := field.Type, ""
+		if entry, ok := g.mapEntry(field.Type, pbStructManifest); ok && override == nil {
+			// the entries of a legacy map are folded into a map, e.g. []*HelloRequest_LabelsEntry -> map[string]string
+			pbFieldType, mapEntryName = fmt.Sprintf("map[%s]%s", mapEntryFieldType(entry, "Key"), mapEntryFieldType(entry, "Value")), entry.Name
+			logrus.Debug("field holds the entries of a legacy map: ", field.Name, " folded into: ", pbFieldType)
+		}
+		collectionType, isCollectionPtr := collectionPtrType(pbFieldType)
+		if isCollectionPtr = isCollectionPtr && override == nil; isCollectionPtr {
+			// the dto holds the collection itself, e.g. *[]string -> []string
+			logrus.Debug("field is a pointer to a collection: ", field.Name, " mapped to: ", collectionType)
+			pbFieldType = collectionType
+		}
+		fieldType, isSlice, isMap, mapKeyType := parseFieldType(parser.ParseType(pbFieldType))
+		arrayLen := fieldArrayLen(pbFieldType)
+		fieldType, importPath := g.resolveFieldType(fieldType)
+		logrus.Debug("fieldType: ", fieldType, " importPath: ", importPath, " isSlice: ", isSlice, " isMap: ", isMap, " mapKeyType: ", mapKeyType)
+
+		// fieldType is not a struct if it is not in the manifest, but can be a map / slice of primitive types, e.g. map[string]string, []string
+		// or a type declared in another package, e.g. *commonpb.Metadata
+		_, isStructType := pbStructManifest[fieldType]
+		isStructType = isStructType && importPath == "" && override == nil
+		currentFieldState := fieldState{
+			Name:         field.Name,
+			PBType:       pbFieldType,
+			TypeName:     fieldType,
+			ImportPath:   importPath,
+			IsStructType: isStructType,
+			IsSlice:      isSlice,
+			ArrayLen:     arrayLen,
+			IsMap:        isMap,
+			MapKeyType:   mapKeyType,
+			Override:     override,
+			MapEntry:     mapEntryName,
+
+			IsCollectionPtr: isCollectionPtr,
+		}
+		currentFieldState.IsValueNested = g.options.ValueNested && isStructType && !isSlice && !isMap && arrayLen == "" &&
+			!g.isReachable(fieldType, currentPBStruct.Name, pbStructManifest)
+		if wrapped, ok := g.flattenedWrappers[fieldType]; ok && isStructType && arrayLen == "" {
+			// the field takes the type of the single field of the wrapper, e.g. Name *NameWrapper -> Name string
+			currentFieldState.Wrapper, currentFieldState.WrappedField = fieldType, wrapped.Name
+			currentFieldState.PBType, currentFieldState.IsStructType, currentFieldState.IsValueNested = wrapped.Type, false, false
+			currentFieldState.TypeName, currentFieldState.IsSlice, currentFieldState.IsMap, currentFieldState.MapKeyType = parseFieldType(wrapped.TypeInfo)
+			currentFieldState.ArrayLen = fieldArrayLen(wrapped.Type)
+		}
+		if override != nil {
+			logrus.Debug("field mapping is overridden by the mapping spec: ", field.Name)
+		} else if wellKnown, ok := g.wellKnownType(importPath, fieldType, pbFieldType); ok {
+			logrus.Debug("field is a well known type: ", fieldType)
+			currentFieldState.WellKnown = &wellKnown
+			g.usesAnyAsRaw = g.usesAnyAsRaw || wellKnown.importPath == anypbImportPath
+			g.usesEmpty = g.usesEmpty || wellKnown.importPath == emptypbImportPath
+			g.usesFieldMask = g.usesFieldMask || wellKnown.importPath == fieldmaskpbImportPath
+			g.usesTimestamp = g.usesTimestamp || wellKnown.importPath == timestamppbImportPath
+			if wellKnown.importPath == "" && strings.HasPrefix(pbFieldType, "*") {
+				g.usesInt64AsString["*"+wellKnown.name] = true
+			} else if wellKnown.importPath == "" {
+				g.usesInt64AsString[wellKnown.name] = true
+			} else if wellKnown.importPath == wrapperspbImportPath {
+				g.usesWrappers[wellKnown.name] = true
+			}
+		}
+		if currentFieldState.IsMap && override == nil {
+			var ok bool
+			if currentFieldState.MapKeyType, currentFieldState.MapKeyImportPath, ok = g.mapKeyType(currentFieldState.MapKeyType); !ok {
+				g.invalidMapKeyFields = append(g.invalidMapKeyFields,
+					fmt.Sprintf("%s.%s (%s) at %s", currentPBStruct.Name, field.Name, currentFieldState.PBType, g.pbPosition(field.Position)))
+				continue
+			}
+		}
+		if generated, ok := g.pbEnums[fieldType]; ok && importPath == "" && override == nil {
+			currentFieldState.IsEnum = true
+			currentFieldState.IsOptionalEnum = !isSlice && !isMap && arrayLen == "" && strings.HasPrefix(pbFieldType, "*")
+			if !generated {
+				g.genEnum(fieldType)
+			}
+		}
+		if isUnknownType(currentFieldState) && g.reflectFallbacks[currentPBStruct.Name] {
+			if oneofFields, ok := g.genOneofMemberFields(field.Name, fieldType, pbStructManifest); ok {
+				dtoFields = append(dtoFields, oneofFields...)
+			} else {
+				g.warnf("skipping field of unknown type: %s.%s (%s) at %s, it is not a oneof",
+					currentPBStruct.Name, field.Name, currentFieldState.PBType, g.pbPosition(field.Position))
+				g.skippedFields = append(g.skippedFields, currentPBStruct.Name+"."+field.Name)
+			}
+			continue
+		} else if isUnknownType(currentFieldState) {
+			unknownField := fmt.Sprintf("%s.%s (%s) at %s", currentPBStruct.Name, field.Name, currentFieldState.PBType, g.pbPosition(field.Position))
+			switch g.options.UnknownTypePolicy {
+			case UnknownTypeWarnAndSkip:
+				g.warnf("skipping field of unknown type: %s", unknownField)
+				g.skippedFields = append(g.skippedFields, currentPBStruct.Name+"."+field.Name)
+				continue
+			case UnknownTypeError:
+				g.unknownTypeFields = append(g.unknownTypeFields, unknownField)
+			default:
+				logrus.Debug("assigning field of unknown type as is: ", unknownField)
+			}
+		}
+		currentFieldState.Required = isRequiredField(field, currentFieldState)
+		currentFieldState.Redacted = isRedactedField(field)
+
+		jsonTagKey, jsonTagVal := utils.JsonTag(field.Name)
+		if jsonName, ok := protoJSONName(field.Tag); ok {
+			jsonTagVal = jsonName
+		}
+		if jsonTags := commentDirectives(field.Comment, "json"); len(jsonTags) > 0 {
+			jsonTagVal = jsonTags[0]
+		}
+		if isNoJSONField(field) {
+			jsonTagVal = "-"
+		}
+		currentFieldState.JSONName = strings.Split(jsonTagVal, ",")[0]
+		jsonTagVal = g.reflectFallbackTag(currentPBStruct.Name, currentFieldState, jsonTagVal)
+		fieldManifest = append(fieldManifest, currentFieldState)
+		dtoField := jen.Id(field.Name).Add(g.dtoFieldType(currentFieldState)).Tag(map[string]string{jsonTagKey: jsonTagVal})
+		comments := []string{}
+		if g.options.AnnotateFieldNumbers {
+			if fieldNumber, ok := protoFieldNumber(field.Tag); ok {
+				comments = append(comments, fmt.Sprintf("proto field %d", fieldNumber))
+			}
+		}
+		if g.options.AnnotatePBTypes && currentFieldState.pbTypeChanged() {
+			comments = append(comments, fmt.Sprintf("pb: %s", field.Type))
+		}
+		if currentFieldState.Wrapper != "" && currentFieldState.isNilable() {
+			comments = append(comments, fmt.Sprintf("flattened from %s.%s (--flatten-wrappers)", currentFieldState.Wrapper, currentFieldState.WrappedField))
+		} else if currentFieldState.Wrapper != "" {
+			// a nil wrapper and a wrapped zero value are both a zero value in dto, ToPB always sets the wrapper
+			comments = append(comments, fmt.Sprintf("flattened from %s.%s (--flatten-wrappers), ToPB always sets %s",
+				currentFieldState.Wrapper, currentFieldState.WrappedField, currentFieldState.Wrapper))
+		}
+		if len(comments) > 0 {
+			dtoField.Comment(strings.Join(comments, ", "))
+		}
+		if notice, ok := deprecationNotice(field.Comment); ok && g.options.PropagateDeprecation {
+			dtoFields = append(dtoFields, jen.Comment(notice))
+		}
+		dtoFields = append(dtoFields, dtoField)
+	}
+
+	g.fieldManifests[currentPBStruct.Name] = fieldManifest
 
-	for fieldName, fieldState := range fieldManifest {
+	// dto struct name is the same as pb go struct name, plus the optional prefix / suffix
+	g.code.appendStruct(g.dtoTypeName(currentPBStruct.Name), dtoFields...)
+	if g.options.WithFieldConstants {
+		g.genFieldConstants(currentPBStruct.Name, fieldManifest)
+	}
+	if g.options.WithConstructors {
+		g.genConstructor(currentPBStruct.Name, fieldManifest)
+	}
+	if g.options.WithBuilder {
+		g.genBuilder(currentPBStruct.Name, fieldManifest)
+	}
+	if g.options.WithStringer {
+		g.genStringer(currentPBStruct.Name, fieldManifest)
+	}
+	if g.options.WithGetters {
+		g.genGetters(currentPBStruct.Name, fieldManifest)
+	}
+	g.genMarshalJSON(currentPBStruct.Name, fieldManifest)
+	if g.options.WithJSONHelpers {
+		g.genJSONHelpers(currentPBStruct.Name)
+	}
+
+	if g.reflectFallbacks[currentPBStruct.Name] {
+		g.genReflectFallbackBindings(currentPBStruct.Name)
+	} else {
+		g.genBindingFromPB(currentPBStruct.Name, fieldManifest)
+		g.genBindingToPB(currentPBStruct.Name, fieldManifest)
+	}
+	if g.options.MapperInterface {
+		g.genMapper(currentPBStruct.Name)
+	}
+}
+
+// isMapEntry tells if a pb struct is the synthetic entry of a map generated by legacy protoc plugins,
+// i.e. a *Entry struct having exactly a Key and a Value field
+func isMapEntry(pbStruct parser.Struct) bool {
+	if !strings.HasSuffix(pbStruct.Name, "Entry") {
+		return false
+	}
+	fieldNames := []string{}
+	for _, field := range pbStruct.Vars {
+		if ast.IsExported(field.Name) {
+			fieldNames = append(fieldNames, field.Name)
+		}
+	}
+	sort.Strings(fieldNames)
+	return len(fieldNames) == 2 && fieldNames[0] == "Key" && fieldNames[1] == "Value"
+}
+
+// mapEntry returns the map entry struct of a field holding the entries of a legacy map, e.g. Labels []*HelloRequest_LabelsEntry
+func (g *GenerateDTOFromProtoGo) mapEntry(fieldType string, pbStructManifest map[string]*structState) (parser.Struct, bool) {
+	typeName, isSlice, _, _ := parseFieldType(parser.ParseType(fieldType))
+	typeName, importPath := g.resolveFieldType(typeName)
+	structState, ok := pbStructManifest[typeName]
+	if !ok || !isSlice || importPath != "" || !isMapEntry(structState.Struct) {
+		return parser.Struct{}, false
+	}
+	return structState.Struct, true
+}
+
+// mapEntryFieldType returns the type of the Key or Value field of a map entry struct
+func mapEntryFieldType(entry parser.Struct, fieldName string) string {
+	for _, field := range entry.Vars {
+		if field.Name == fieldName {
+			return field.Type
+		}
+	}
+	return ""
+}
+
+// isExcluded tells if a pb struct matches one of the exclusion patterns or a struct pattern of .kitignore
+func (g *GenerateDTOFromProtoGo) isExcluded(pbStructName string) bool {
+	if g.kitignore.excludes(pbStructName) {
+		return true
+	}
+	for _, pattern := range g.options.Exclude {
+		if matched, _ := path.Match(pattern, pbStructName); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// dtoFieldType returns the type of a field in the dto struct
+// struct field types refer to the dto type, which can be renamed, e.g. []*Address -> []*AddressDTO
+// and well known types are replaced by their dto representation, e.g. *structpb.Struct -> map[string]interface{}
+func (g *GenerateDTOFromProtoGo) dtoFieldType(fieldState fieldState) jen.Code {
+	if fieldState.Override != nil {
+		if fieldState.Override.Type != "" {
+			return g.mappingSpec.code(fieldState.Override.Type, nil)
+		}
+		return jen.Id(fieldState.PBType)
+	}
+	if !fieldState.isConverted() && fieldState.ImportPath == "" || fieldState.Wrapper != "" {
+		return jen.Id(fieldState.PBType)
+	}
+
+	if fieldState.IsMap {
+		return jen.Map(fieldState.mapKeyType()).Add(g.dtoElemType(fieldState))
+	} else if fieldState.IsSlice {
+		return jen.Index().Add(g.dtoElemType(fieldState))
+	} else if fieldState.ArrayLen != "" {
+		return jen.Index(jen.Id(fieldState.ArrayLen)).Add(g.dtoElemType(fieldState))
+	} else if fieldState.IsValueNested {
+		return jen.Qual(g.dtoImportPath, g.dtoTypeName(fieldState.TypeName))
+	} else if fieldState.IsOptionalEnum {
+		return jen.Id("*").Add(g.dtoElemType(fieldState))
+	}
+	return g.dtoElemType(fieldState)
+}
+
+// isReachable tells if the pb struct to can be reached from the pb struct from through the struct fields,
+// a struct reachable from its own fields cannot be embedded by value as its type would be recursive
+func (g *GenerateDTOFromProtoGo) isReachable(from, to string, pbStructManifest map[string]*structState) bool {
+	visited := map[string]bool{}
+	var visit func(pbStructName string) bool
+	visit = func(pbStructName string) bool {
+		if pbStructName == to {
+			return true
+		}
+		if visited[pbStructName] {
+			return false
+		}
+		visited[pbStructName] = true
+		for _, field := range pbStructManifest[pbStructName].Struct.Vars {
+			if referenced, ok := g.referencedStruct(pbStructName, field, pbStructManifest); ok && visit(referenced) {
+				return true
+			}
+		}
+		return false
+	}
+	return visit(from)
+}
+
+// commentDirectives returns the values of the @<name>:<value> directives starting the lines of a pb field comment,
+// e.g. "custom_name,omitempty" for the json directive of the comment:
+//
+//	// @json:custom_name,omitempty
+func commentDirectives(comment, name string) []string {
+	values := []string{}
+	for _, line := range strings.Split(comment, "\n") {
+		value := strings.TrimPrefix(strings.TrimSpace(line), "@"+name+":")
+		if value == strings.TrimSpace(line) {
+			continue
+		}
+		if fields := strings.Fields(value); len(fields) > 0 {
+			values = append(values, fields[0])
+		}
+	}
+	return values
+}
+
+// isSkippedField tells if a field of a pb struct is excluded from its dto with the @dto:skip directive of its comment, e.g.
+//
+//	// @dto:skip
+//
+// or by a Struct.Field pattern of .kitignore, see kitignore
+func (g *GenerateDTOFromProtoGo) isSkippedField(pbStructName string, field parser.NamedTypeValue) bool {
+	if g.kitignore.ignores(pbStructName, field.Name) {
+		return true
+	}
+	for _, value := range commentDirectives(field.Comment, "dto") {
+		if value == "skip" {
+			return true
+		}
+	}
+	return false
+}
+
+// isNoJSONField tells if a field is kept in its dto but not marshalled to json with the @dto:nojson directive of its comment,
+// e.g. an internal pointer, the field being tagged json:"-", as with @json:-
+//
+//	// @dto:nojson
+func isNoJSONField(field parser.NamedTypeValue) bool {
+	for _, value := range commentDirectives(field.Comment, "dto") {
+		if value == "nojson" {
+			return true
+		}
+	}
+	return false
+}
+
+// deprecationNotice returns the Deprecated: paragraph of a pb field comment, as protoc-gen-go writes for the deprecated fields, e.g.
+//
+//	// Deprecated: Marked as deprecated in hello.proto.
+func deprecationNotice(comment string) (string, bool) {
+	for _, line := range strings.Split(comment, "\n") {
+		if line = strings.TrimSpace(line); strings.HasPrefix(line, "Deprecated:") {
+			return line, true
+		}
+	}
+	return "", false
+}
+
+// protoFieldNumber extracts the field number from the protobuf tag of a pb struct field
+// e.g. protobuf:"bytes,3,opt,name=foo,proto3" -> 3
+func protoFieldNumber(tag string) (int, bool) {
+	protobufTag, ok := reflect.StructTag(tag).Lookup("protobuf")
+	if !ok {
+		return 0, false
+	}
+	parts := strings.Split(protobufTag, ",")
+	if len(parts) < 2 {
+		return 0, false
+	}
+	fieldNumber, err := strconv.Atoi(parts[1])
+	if err != nil || fieldNumber <= 0 {
+		return 0, false
+	}
+	return fieldNumber, true
+}
+
+// protoJSONName extracts the json name declared in proto from the protobuf tag of a pb struct field, as used by protojson,
+// e.g. protobuf:"bytes,3,opt,name=user_id,json=uid,proto3" -> uid, protoc omits it when it is the name of the field
+func protoJSONName(tag string) (string, bool) {
+	protobufTag, ok := reflect.StructTag(tag).Lookup("protobuf")
+	if !ok {
+		return "", false
+	}
+	for _, part := range strings.Split(protobufTag, ",") {
+		if strings.HasPrefix(part, "json=") && len(part) > len("json=") {
+			return strings.TrimPrefix(part, "json="), true
+		}
+	}
+	return "", false
+}
+
+// isRequiredField tells if a field must be provided to the dto constructor
+// a field with a `validate` tag is required only if the tag contains `required`,
+// otherwise non-pointer, non-collection fields (i.e. scalars) are required
+func isRequiredField(field parser.NamedTypeValue, fieldState fieldState) bool {
+	if validateTag, ok := reflect.StructTag(field.Tag).Lookup("validate"); ok {
+		for _, rule := range strings.Split(validateTag, ",") {
+			if rule == "required" {
+				return true
+			}
+		}
+		return false
+	}
+	return !strings.HasPrefix(field.Type, "*") && !fieldState.IsSlice && !fieldState.IsMap
+}
+
+// genConstructor generates the constructor of a dto taking its required fields as parameters, optional fields are left zero
+// e.g. func NewSomething(name string, other *Other) *Something {...}
+func (g *GenerateDTOFromProtoGo) genConstructor(currentPBStructName string, fieldManifest []fieldState) {
+	parameters := []jen.Code{}
+	assignments := jen.Dict{}
+	for _, fieldState := range fieldManifest {
+		if !fieldState.Required {
+			continue
+		}
+
+		paramName := utils.ToLowerFirstCamelCase(fieldState.Name)
+		if token.Lookup(paramName).IsKeyword() {
+			paramName += "Arg"
+		}
+		parameters = append(parameters, jen.Id(paramName).Add(g.dtoFieldType(fieldState)))
+		assignments[jen.Id(fieldState.Name)] = jen.Id(paramName)
+	}
+
+	g.code.appendFunction(
+		"New"+g.dtoTypeName(currentPBStructName),
+		nil,
+		parameters,
+		[]jen.Code{
+			jen.Id("").Id("*").Qual(g.dtoImportPath, g.dtoTypeName(currentPBStructName)),
+		},
+		"",
+		jen.Return(jen.Id("&").Qual(g.dtoImportPath, g.dtoTypeName(currentPBStructName)).Values(assignments)),
+	)
+	g.code.NewLine()
+	g.code.NewLine()
+}
+
+// dtoElemType returns the dto type of a single struct / well known value of the field, i.e. the map value or slice element type for collections
+func (g *GenerateDTOFromProtoGo) dtoElemType(fieldState fieldState) jen.Code {
+	if fieldState.WellKnown != nil {
+		return fieldState.WellKnown.dtoType()
+	} else if fieldState.ImportPath != "" {
+		// types of other packages are kept as is, e.g. *commonpb.Metadata
+		return g.pbElemType(fieldState)
+	} else if fieldState.IsEnum {
+		return jen.Qual(g.dtoImportPath, g.dtoTypeName(fieldState.TypeName))
+	}
+	return jen.Id("*").Qual(g.dtoImportPath, g.dtoTypeName(fieldState.TypeName))
+}
+
+// pbCollectionType returns the pb type of a collection field, e.g. []*pb.Address for Addresses []*Address
+func (g *GenerateDTOFromProtoGo) pbCollectionType(fieldState fieldState) jen.Code {
+	if !fieldState.isConverted() && fieldState.ImportPath == "" {
+		return jen.Id(fieldState.PBType)
+	} else if fieldState.IsMap {
+		return jen.Map(fieldState.mapKeyType()).Add(g.pbElemType(fieldState))
+	} else if fieldState.ArrayLen != "" {
+		return jen.Index(jen.Id(fieldState.ArrayLen)).Add(g.pbElemType(fieldState))
+	}
+	return jen.Index().Add(g.pbElemType(fieldState))
+}
+
+// pbElemType returns the pb type of a single struct / well known value of the field, i.e. the map value or slice element type for collections
+func (g *GenerateDTOFromProtoGo) pbElemType(fieldState fieldState) jen.Code {
+	if fieldState.WellKnown != nil && fieldState.WellKnown.importPath == "" {
+		return jen.Id(fieldState.WellKnown.name)
+	} else if fieldState.WellKnown != nil {
+		return jen.Id("*").Qual(fieldState.WellKnown.importPath, fieldState.WellKnown.name)
+	} else if fieldState.ImportPath != "" {
+		if strings.Contains(fieldState.PBType, "*") {
+			return jen.Id("*").Qual(fieldState.ImportPath, fieldState.TypeName)
+		}
+		return jen.Qual(fieldState.ImportPath, fieldState.TypeName)
+	} else if fieldState.IsEnum {
+		return jen.Qual(g.pbPackagePath, fieldState.TypeName)
+	}
+	return jen.Id("*").Add(g.pbStructType(fieldState.TypeName))
+}
+
+// fromPBConversion returns the expression converting a single pb value src of the field to its dto value,
+// and whether the conversion also returns an error
+func (g *GenerateDTOFromProtoGo) fromPBConversion(fieldState fieldState, src jen.Code) (jen.Code, bool) {
+	if fieldState.WellKnown != nil {
+		return fieldState.WellKnown.fromPB(src), false
+	} else if fieldState.IsEnum {
+		return jen.Qual(g.dtoImportPath, g.dtoTypeName(fieldState.TypeName)).Call(src), false
+	}
+	return g.bindingCall(g.fromPBFuncName(fieldState.TypeName), jen.Id("ctx"), src), g.fallibleFromPB[fieldState.TypeName]
+}
+
+// toPBConversion returns the expression converting a single dto value src of the field to its pb value,
+// and whether the conversion also returns an error
+func (g *GenerateDTOFromProtoGo) toPBConversion(fieldState fieldState, src jen.Code) (jen.Code, bool) {
+	if fieldState.WellKnown != nil {
+		return fieldState.WellKnown.toPB(src), fieldState.WellKnown.toPBReturnsError
+	} else if fieldState.IsEnum {
+		return jen.Qual(g.pbPackagePath, fieldState.TypeName).Call(src), false
+	}
+	return g.bindingCall(g.toPBFuncName(fieldState.TypeName), jen.Id("ctx"), src), g.fallibleToPB[fieldState.TypeName]
+}
+
+// bindingParams returns the params of a FromPB / ToPB binding, preceded by ctx context.Context when options.CtxBindings is set
+func (g *GenerateDTOFromProtoGo) bindingParams(params ...jen.Code) []jen.Code {
+	if g.options.CtxBindings {
+		return append([]jen.Code{jen.Id("ctx").Qual("context", "Context")}, params...)
+	}
+	return params
+}
+
+// bindingCall returns the call of a FromPB / ToPB binding with arg, preceded by ctx when options.CtxBindings is set
+func (g *GenerateDTOFromProtoGo) bindingCall(binding string, ctx, arg jen.Code) *jen.Statement {
+	if g.options.CtxBindings {
+		return jen.Id(binding).Call(ctx, arg)
+	}
+	return jen.Id(binding).Call(arg)
+}
+
+// bindingStdImports are the standard packages the bindings may use, their names are not used for local variables
+var bindingStdImports = []string{"context", "encoding/json", "fmt", "strconv", "time"}
+
+// localNames allocates the names of the local variables of a binding, see name
+type localNames map[string]bool
+
+// newLocalNames returns the local names of a binding, reserving the names of its params, of the variables declared
+// in its loops and of the packages it may use, so that a local variable never shadows them
+func (g *GenerateDTOFromProtoGo) newLocalNames() localNames {
+	names := localNames{}
+	for _, name := range []string{"ctx", "pb", "orig", "res", "err", "i", "k", "v", "e", "entry"} {
+		names[name] = true
+	}
+	for _, alias := range g.importAliases {
+		names[alias] = true
+	}
+	for _, importPath := range bindingStdImports {
+		names[path.Base(importPath)] = true
+	}
+	return names
+}
+
+// name returns a unique local name based on base, a name already used, a go keyword or a predeclared identifier
+// gets a numbered suffix, e.g. m, m1, m2 for the maps of a binding, or type1 for a field Type
+func (n localNames) name(base string) string {
+	unique := base
+	for i := 1; n[unique] || token.Lookup(unique).IsKeyword() || types.Universe.Lookup(unique) != nil; i++ {
+		unique = fmt.Sprintf("%s%d", base, i)
+	}
+	n[unique] = true
+	return unique
+}
+
+// convert returns the expression holding the result of conversion, preceded by the statements checking its error
+// when the conversion of a field of a pb struct is fallible, the error being wrapped with the field, see genConversionError,
+// e.g. for `structpb.NewStruct(orig.Field)`:
+//
+//	field, err := structpb.NewStruct(orig.Field)
+//	if err != nil {
+//		return nil, conversionError("HelloRequest", "Field", err)
+//	}
+func (g *GenerateDTOFromProtoGo) convert(conversion jen.Code, fallible bool, varName, pbStructName, fieldName string) ([]jen.Code, jen.Code) {
+	if !fallible {
+		return nil, conversion
+	}
+
+	g.usesConversionError = true
+	return []jen.Code{
+		jen.List(jen.Id(varName), jen.Err()).Op(":=").Add(conversion),
+		jen.If(jen.Err().Op("!=").Nil()).Block(
+			jen.Return(jen.Nil(), jen.Id("conversionError").Call(jen.Lit(g.dtoTypeName(pbStructName)), jen.Lit(fieldName), jen.Err())),
+		),
+	}, jen.Id(varName)
+}
+
+// pbCollectionPtr returns the local variable holding the collection of a pointer to collection field of pb in a FromPB binding,
+// and the statements reading it, the collection is nil when the pointer is nil, e.g.
+//
+//	var tags []string
+//	if pb.Tags != nil {
+//		tags = *pb.Tags
+//	}
+func (g *GenerateDTOFromProtoGo) pbCollectionPtr(fieldState fieldState, names localNames) (func() *jen.Statement, []jen.Code) {
+	varName := names.name(utils.ToLowerFirstCamelCase(fieldState.Name))
+	return func() *jen.Statement { return jen.Id(varName) }, []jen.Code{
+		jen.Var().Id(varName).Add(g.pbCollectionType(fieldState)),
+		jen.If(jen.Id("pb").Dot(fieldState.Name).Op("!=").Nil()).
+			Block(jen.Id(varName).Op("=").Op("*").Id("pb").Dot(fieldState.Name)),
+	}
+}
+
+// pbField returns the expression reading a field of the pb struct in a FromPB binding, preferring its getter when pb.go has one
+// returning the field type, e.g. pb.GetName() instead of pb.Name, as the getters are nil-safe,
+// the getter of a proto3 optional scalar returns the value rather than the pointer, so its field is read as is
+func (g *GenerateDTOFromProtoGo) pbField(pbStructName string, fieldState fieldState) *jen.Statement {
+	if resultType, ok := g.pbGetters[pbStructName+"."+fieldState.Name]; ok && resultType == fieldState.PBType {
+		return jen.Id("pb").Dot("Get" + fieldState.Name).Call()
+	}
+	return jen.Id("pb").Dot(fieldState.Name)
+}
+
+func (g *GenerateDTOFromProtoGo) genBindingFromPB(currentPBStructName string, fieldManifest []fieldState) {
+	// a fallible FromPB returns (*Something, error)
+	fallible := g.fallibleFromPB[currentPBStructName]
+	results := []jen.Code{
+		jen.Id("").Id("*").Qual(g.dtoImportPath, g.dtoTypeName(currentPBStructName)),
+	}
+	nilReturn := []jen.Code{jen.Nil()}
+	if fallible {
+		results = append(results, jen.Error())
+		nilReturn = append(nilReturn, jen.Nil())
+	}
+
+	funcBodyForFromPB := []jen.Code{
+		jen.If(jen.Id("pb").Id("==").Nil()).
+			Block(jen.Return(nilReturn...)).Line(),
+	}
+	assignmentsForFromPB := jen.Dict{}
+	names := g.newLocalNames()
+
+	for _, fieldState := range fieldManifest {
+		fieldName := fieldState.Name
 		logrus.Debug("genBindingFromPB: ", "field name: ", fieldName, " fieldState: ", fieldState)
 
-		// if field is not a struct, only need assignment line:
+		// if field is neither a struct, an enum nor a well known type, only need assignment line:
 		// `AStringField := pb.AStringField`
-		if !fieldState.IsStructType {
-			assignmentsForFromPB[jen.Id(fieldName)] = jen.Id("pb").Dot(fieldName)
+		if fieldState.Override != nil {
+			assignmentsForFromPB[jen.Id(fieldName)] = g.overriddenValue(fieldState.Override.FromPB, g.pbField(currentPBStructName, fieldState))
+			continue
+		}
+		if fieldState.Wrapper != "" {
+			// var name string
+			// if pb.Name != nil {
+			//		name = pb.Name.Value
+			//}
+			varName := names.name(utils.ToLowerFirstCamelCase(fieldName))
+			funcBodyForFromPB = append(funcBodyForFromPB,
+				jen.Var().Id(varName).Add(g.dtoFieldType(fieldState)),
+				jen.If(g.pbField(currentPBStructName, fieldState).Op("!=").Nil()).
+					Block(jen.Id(varName).Op("=").Id("pb").Dot(fieldName).Dot(fieldState.WrappedField)),
+			)
+
+			// Name = name
+			assignmentsForFromPB[jen.Id(fieldName)] = jen.Id(varName)
+			continue
+		}
+		if fieldState.MapEntry != "" {
+			// m := make(map[string]*Address, len(pb.Addresses))
+			// for _, entry := range pb.Addresses {
+			//		m[entry.Key] = AddressFromPB(entry.Value)
+			//}
+			var checks []jen.Code
+			var value jen.Code = jen.Id("entry").Dot("Value")
+			if fieldState.isConverted() {
+				conversion, fallible := g.fromPBConversion(fieldState, value)
+				checks, value = g.convert(conversion, fallible, "e", currentPBStructName, fieldName)
+			}
+			m := names.name("m")
+			funcBodyForFromPB = append(funcBodyForFromPB,
+				jen.Id(m).Op(":=").Make(g.dtoFieldType(fieldState), jen.Len(g.pbField(currentPBStructName, fieldState))),
+				jen.For(
+					jen.Id("_").Op(`,`).Id("entry").Op(":=").Range().Id("pb").Dot(fieldName).
+						Block(append(checks, jen.Id(m).Index(jen.Id("entry").Dot("Key")).Op("=").Add(value))...)),
+			)
+
+			// Addresses = m
+			assignmentsForFromPB[jen.Id(fieldName)] = jen.Id(m)
+			continue
+		}
+		// the collections are read from pb, or from a local variable for a pointer to collection, see pbCollectionPtr
+		pbField := func() *jen.Statement { return g.pbField(currentPBStructName, fieldState) }
+		pbCollection := func() *jen.Statement { return jen.Id("pb").Dot(fieldName) }
+		if fieldState.IsCollectionPtr {
+			collection, checks := g.pbCollectionPtr(fieldState, names)
+			funcBodyForFromPB = append(funcBodyForFromPB, checks...)
+			pbField, pbCollection = collection, collection
+		}
+		if !fieldState.isConverted() {
+			assignmentsForFromPB[jen.Id(fieldName)] = g.assignedValue(fieldState, pbField())
 			continue
 		}
 
@@ -235,69 +2131,270 @@ func (g *GenerateDTOFromProtoGo) genBindingFromPB(currentPBStructName string, fi
 			// for k, v := range pb.Addresses {
 			//		m[k] = AddressFromPB(v)
 			//}
+			conversion, fallible := g.fromPBConversion(fieldState, jen.Id("v"))
+			checks, result := g.convert(conversion, fallible, "e", currentPBStructName, fieldName)
+			m := names.name("m")
 			funcBodyForFromPB = append(funcBodyForFromPB,
-				jen.Id("m").Op(":=").Make(jen.Map(jen.Id(fieldState.MapKeyType)).Id("*").Qual(g.dtoPackagePath, fieldState.TypeName), jen.Len(jen.Id("pb").Dot(fieldName))),
+				jen.Id(m).Op(":=").Make(jen.Map(fieldState.mapKeyType()).Add(g.dtoElemType(fieldState)), jen.Len(pbField())),
 				jen.For(
-					jen.Id("k").Op(`,`).Id("v").Op(":=").Range().Qual(g.pbPackagePath, fieldName).
-						Block(jen.Id("m").Index(jen.Id("k")).Op("=").Id(fieldState.TypeName+"FromPB").Call(jen.Id("v")))),
+					jen.Id("k").Op(`,`).Id("v").Op(":=").Range().Add(pbCollection()).
+						Block(append(checks, jen.Id(m).Index(jen.Id("k")).Op("=").Add(result))...)),
 			)
 
 			// Addresses = m
-			assignmentsForFromPB[jen.Id(fieldName)] = jen.Id("m")
+			assignmentsForFromPB[jen.Id(fieldName)] = jen.Id(m)
 		} else if fieldState.IsSlice {
 			// aSlice := make([]*Address, 0, len(pb.Addresses))
 			// for _, v := range pb.Addresses {
 			//		aSlice = append(aSlice, AddressFromPB(v))
 			//}
+			conversion, fallible := g.fromPBConversion(fieldState, jen.Id("v"))
+			checks, result := g.convert(conversion, fallible, "e", currentPBStructName, fieldName)
+			aSlice := names.name("aSlice")
 			funcBodyForFromPB = append(funcBodyForFromPB,
-				jen.Id("aSlice").Op(":=").Make(jen.Index().Id("*").Qual(g.dtoPackagePath, fieldState.TypeName), jen.Lit(0), jen.Len(jen.Id("pb").Dot(fieldName))),
+				jen.Id(aSlice).Op(":=").Make(jen.Index().Add(g.dtoElemType(fieldState)), jen.Lit(0), jen.Len(pbField())),
 				jen.For(
-					jen.Id("_").Op(`,`).Id("v").Op(":=").Range().Qual(g.pbPackagePath, fieldName).
-						Block(jen.Id("aSlice").Op("=").Append(jen.Id("aSlice"), jen.Id(fieldState.TypeName+"FromPB").Call(jen.Id("v"))))),
+					jen.Id("_").Op(`,`).Id("v").Op(":=").Range().Add(pbCollection()).
+						Block(append(checks, jen.Id(aSlice).Op("=").Append(jen.Id(aSlice), result))...)),
 			)
 
 			// Addresses = aSlice
-			assignmentsForFromPB[jen.Id(fieldName)] = jen.Id("aSlice")
+			assignmentsForFromPB[jen.Id(fieldName)] = jen.Id(aSlice)
+		} else if fieldState.ArrayLen != "" {
+			// var arr [4]*Address
+			// for i, v := range pb.Addresses {
+			//		arr[i] = AddressFromPB(v)
+			//}
+			conversion, fallible := g.fromPBConversion(fieldState, jen.Id("v"))
+			checks, result := g.convert(conversion, fallible, "e", currentPBStructName, fieldName)
+			arr := names.name("arr")
+			funcBodyForFromPB = append(funcBodyForFromPB,
+				jen.Var().Id(arr).Add(g.dtoFieldType(fieldState)),
+				jen.For(
+					jen.Id("i").Op(`,`).Id("v").Op(":=").Range().Add(pbField()).
+						Block(append(checks, jen.Id(arr).Index(jen.Id("i")).Op("=").Add(result))...)),
+			)
+
+			// Addresses = arr
+			assignmentsForFromPB[jen.Id(fieldName)] = jen.Id(arr)
+		} else if fieldState.IsValueNested {
+			// var address Address
+			// if pb.Address != nil {
+			//		address = *AddressFromPB(pb.Address)
+			//}
+			varName := names.name(utils.ToLowerFirstCamelCase(fieldName))
+			conversion, fallible := g.fromPBConversion(fieldState, g.pbField(currentPBStructName, fieldState))
+			checks, result := g.convert(conversion, fallible, "v", currentPBStructName, fieldName)
+			funcBodyForFromPB = append(funcBodyForFromPB,
+				jen.Var().Id(varName).Add(g.dtoFieldType(fieldState)),
+				jen.If(g.pbField(currentPBStructName, fieldState).Op("!=").Nil()).
+					Block(append(checks, jen.Id(varName).Op("=").Op("*").Add(result))...),
+			)
+
+			// Address = address
+			assignmentsForFromPB[jen.Id(fieldName)] = jen.Id(varName)
+		} else if fieldState.IsOptionalEnum {
+			// var status *Status
+			// if pb.Status != nil {
+			//		v := Status(*pb.Status)
+			//		status = &v
+			//}
+			varName := names.name(utils.ToLowerFirstCamelCase(fieldName))
+			conversion, _ := g.fromPBConversion(fieldState, jen.Op("*").Add(pbField()))
+			funcBodyForFromPB = append(funcBodyForFromPB,
+				jen.Var().Id(varName).Add(g.dtoFieldType(fieldState)),
+				jen.If(pbField().Op("!=").Nil()).
+					Block(jen.Id("v").Op(":=").Add(conversion), jen.Id(varName).Op("=").Op("&").Id("v")),
+			)
+
+			// Status = status
+			assignmentsForFromPB[jen.Id(fieldName)] = jen.Id(varName)
 		} else {
 			// field is a single struct, we add only assignment:
 			// Address = AddressFromPB(pb.Address)
-			assignmentsForFromPB[jen.Id(fieldName)] = jen.Id(fieldState.TypeName + "FromPB").Call(jen.Id("pb").Dot(fieldName))
+			conversion, fallible := g.fromPBConversion(fieldState, g.pbField(currentPBStructName, fieldState))
+			varName := utils.ToLowerFirstCamelCase(fieldName)
+			if fallible {
+				varName = names.name(varName)
+			}
+			checks, result := g.convert(conversion, fallible, varName, currentPBStructName, fieldName)
+			funcBodyForFromPB = append(funcBodyForFromPB, checks...)
+			assignmentsForFromPB[jen.Id(fieldName)] = result
 		}
 	}
 
+	if mapping := g.embedBaseMapping(currentPBStructName); mapping != nil && mapping.FromPB != "" {
+		_, baseTypeName := g.embedBase()
+		assignmentsForFromPB[jen.Id(baseTypeName)] = g.mappingSpec.code(mapping.FromPB, jen.Id("pb"))
+	}
+
 	// add assignments to the end of func body
-	funcBodyForFromPB = append(funcBodyForFromPB, jen.Return(jen.Id("&").Qual(g.dtoPackagePath, currentPBStructName).Values(assignmentsForFromPB)))
+	dtoValue := jen.Id("&").Qual(g.dtoImportPath, g.dtoTypeName(currentPBStructName)).Values(assignmentsForFromPB)
+	if fallible {
+		funcBodyForFromPB = append(funcBodyForFromPB, jen.Return(dtoValue, jen.Nil()))
+	} else {
+		funcBodyForFromPB = append(funcBodyForFromPB, jen.Return(dtoValue))
+	}
 
-	g.code.appendFunction(
-		fmt.Sprintf("%sFromPB", currentPBStructName),
+	g.bindingsCode.appendFunction(
+		g.fromPBFuncName(currentPBStructName),
 		nil,
-		[]jen.Code{
-			jen.Id("pb").Id("*").Qual(g.pbPackagePath, currentPBStructName),
-		},
-		[]jen.Code{
-			jen.Id("").Id("*").Qual(g.dtoPackagePath, currentPBStructName),
-		},
+		g.bindingParams(jen.Id("pb").Id("*").Add(g.pbStructType(currentPBStructName))),
+		results,
 		"",
 		funcBodyForFromPB...,
 	)
-	g.code.NewLine()
-	g.code.NewLine()
+	g.bindingsCode.NewLine()
+	g.bindingsCode.NewLine()
 }
 
-func (g *GenerateDTOFromProtoGo) genBindingToPB(currentPBStructName string, fieldManifest map[string]fieldState) {
+func (g *GenerateDTOFromProtoGo) genBindingToPB(currentPBStructName string, fieldManifest []fieldState) {
+	// a fallible ToPB returns (*pb.Something, error)
+	fallible := g.fallibleToPB[currentPBStructName]
+	results := []jen.Code{
+		jen.Id("").Id("*").Add(g.pbStructType(currentPBStructName)),
+	}
+	nilReturn := []jen.Code{jen.Nil()}
+	if fallible {
+		results = append(results, jen.Error())
+		nilReturn = append(nilReturn, jen.Nil())
+	}
+
 	funcBodyForToPB := []jen.Code{
 		jen.If(jen.Id("orig").Id("==").Nil()).
-			Block(jen.Return(jen.Nil())).Line(),
+			Block(jen.Return(nilReturn...)).Line(),
 	}
 	assignmentsForToPB := jen.Dict{}
+	names := g.newLocalNames()
+	// with options.Proto3ZeroOmit, scalar fields are only assigned when they are not zero
+	zeroOmitGuards := []jen.Code{}
+	// collectionPtr returns the value of a pb field from the value of its collection, i.e. the collection itself or,
+	// for a pointer to collection, a local variable pointing to it, nil when the dto collection is nil, e.g.
+	//
+	//	var tags *[]string
+	//	if orig.Tags != nil {
+	//		v := orig.Tags
+	//		tags = &v
+	//	}
+	collectionPtr := func(fieldState fieldState, value jen.Code) jen.Code {
+		if !fieldState.IsCollectionPtr {
+			return value
+		}
+		varName := names.name(utils.ToLowerFirstCamelCase(fieldState.Name))
+		setPtr := []jen.Code{jen.Id("v").Op(":=").Add(value), jen.Id(varName).Op("=").Op("&").Id("v")}
+		funcBodyForToPB = append(funcBodyForToPB, jen.Var().Id(varName).Op("*").Add(g.pbCollectionType(fieldState)))
+		if fieldState.ArrayLen != "" {
+			// an array is never nil
+			funcBodyForToPB = append(funcBodyForToPB, setPtr...)
+		} else {
+			funcBodyForToPB = append(funcBodyForToPB, jen.If(jen.Id("orig").Dot(fieldState.Name).Op("!=").Nil()).Block(setPtr...))
+		}
+		return jen.Id(varName)
+	}
+
+	// makeCollection returns the statements making the pb collection of a dto collection, filled by loop, e.g.
+	//
+	//	m := make(map[string]*pb.Address, len(orig.Addresses))
+	//	for k, v := range orig.Addresses {...}
+	//
+	// the pb collection is left nil when the dto collection is with options.ToPBNilCollections, e.g.
+	//
+	//	var m map[string]*pb.Address
+	//	if orig.Addresses != nil {
+	//		m = make(map[string]*pb.Address, len(orig.Addresses))
+	//		for k, v := range orig.Addresses {...}
+	//	}
+	makeCollection := func(fieldName, varName string, collectionType jen.Code, makeArgs []jen.Code, loop jen.Code) []jen.Code {
+		makeArgs = append([]jen.Code{collectionType}, makeArgs...)
+		if !g.options.ToPBNilCollections {
+			return []jen.Code{jen.Id(varName).Op(":=").Make(makeArgs...), loop}
+		}
+		return []jen.Code{
+			jen.Var().Id(varName).Add(collectionType),
+			jen.If(jen.Id("orig").Dot(fieldName).Op("!=").Nil()).Block(jen.Id(varName).Op("=").Make(makeArgs...), loop),
+		}
+	}
 
-	for fieldName, fieldState := range fieldManifest {
+	for _, fieldState := range fieldManifest {
+		fieldName := fieldState.Name
 		logrus.Debug("genBindingToPB: ", "field name: ", fieldName, " fieldState: ", fieldState)
 
-		// if field is not a struct, only need assignment line:
-		// `AStringField := pb.AStringField`
-		if !fieldState.IsStructType {
-			assignmentsForToPB[jen.Id(fieldName)] = jen.Id("orig").Dot(fieldName)
+		if fieldState.Wrapper != "" {
+			wrapper := jen.Op("&").Add(g.pbStructType(fieldState.Wrapper)).Values(jen.Dict{
+				jen.Id(fieldState.WrappedField): jen.Id("orig").Dot(fieldName),
+			})
+			if !fieldState.isNilable() {
+				// the wrapper of a scalar is always set, even to wrap a zero value, as the comment of the dto field tells
+				// Name = &pb.NameWrapper{Value: orig.Name}
+				assignmentsForToPB[jen.Id(fieldName)] = wrapper
+				continue
+			}
+			// a nil collection leaves the wrapper nil, as FromPB converts a nil wrapper to a nil collection
+			// var tags *pb.Tags
+			// if orig.Tags != nil {
+			//		tags = &pb.Tags{Values: orig.Tags}
+			//}
+			varName := names.name(utils.ToLowerFirstCamelCase(fieldName))
+			funcBodyForToPB = append(funcBodyForToPB,
+				jen.Var().Id(varName).Op("*").Add(g.pbStructType(fieldState.Wrapper)),
+				jen.If(jen.Id("orig").Dot(fieldName).Op("!=").Nil()).Block(jen.Id(varName).Op("=").Add(wrapper)),
+			)
+
+			// Tags = tags
+			assignmentsForToPB[jen.Id(fieldName)] = jen.Id(varName)
+			continue
+		}
+
+		if nonZero, ok := nonZeroScalar(fieldState, jen.Id("orig").Dot(fieldName)); ok && g.options.Proto3ZeroOmit {
+			// if orig.Name != "" {
+			//		res.Name = orig.Name
+			//}
+			var value jen.Code = jen.Id("orig").Dot(fieldName)
+			if fieldState.IsEnum {
+				value, _ = g.toPBConversion(fieldState, value)
+			}
+			zeroOmitGuards = append(zeroOmitGuards,
+				jen.If(nonZero).
+					Block(jen.Id("res").Dot(fieldName).Op("=").Add(value)),
+			)
+			continue
+		}
+
+		// if field is neither a struct, an enum nor a well known type, only need assignment line:
+		// `AStringField := pb.AStringField`
+		if fieldState.Override != nil {
+			assignmentsForToPB[jen.Id(fieldName)] = g.overriddenValue(fieldState.Override.ToPB, jen.Id("orig").Dot(fieldName))
+			continue
+		}
+		if fieldState.MapEntry != "" {
+			// entries := make([]*pb.HelloRequest_AddressesEntry, 0, len(orig.Addresses))
+			// for k, v := range orig.Addresses {
+			//		entries = append(entries, &pb.HelloRequest_AddressesEntry{Key: k, Value: AddressToPB(v)})
+			//}
+			var checks []jen.Code
+			var value jen.Code = jen.Id("v")
+			if fieldState.isConverted() {
+				conversion, fallible := g.toPBConversion(fieldState, value)
+				checks, value = g.convert(conversion, fallible, "e", currentPBStructName, fieldName)
+			}
+			entry := jen.Op("&").Add(g.pbStructType(fieldState.MapEntry)).Values(jen.Dict{
+				jen.Id("Key"):   jen.Id("k"),
+				jen.Id("Value"): value,
+			})
+			entries := names.name("entries")
+			funcBodyForToPB = append(funcBodyForToPB, makeCollection(fieldName, entries,
+				jen.Index().Id("*").Add(g.pbStructType(fieldState.MapEntry)), []jen.Code{jen.Lit(0), jen.Len(jen.Id("orig").Dot(fieldName))},
+				jen.For(
+					jen.Id("k").Op(`,`).Id("v").Op(":=").Range().Id("orig").Dot(fieldName).
+						Block(append(checks, jen.Id(entries).Op("=").Append(jen.Id(entries), entry))...)),
+			)...)
+
+			// Addresses = entries
+			assignmentsForToPB[jen.Id(fieldName)] = jen.Id(entries)
+			continue
+		}
+		if !fieldState.isConverted() {
+			assignmentsForToPB[jen.Id(fieldName)] = collectionPtr(fieldState, g.assignedValue(fieldState, jen.Id("orig").Dot(fieldName)))
 			continue
 		}
 
@@ -306,84 +2403,964 @@ func (g *GenerateDTOFromProtoGo) genBindingToPB(currentPBStructName string, fiel
 			// for k, v := range orig.Addresses {
 			//		m[k] = AddressToPB(v)
 			//}
-			funcBodyForToPB = append(funcBodyForToPB,
-				jen.Id("m").Op(":=").Make(jen.Map(jen.Id(fieldState.MapKeyType)).Id("*").Qual(g.pbPackagePath, fieldState.TypeName), jen.Len(jen.Id("orig").Dot(fieldName))),
+			conversion, fallible := g.toPBConversion(fieldState, jen.Id("v"))
+			checks, result := g.convert(conversion, fallible, "e", currentPBStructName, fieldName)
+			m := names.name("m")
+			funcBodyForToPB = append(funcBodyForToPB, makeCollection(fieldName, m,
+				jen.Map(fieldState.mapKeyType()).Add(g.pbElemType(fieldState)), []jen.Code{jen.Len(jen.Id("orig").Dot(fieldName))},
 				jen.For(
 					jen.Id("k").Op(`,`).Id("v").Op(":=").Range().Id("orig").Dot(fieldName).
-						Block(jen.Id("m").Index(jen.Id("k")).Op("=").Id(fieldState.TypeName+"ToPB").Call(jen.Id("v")))),
-			)
+						Block(append(checks, jen.Id(m).Index(jen.Id("k")).Op("=").Add(result))...)),
+			)...)
 			// Addresses = m
-			assignmentsForToPB[jen.Id(fieldName)] = jen.Id("m")
+			assignmentsForToPB[jen.Id(fieldName)] = collectionPtr(fieldState, jen.Id(m))
 		} else if fieldState.IsSlice {
 			// aSlice := make([]*pb.Address, 0, len(orig.Addresses))
 			// for _, v := range orig.Addresses {
 			//		aSlice = append(aSlice, AddressToPB(v))
 			//}
-			funcBodyForToPB = append(funcBodyForToPB,
-				jen.Id("aSlice").Op(":=").Make(jen.Index().Id("*").Qual(g.pbPackagePath, fieldState.TypeName), jen.Lit(0), jen.Len(jen.Id("orig").Dot(fieldName))),
+			conversion, fallible := g.toPBConversion(fieldState, jen.Id("v"))
+			checks, result := g.convert(conversion, fallible, "e", currentPBStructName, fieldName)
+			aSlice := names.name("aSlice")
+			funcBodyForToPB = append(funcBodyForToPB, makeCollection(fieldName, aSlice,
+				jen.Index().Add(g.pbElemType(fieldState)), []jen.Code{jen.Lit(0), jen.Len(jen.Id("orig").Dot(fieldName))},
 				jen.For(
 					jen.Id("_").Op(`,`).Id("v").Op(":=").Range().Id("orig").Dot(fieldName).
-						Block(jen.Id("aSlice").Op("=").Append(jen.Id("aSlice"), jen.Id(fieldState.TypeName+"ToPB").Call(jen.Id("v"))))),
-			)
+						Block(append(checks, jen.Id(aSlice).Op("=").Append(jen.Id(aSlice), result))...)),
+			)...)
 
 			// Addresses = aSlice
-			assignmentsForToPB[jen.Id(fieldName)] = jen.Id("aSlice")
+			assignmentsForToPB[jen.Id(fieldName)] = collectionPtr(fieldState, jen.Id(aSlice))
+		} else if fieldState.ArrayLen != "" {
+			// var arr [4]*pb.Address
+			// for i, v := range orig.Addresses {
+			//		arr[i] = AddressToPB(v)
+			//}
+			conversion, fallible := g.toPBConversion(fieldState, jen.Id("v"))
+			checks, result := g.convert(conversion, fallible, "e", currentPBStructName, fieldName)
+			arr := names.name("arr")
+			funcBodyForToPB = append(funcBodyForToPB,
+				jen.Var().Id(arr).Index(jen.Id(fieldState.ArrayLen)).Add(g.pbElemType(fieldState)),
+				jen.For(
+					jen.Id("i").Op(`,`).Id("v").Op(":=").Range().Id("orig").Dot(fieldName).
+						Block(append(checks, jen.Id(arr).Index(jen.Id("i")).Op("=").Add(result))...)),
+			)
+
+			// Addresses = arr
+			assignmentsForToPB[jen.Id(fieldName)] = collectionPtr(fieldState, jen.Id(arr))
+		} else if fieldState.IsOptionalEnum {
+			// var status *pb.Status
+			// if orig.Status != nil {
+			//		v := pb.Status(*orig.Status)
+			//		status = &v
+			//}
+			varName := names.name(utils.ToLowerFirstCamelCase(fieldName))
+			conversion, _ := g.toPBConversion(fieldState, jen.Op("*").Id("orig").Dot(fieldName))
+			funcBodyForToPB = append(funcBodyForToPB,
+				jen.Var().Id(varName).Id("*").Add(g.pbElemType(fieldState)),
+				jen.If(jen.Id("orig").Dot(fieldName).Op("!=").Nil()).
+					Block(jen.Id("v").Op(":=").Add(conversion), jen.Id(varName).Op("=").Op("&").Id("v")),
+			)
+
+			// Status = status
+			assignmentsForToPB[jen.Id(fieldName)] = jen.Id(varName)
 		} else {
 			// field is a single struct, we add only assignment:
-			// Address = AddressToPB(pb.Address)
-			assignmentsForToPB[jen.Id(fieldName)] = jen.Id(fieldState.TypeName + "ToPB").Call(jen.Id("orig").Dot(fieldName))
+			// Address = AddressToPB(pb.Address), or AddressToPB(&orig.Address) when it is embedded by value
+			var src jen.Code = jen.Id("orig").Dot(fieldName)
+			if fieldState.IsValueNested {
+				src = jen.Op("&").Id("orig").Dot(fieldName)
+			}
+			conversion, fallible := g.toPBConversion(fieldState, src)
+			varName := utils.ToLowerFirstCamelCase(fieldName)
+			if fallible {
+				varName = names.name(varName)
+			}
+			checks, result := g.convert(conversion, fallible, varName, currentPBStructName, fieldName)
+			funcBodyForToPB = append(funcBodyForToPB, checks...)
+			assignmentsForToPB[jen.Id(fieldName)] = result
 		}
 	}
 
 	// add assignments to the end of func body
-	funcBodyForToPB = append(funcBodyForToPB, jen.Return(jen.Id("&").Qual(g.pbPackagePath, currentPBStructName).Values(assignmentsForToPB)))
+	pbValue := jen.Id("&").Add(g.pbStructType(currentPBStructName)).Values(assignmentsForToPB)
+	baseMapping := g.embedBaseMapping(currentPBStructName)
+	if len(zeroOmitGuards) > 0 || baseMapping != nil && baseMapping.ToPB != "" {
+		funcBodyForToPB = append(funcBodyForToPB, jen.Id("res").Op(":=").Add(pbValue))
+		pbValue = jen.Id("res")
+	}
+	if len(zeroOmitGuards) > 0 {
+		funcBodyForToPB = append(funcBodyForToPB,
+			jen.Comment("proto3 does not serialize scalar zero values, so zero scalar fields are left unset (--proto3-zero-omit)"),
+		)
+		funcBodyForToPB = append(funcBodyForToPB, zeroOmitGuards...)
+	}
+	if baseMapping != nil && baseMapping.ToPB != "" {
+		// the fields of the embedded base, see embedBaseMapping
+		funcBodyForToPB = append(funcBodyForToPB, g.mappingSpec.code(baseMapping.ToPB, jen.Id("res")))
+	}
+	if fallible {
+		funcBodyForToPB = append(funcBodyForToPB, jen.Return(pbValue, jen.Nil()))
+	} else {
+		funcBodyForToPB = append(funcBodyForToPB, jen.Return(pbValue))
+	}
 
 	// gen *ToPB func, e.g. InitApplicationRequestToPB
-	g.code.appendFunction(
-		fmt.Sprintf("%sToPB", currentPBStructName),
+	g.bindingsCode.appendFunction(
+		g.toPBFuncName(currentPBStructName),
 		nil,
-		[]jen.Code{
-			jen.Id("orig").Id("*").Qual(g.dtoPackagePath, currentPBStructName),
-		},
-		[]jen.Code{
-			jen.Id("").Id("*").Qual(g.pbPackagePath, currentPBStructName),
-		},
+		g.bindingParams(jen.Id("orig").Id("*").Qual(g.dtoImportPath, g.dtoTypeName(currentPBStructName))),
+		results,
 		"",
 		funcBodyForToPB...,
 	)
+	g.bindingsCode.NewLine()
+}
+
+// assignedValue returns the value assigned to a field that needs no conversion in the bindings, that is src itself,
+// or a copy of src for slices of primitives when options.CopySlices is set, e.g. append([]string(nil), pb.Tags...)
+func (g *GenerateDTOFromProtoGo) assignedValue(fieldState fieldState, src jen.Code) jen.Code {
+	if g.options.CopySlices && fieldState.IsSlice && !fieldState.IsStructType && fieldState.ImportPath == "" {
+		return jen.Append(jen.Id(fieldState.PBType).Parens(jen.Nil()), jen.Add(src).Op("..."))
+	}
+	return src
+}
+
+// overriddenValue returns the value assigned to a field overridden by the mapping spec, i.e. the given expression
+// applied to src, or src itself if the spec does not give an expression
+func (g *GenerateDTOFromProtoGo) overriddenValue(expr string, src jen.Code) jen.Code {
+	if expr == "" {
+		return src
+	}
+	return g.mappingSpec.code(expr, src)
+}
+
+// loadMappingSpec reads the mapping spec of options.MappingSpec, every overridden field must exist in pb.go
+func (g *GenerateDTOFromProtoGo) loadMappingSpec(pbStructManifest map[string]*structState) error {
+	specSrc, err := g.fs.ReadFile(g.options.MappingSpec)
+	if err != nil {
+		return fmt.Errorf("err reading mapping spec at: %s, err: %v", g.options.MappingSpec, err)
+	}
+	if g.mappingSpec, err = parseMappingSpec(specSrc); err != nil {
+		return fmt.Errorf("err parsing mapping spec at: %s, err: %v", g.options.MappingSpec, err)
+	}
+	// the pb package can always be referred to as pb
+	if _, ok := g.mappingSpec.Imports["pb"]; !ok {
+		if g.mappingSpec.Imports == nil {
+			g.mappingSpec.Imports = map[string]string{}
+		}
+		g.mappingSpec.Imports["pb"] = g.pbPackagePath
+	}
+
+	for key := range g.mappingSpec.Fields {
+		parts := strings.Split(key, ".")
+		structState, ok := pbStructManifest[parts[0]]
+		if !ok {
+			return fmt.Errorf("mapping spec field %s refers to an unknown pb struct %s", key, parts[0])
+		}
+		// the embedded base is mapped as a field, see embedBaseMapping
+		_, baseTypeName := g.embedBase()
+		found := g.options.EmbedBase != "" && parts[1] == baseTypeName
+		for _, field := range structState.Struct.Vars {
+			found = found || field.Name == parts[1]
+		}
+		if !found {
+			return fmt.Errorf("mapping spec field %s refers to an unknown field of pb struct %s", key, parts[0])
+		}
+	}
+	return g.validateUnions(pbStructManifest)
+}
+
+// nonZeroScalar returns the condition telling that src, the value of a scalar field, is not zero, e.g. orig.Name != ""
+// only strings, bools, numbers and enums are scalars, messages, collections, pointers and types of other packages are not
+func nonZeroScalar(fieldState fieldState, src jen.Code) (jen.Code, bool) {
+	if fieldState.IsEnum && !fieldState.IsOptionalEnum && !fieldState.IsMap && !fieldState.IsSlice && fieldState.ArrayLen == "" {
+		return jen.Add(src).Op("!=").Lit(0), true
+	}
+	if fieldState.isConverted() || fieldState.ImportPath != "" {
+		return nil, false
+	}
+
+	switch fieldState.PBType {
+	case "string":
+		return jen.Add(src).Op("!=").Lit(""), true
+	case "bool":
+		return src, true
+	case "int32", "int64", "uint32", "uint64", "float32", "float64":
+		return jen.Add(src).Op("!=").Lit(0), true
+	}
+	return nil, false
+}
+
+// registerImportAliases sets stable aliases for the pb package and the packages imported by pb.go,
+// so the generated imports do not depend on the order they are used in and never collide with each other,
+// an alias does not import its package, jennifer only imports the packages referenced by the generated code
+func (g *GenerateDTOFromProtoGo) registerImportAliases(pbGoFile *parser.File) {
+	g.importAliases = map[string]string{}
+	g.pbImports = map[string]string{}
+	g.addImportAlias(g.pbPackagePath, pbImportAlias(g.serviceName))
+
+	for alias, importPath := range pbGoFile.ImportPaths {
+		g.pbImports[alias] = importPath
+	}
+	// in the order of pb.go, so the aliases of colliding imports are stable
+	for _, imp := range pbGoFile.Imports {
+		if alias, importPath, ok := parser.ImportName(imp); ok {
+			g.addImportAlias(importPath, alias)
+		}
+	}
+}
+
+// resolveFieldType splits a package qualified type of pb.go, e.g. commonpb.Metadata, into the type name and the import
+// path of its package, the import path is empty for types declared in the pb package, an imported pb struct followed
+// with options.FollowImports being kept qualified as it is its name in the manifest
+func (g *GenerateDTOFromProtoGo) resolveFieldType(fieldType string) (typeName string, importPath string) {
+	dot := strings.LastIndex(fieldType, ".")
+	if dot < 0 {
+		return fieldType, ""
+	}
+
+	importPath, ok := g.pbImports[fieldType[:dot]]
+	if !ok {
+		g.warnf("could not resolve the package of type %s in pb.go, it is kept as is", fieldType)
+		return fieldType, ""
+	}
+	if importPath == g.pbPackagePath {
+		return fieldType[dot+1:], ""
+	}
+	// the imported pb structs followed with options.FollowImports have a dto, see loadImportedPBStructs
+	if _, ok := g.importedPBStructs[fieldType]; ok {
+		return fieldType, ""
+	}
+	return fieldType[dot+1:], importPath
+}
+
+// mapKeyType resolves the key type of a map field, only the strings, bools, numbers and enums being supported, i.e. the pb enums,
+// qualified by the pb package, and the types of other packages, assumed to be enums, e.g. commonpb.Status,
+// ok is false for the other types, e.g. a pb struct, which are not comparable or do not convert to dto
+func (g *GenerateDTOFromProtoGo) mapKeyType(keyType string) (typeName, importPath string, ok bool) {
+	key := parser.ParseType(keyType)
+	if key.Pointers > 0 || key.Name == "" {
+		return keyType, "", false
+	}
+	if universeType, ok := types.Universe.Lookup(key.Name).(*types.TypeName); ok {
+		_, isBasic := universeType.Type().(*types.Basic)
+		return keyType, "", isBasic
+	}
+	typeName, importPath = g.resolveFieldType(key.Name)
+	if importPath != "" {
+		return typeName, importPath, true
+	}
+	if _, ok := g.pbEnums[typeName]; ok {
+		return typeName, g.pbPackagePath, true
+	}
+	return keyType, "", false
+}
+
+// addImportAlias registers the alias of an import path in the generated files,
+// a number is appended to the alias if it is already used by another import path, e.g. commonpb1
+func (g *GenerateDTOFromProtoGo) addImportAlias(importPath, alias string) {
+	if _, ok := g.importAliases[importPath]; ok {
+		return
+	}
+
+	used := map[string]bool{}
+	for _, a := range g.importAliases {
+		used[a] = true
+	}
+	unique := alias
+	for i := 1; used[unique]; i++ {
+		unique = fmt.Sprintf("%s%d", alias, i)
+	}
+
+	g.importAliases[importPath] = unique
+	g.srcFile.ImportAlias(importPath, unique)
+	if g.bindingsSrcFile != g.srcFile {
+		g.bindingsSrcFile.ImportAlias(importPath, unique)
+	}
+}
+
+// pbImportAlias returns the alias of the pb package of a service, e.g. helloservicepb for helloService
+func pbImportAlias(serviceName string) string {
+	alias := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, serviceName)
+	return alias + "pb"
+}
+
+// findPBEnums returns the enums of a pb.go file, i.e. the int32 types having a <Enum>_name map generated by protoc
+func findPBEnums(pbGoFile *parser.File) map[string]bool {
+	nameMaps := map[string]bool{}
+	for _, v := range pbGoFile.Vars {
+		nameMaps[v.Name] = true
+	}
+
+	pbEnums := map[string]bool{}
+	for _, definedType := range pbGoFile.DefinedTypes {
+		if definedType.Type == "int32" && nameMaps[definedType.Name+"_name"] {
+			pbEnums[definedType.Name] = false
+		}
+	}
+	return pbEnums
+}
+
+// findPBGetters returns the result types of the getters of the pb structs keyed by <pb struct name>.<field name>,
+// e.g. HelloRequest.Name -> string for func (x *HelloRequest) GetName() string, as generated by protoc
+func findPBGetters(pbGoFile *parser.File) map[string]string {
+	pbGetters := map[string]string{}
+	for _, m := range pbGoFile.Methods {
+		if !strings.HasPrefix(m.Name, "Get") || len(m.Parameters) != 0 || len(m.Results) != 1 {
+			continue
+		}
+		pbGetters[strings.TrimPrefix(m.Struct.Type, "*")+"."+strings.TrimPrefix(m.Name, "Get")] = m.Results[0].Type
+	}
+	return pbGetters
+}
+
+// genEnum generates the dto enum mirroring a pb enum, e.g. type Status int32
+// when options.EnumAsString is set, the enum is (un)marshalled to json by name using the <Enum>_name / <Enum>_value maps of pb.go:
+//
+//	func (s Status) MarshalJSON() ([]byte, error) {...}
+//	func (s *Status) UnmarshalJSON(data []byte) error {...}
+func (g *GenerateDTOFromProtoGo) genEnum(pbEnumName string) {
+	g.pbEnums[pbEnumName] = true
+	logrus.Info("generating dto enum for: ", pbEnumName)
+
+	dtoEnumName := g.dtoTypeName(pbEnumName)
+	g.code.Raw().Type().Id(dtoEnumName).Int32().Line()
+	g.code.NewLine()
+	if !g.options.EnumAsString {
+		return
+	}
+
+	recv := utils.ReceiverName(dtoEnumName, "data", "name", "value", "ok", "err")
+
+	// known values are marshalled by name, unknown ones as numbers
+	g.code.appendFunction(
+		"MarshalJSON",
+		jen.Id(recv).Id(dtoEnumName),
+		[]jen.Code{},
+		[]jen.Code{jen.Index().Byte(), jen.Error()},
+		"",
+		jen.If(
+			jen.List(jen.Id("name"), jen.Id("ok")).Op(":=").Qual(g.pbPackagePath, pbEnumName+"_name").Index(jen.Int32().Call(jen.Id(recv))),
+			jen.Id("ok"),
+		).Block(jen.Return(jen.Qual("encoding/json", "Marshal").Call(jen.Id("name")))),
+		jen.Return(jen.Qual("encoding/json", "Marshal").Call(jen.Int32().Call(jen.Id(recv)))),
+	)
 	g.code.NewLine()
+	g.code.NewLine()
+
+	// names and numbers are both accepted, numbers do not need to be known values
+	g.code.appendFunction(
+		"UnmarshalJSON",
+		jen.Id(recv).Id("*"+dtoEnumName),
+		[]jen.Code{jen.Id("data").Index().Byte()},
+		[]jen.Code{},
+		"error",
+		jen.Var().Id("name").String(),
+		jen.If(jen.Err().Op(":=").Qual("encoding/json", "Unmarshal").Call(jen.Id("data"), jen.Op("&").Id("name")), jen.Err().Op("==").Nil()).Block(
+			jen.If(
+				jen.List(jen.Id("value"), jen.Id("ok")).Op(":=").Qual(g.pbPackagePath, pbEnumName+"_value").Index(jen.Id("name")),
+				jen.Id("ok"),
+			).Block(
+				jen.Op("*").Id(recv).Op("=").Id(dtoEnumName).Call(jen.Id("value")),
+				jen.Return(jen.Nil()),
+			),
+			jen.Return(jen.Qual("fmt", "Errorf").Call(jen.Lit("unknown "+dtoEnumName+" name: %q"), jen.Id("name"))),
+		),
+		jen.Var().Id("value").Int32(),
+		jen.If(jen.Err().Op(":=").Qual("encoding/json", "Unmarshal").Call(jen.Id("data"), jen.Op("&").Id("value")), jen.Err().Op("!=").Nil()).Block(
+			jen.Return(jen.Err()),
+		),
+		jen.Op("*").Id(recv).Op("=").Id(dtoEnumName).Call(jen.Id("value")),
+		jen.Return(jen.Nil()),
+	)
+	g.code.NewLine()
+	g.code.NewLine()
+}
+
+// wellKnownType returns how a type declared outside of the pb package is mapped to dto if it is a well known type
+func (g *GenerateDTOFromProtoGo) wellKnownType(importPath, typeName, pbFieldType string) (wellKnownType, bool) {
+	if importPath == "" && !g.options.Int64AsString {
+		return wellKnownType{}, false
+	} else if importPath == "" && strings.HasPrefix(pbFieldType, "*") {
+		// proto3 optional fields, e.g. *int64
+		optionalInt64AsString, ok := optionalInt64AsStringTypes[typeName]
+		return optionalInt64AsString, ok
+	} else if importPath == "" {
+		int64AsString, ok := int64AsStringTypes[typeName]
+		return int64AsString, ok
+	}
+	if g.options.AnyAsRaw && importPath == anypbImportPath && typeName == "Any" {
+		return anyAsRawType, true
+	}
+	wellKnown, ok := wellKnownTypes[importPath+"."+typeName]
+	return wellKnown, ok
+}
+
+// genAnyAsRawHelpers generates the helpers converting *anypb.Any from / to json.RawMessage, used with options.AnyAsRaw
+// this is a best effort mapping, the Any payload is kept as protobuf bytes as decoding it requires its message type
+func (g *GenerateDTOFromProtoGo) genAnyAsRawHelpers() {
+	g.bindingsCode.appendMultilineComment([]string{
+		"anyAsRaw is the json representation of a pb Any in dto, i.e. {\"type_url\":\"...\",\"value\":\"...\"}",
+		"limitation: the payload is not decoded, value holds the base64 encoded protobuf bytes of the message,",
+		"so it is only readable by a client knowing the message type of type_url.",
+	})
+	g.bindingsCode.NewLine()
+	g.bindingsCode.appendStruct("anyAsRaw",
+		jen.Id("TypeURL").String().Tag(map[string]string{"json": "type_url"}),
+		jen.Id("Value").Index().Byte().Tag(map[string]string{"json": "value"}),
+	)
+	g.bindingsCode.NewLine()
+
+	g.bindingsCode.appendMultilineComment([]string{"anyToRawMessage converts a pb Any to its json representation, see anyAsRaw."})
+	g.bindingsCode.NewLine()
+	g.bindingsCode.appendFunction(
+		"anyToRawMessage",
+		nil,
+		[]jen.Code{jen.Id("a").Id("*").Qual(anypbImportPath, "Any")},
+		[]jen.Code{jen.Qual("encoding/json", "RawMessage")},
+		"",
+		jen.If(jen.Id("a").Op("==").Nil()).Block(jen.Return(jen.Nil())),
+		// marshalling a string and a []byte cannot fail
+		jen.List(jen.Id("raw"), jen.Id("_")).Op(":=").Qual("encoding/json", "Marshal").Call(
+			jen.Id("anyAsRaw").Values(jen.Dict{
+				jen.Id("TypeURL"): jen.Id("a").Dot("TypeUrl"),
+				jen.Id("Value"):   jen.Id("a").Dot("Value"),
+			}),
+		),
+		jen.Return(jen.Id("raw")),
+	)
+	g.bindingsCode.NewLine()
+	g.bindingsCode.NewLine()
+
+	g.bindingsCode.appendMultilineComment([]string{"rawMessageToAny converts the json representation of a pb Any back to a pb Any, see anyAsRaw."})
+	g.bindingsCode.NewLine()
+	g.bindingsCode.appendFunction(
+		"rawMessageToAny",
+		nil,
+		[]jen.Code{jen.Id("raw").Qual("encoding/json", "RawMessage")},
+		[]jen.Code{jen.Id("*").Qual(anypbImportPath, "Any"), jen.Error()},
+		"",
+		jen.If(jen.Len(jen.Id("raw")).Op("==").Lit(0)).Block(jen.Return(jen.Nil(), jen.Nil())),
+		jen.Var().Id("a").Id("anyAsRaw"),
+		jen.If(jen.Err().Op(":=").Qual("encoding/json", "Unmarshal").Call(jen.Id("raw"), jen.Op("&").Id("a")), jen.Err().Op("!=").Nil()).Block(
+			jen.Return(jen.Nil(), jen.Err()),
+		),
+		jen.Return(jen.Op("&").Qual(anypbImportPath, "Any").Values(jen.Dict{
+			jen.Id("TypeUrl"): jen.Id("a").Dot("TypeURL"),
+			jen.Id("Value"):   jen.Id("a").Dot("Value"),
+		}), jen.Nil()),
+	)
+	g.bindingsCode.NewLine()
+	g.bindingsCode.NewLine()
+}
+
+// genRegistry generates FromPBRegistry, a map of the generated FromPB bindings keyed by pb struct name,
+// for a generic dispatch of the pb messages, e.g. in a middleware
+func (g *GenerateDTOFromProtoGo) genRegistry(pbStructNames []string) {
+	// the bindings of the registry return an error as soon as one of them does
+	fallible := false
+	for _, pbStructName := range pbStructNames {
+		fallible = fallible || g.fallibleFromPB[pbStructName]
+	}
+	var results jen.Code = jen.Interface()
+	if fallible {
+		results = jen.Parens(jen.List(jen.Interface(), jen.Error()))
+	}
+
+	bindings := jen.Dict{}
+	for _, pbStructName := range pbStructNames {
+		returned := []jen.Code{g.bindingCall(g.fromPBFuncName(pbStructName), jen.Id("ctx"), jen.Id("v"))}
+		if fallible && !g.fallibleFromPB[pbStructName] {
+			returned = append(returned, jen.Nil())
+		}
+		bindings[jen.Lit(pbStructName)] = jen.Func().Params(g.bindingParams(jen.Id("pbMsg").Interface())...).Add(results).Block(
+			// the bindings handle nil, so a message of the wrong type is converted to a nil dto instead of panicking
+			jen.List(jen.Id("v"), jen.Id("_")).Op(":=").Id("pbMsg").Assert(jen.Id("*").Add(g.pbStructType(pbStructName))),
+			jen.Return(returned...),
+		)
+	}
+
+	g.bindingsCode.appendMultilineComment([]string{
+		"FromPBRegistry maps the name of every pb struct having a dto to its FromPB binding.",
+		"A pb message which is not of the registered type is converted to a nil dto.",
+	})
+	g.bindingsCode.NewLine()
+	registryParams := []jen.Code{jen.Interface()}
+	if g.options.CtxBindings {
+		registryParams = []jen.Code{jen.Qual("context", "Context"), jen.Interface()}
+	}
+	g.bindingsCode.Raw().Var().Id("FromPBRegistry").Op("=").Map(jen.String()).Func().Params(registryParams...).Add(results).Values(bindings)
+	g.bindingsCode.NewLine()
 }
 
-func fieldIsAMap(typeName string) bool {
-	return strings.Contains(typeName, `map[`)
+// genInt64AsStringHelpers generates the helpers parsing the 64-bit integers of dto back, used with options.Int64AsString
+// e.g. int64FromString, the empty string being parsed as zero so that a zero dto converts to pb,
+// and the helpers converting the proto3 optional ones, e.g. optionalInt64ToString / optionalInt64FromString, nil staying nil
+func (g *GenerateDTOFromProtoGo) genInt64AsStringHelpers() {
+	parsers := map[string]string{"int64": "ParseInt", "uint64": "ParseUint"}
+	for _, typeName := range []string{"int64", "uint64"} {
+		if !g.usesInt64AsString[typeName] && !g.usesInt64AsString["*"+typeName] {
+			continue
+		}
+		funcName := typeName + "FromString"
+		g.bindingsCode.appendMultilineComment([]string{fmt.Sprintf("%s parses the %s of a dto field, the empty string is zero.", funcName, typeName)})
+		g.bindingsCode.NewLine()
+		g.bindingsCode.appendFunction(
+			funcName,
+			nil,
+			[]jen.Code{jen.Id("s").String()},
+			[]jen.Code{jen.Id(typeName), jen.Error()},
+			"",
+			jen.If(jen.Id("s").Op("==").Lit("")).Block(jen.Return(jen.Lit(0), jen.Nil())),
+			jen.Return(jen.Qual("strconv", parsers[typeName]).Call(jen.Id("s"), jen.Lit(10), jen.Lit(64))),
+		)
+		g.bindingsCode.NewLine()
+		g.bindingsCode.NewLine()
+		if g.usesInt64AsString["*"+typeName] {
+			g.genOptionalInt64AsStringHelpers(typeName)
+		}
+	}
 }
 
-func fieldIsASlice(typeName string) bool {
-	return strings.Contains(typeName, `[]`)
+// genOptionalInt64AsStringHelpers generates the helpers converting a proto3 optional 64-bit integer from / to a *string,
+// e.g. optionalInt64ToString / optionalInt64FromString for *int64, a nil pointer staying nil
+func (g *GenerateDTOFromProtoGo) genOptionalInt64AsStringHelpers(typeName string) {
+	toStringFuncName := "optional" + utils.ToUpperFirst(typeName) + "ToString"
+	fromStringFuncName := "optional" + utils.ToUpperFirst(typeName) + "FromString"
+	g.bindingsCode.appendMultilineComment([]string{fmt.Sprintf("%s converts an optional pb %s to dto, nil stays nil.", toStringFuncName, typeName)})
+	g.bindingsCode.NewLine()
+	g.bindingsCode.appendFunction(
+		toStringFuncName,
+		nil,
+		[]jen.Code{jen.Id("v").Id("*").Id(typeName)},
+		[]jen.Code{jen.Id("*").String()},
+		"",
+		jen.If(jen.Id("v").Op("==").Nil()).Block(jen.Return(jen.Nil())),
+		jen.Id("s").Op(":=").Add(int64AsStringTypes[typeName].fromPB(jen.Op("*").Id("v"))),
+		jen.Return(jen.Op("&").Id("s")),
+	)
+	g.bindingsCode.NewLine()
+	g.bindingsCode.NewLine()
+
+	g.bindingsCode.appendMultilineComment([]string{fmt.Sprintf("%s parses an optional %s of a dto field, nil stays nil.", fromStringFuncName, typeName)})
+	g.bindingsCode.NewLine()
+	g.bindingsCode.appendFunction(
+		fromStringFuncName,
+		nil,
+		[]jen.Code{jen.Id("s").Id("*").String()},
+		[]jen.Code{jen.Id("*").Id(typeName), jen.Error()},
+		"",
+		jen.If(jen.Id("s").Op("==").Nil()).Block(jen.Return(jen.Nil(), jen.Nil())),
+		jen.List(jen.Id("v"), jen.Err()).Op(":=").Id(typeName+"FromString").Call(jen.Op("*").Id("s")),
+		jen.If(jen.Err().Op("!=").Nil()).Block(jen.Return(jen.Nil(), jen.Err())),
+		jen.Return(jen.Op("&").Id("v"), jen.Nil()),
+	)
+	g.bindingsCode.NewLine()
+	g.bindingsCode.NewLine()
 }
 
-// todo eric.wang, this function assumes typeName can only be struct, plain slice or plain map, nested types such as slice of maps or map of slices are not supported yet and will cause weird output
-func parseFieldType(typeName string) (nameNoStar string, isSlice bool, isMap bool, mapKeyType string) {
-	if fieldIsASlice(typeName) {
-		isSlice = true
-		parts := strings.Split(typeName, `]`)
-		nameNoStar = strings.TrimPrefix(parts[1], `*`)
-	} else if fieldIsAMap(typeName) {
-		isMap = true
-		parts := strings.Split(typeName, `]`)
-		nameNoStar = strings.TrimPrefix(parts[1], `*`)
-		mapKeyType = getBetweenBrackets(typeName)
-	} else {
-		nameNoStar = strings.TrimPrefix(typeName, `*`)
+// genWrapperHelpers generates the helpers converting the wrappers of optional scalars used by the fields,
+// e.g. stringValueFromPB / stringValueToPB for *wrapperspb.StringValue <-> *string, a nil wrapper being a nil pointer
+func (g *GenerateDTOFromProtoGo) genWrapperHelpers() {
+	for _, wrapper := range wrapperTypes {
+		if !g.usesWrappers[wrapper.name] {
+			continue
+		}
+		fromPBFuncName, toPBFuncName := utils.ToLowerFirstCamelCase(wrapper.name)+"FromPB", utils.ToLowerFirstCamelCase(wrapper.name)+"ToPB"
+		pbType := jen.Id("*").Qual(wrapperspbImportPath, wrapper.name)
+		dtoType := wellKnownTypes[wrapperspbImportPath+"."+wrapper.name].dtoType()
+
+		// bytes are not pointed to, as a nil slice already tells the value is missing
+		value := []jen.Code{jen.Id("value").Op(":=").Id("v").Dot("GetValue").Call(), jen.Return(jen.Op("&").Id("value"))}
+		wrapped := jen.Op("*").Id("v")
+		if wrapper.goType == "[]byte" {
+			value = []jen.Code{jen.Return(jen.Id("v").Dot("GetValue").Call())}
+			wrapped = jen.Id("v")
+		}
+
+		g.bindingsCode.appendMultilineComment([]string{fmt.Sprintf("%s converts an optional pb %s to dto, nil stays nil.", fromPBFuncName, wrapper.goType)})
+		g.bindingsCode.NewLine()
+		g.bindingsCode.appendFunction(
+			fromPBFuncName,
+			nil,
+			[]jen.Code{jen.Id("v").Add(pbType)},
+			[]jen.Code{dtoType},
+			"",
+			append([]jen.Code{jen.If(jen.Id("v").Op("==").Nil()).Block(jen.Return(jen.Nil()))}, value...)...,
+		)
+		g.bindingsCode.NewLine()
+		g.bindingsCode.NewLine()
+
+		g.bindingsCode.appendMultilineComment([]string{fmt.Sprintf("%s converts an optional dto %s to pb, nil stays nil.", toPBFuncName, wrapper.goType)})
+		g.bindingsCode.NewLine()
+		g.bindingsCode.appendFunction(
+			toPBFuncName,
+			nil,
+			[]jen.Code{jen.Id("v").Add(dtoType)},
+			[]jen.Code{pbType},
+			"",
+			jen.If(jen.Id("v").Op("==").Nil()).Block(jen.Return(jen.Nil())),
+			jen.Return(jen.Qual(wrapperspbImportPath, wrapper.constructor).Call(wrapped)),
+		)
+		g.bindingsCode.NewLine()
+		g.bindingsCode.NewLine()
+	}
+}
+
+// genEmptyHelpers generates the helpers converting the *emptypb.Empty fields, used by the bindings in place of
+// struct literals so that the loops over the collections of empties use their values
+func (g *GenerateDTOFromProtoGo) genEmptyHelpers() {
+	g.bindingsCode.appendMultilineComment([]string{"emptyFromPB converts a pb empty to dto, nil included."})
+	g.bindingsCode.NewLine()
+	g.bindingsCode.appendFunction(
+		"emptyFromPB",
+		nil,
+		[]jen.Code{jen.Id("_").Id("*").Qual(emptypbImportPath, "Empty")},
+		[]jen.Code{jen.Struct()},
+		"",
+		jen.Return(jen.Struct().Values()),
+	)
+	g.bindingsCode.NewLine()
+	g.bindingsCode.NewLine()
+
+	g.bindingsCode.appendMultilineComment([]string{"emptyToPB converts a dto empty to pb."})
+	g.bindingsCode.NewLine()
+	g.bindingsCode.appendFunction(
+		"emptyToPB",
+		nil,
+		[]jen.Code{jen.Id("_").Struct()},
+		[]jen.Code{jen.Id("*").Qual(emptypbImportPath, "Empty")},
+		"",
+		jen.Return(jen.Op("&").Qual(emptypbImportPath, "Empty").Values()),
+	)
+	g.bindingsCode.NewLine()
+	g.bindingsCode.NewLine()
+}
+
+// genConversionError generates the error returned by the fallible bindings, which tells the field failing to convert,
+// the path of the field from the dto being extended by the bindings of the dto holding it, e.g. Address.Location
+func (g *GenerateDTOFromProtoGo) genConversionError() {
+	g.bindingsCode.appendMultilineComment([]string{
+		"ConversionError is the error of a binding failing to convert a field, Field being the path of the field in the Type dto, e.g. Address.Location.",
+	})
+	g.bindingsCode.NewLine()
+	g.bindingsCode.appendStruct(
+		"ConversionError",
+		jen.Id("Field").String(),
+		jen.Id("Type").String(),
+		jen.Id("Err").Error(),
+	)
+	g.bindingsCode.NewLine()
+
+	g.bindingsCode.appendFunction(
+		"Error",
+		jen.Id("e").Id("*ConversionError"),
+		[]jen.Code{},
+		[]jen.Code{},
+		"string",
+		jen.Return(jen.Qual("fmt", "Sprintf").Call(jen.Lit("converting %s.%s: %v"), jen.Id("e").Dot("Type"), jen.Id("e").Dot("Field"), jen.Id("e").Dot("Err"))),
+	)
+	g.bindingsCode.NewLine()
+	g.bindingsCode.NewLine()
+
+	g.bindingsCode.appendFunction(
+		"Unwrap",
+		jen.Id("e").Id("*ConversionError"),
+		[]jen.Code{},
+		[]jen.Code{},
+		"error",
+		jen.Return(jen.Id("e").Dot("Err")),
+	)
+	g.bindingsCode.NewLine()
+	g.bindingsCode.NewLine()
+
+	g.bindingsCode.appendMultilineComment([]string{"conversionError wraps the error converting a field of a dto, the field of a nested dto being prefixed with the field holding it."})
+	g.bindingsCode.NewLine()
+	g.bindingsCode.appendFunction(
+		"conversionError",
+		nil,
+		[]jen.Code{jen.Id("typeName").String(), jen.Id("field").String(), jen.Err().Error()},
+		[]jen.Code{},
+		"error",
+		jen.If(jen.List(jen.Id("nested"), jen.Id("ok")).Op(":=").Err().Assert(jen.Id("*ConversionError")), jen.Id("ok")).Block(
+			jen.Return(jen.Op("&").Id("ConversionError").Values(jen.Dict{
+				jen.Id("Field"): jen.Id("field").Op("+").Lit(".").Op("+").Id("nested").Dot("Field"),
+				jen.Id("Type"):  jen.Id("typeName"),
+				jen.Id("Err"):   jen.Id("nested").Dot("Err"),
+			})),
+		),
+		jen.Return(jen.Op("&").Id("ConversionError").Values(jen.Dict{
+			jen.Id("Field"): jen.Id("field"),
+			jen.Id("Type"):  jen.Id("typeName"),
+			jen.Id("Err"):   jen.Err(),
+		})),
+	)
+	g.bindingsCode.NewLine()
+	g.bindingsCode.NewLine()
+}
+
+// genFieldMaskHelpers generates the helper converting the paths of a dto field mask back to pb, the mask being absent
+// rather than empty when there is no path, as a nil mask converts to no path
+func (g *GenerateDTOFromProtoGo) genFieldMaskHelpers() {
+	g.bindingsCode.appendMultilineComment([]string{"fieldMaskToPB converts the paths of a dto field mask to pb, no path converts to nil."})
+	g.bindingsCode.NewLine()
+	g.bindingsCode.appendFunction(
+		"fieldMaskToPB",
+		nil,
+		[]jen.Code{jen.Id("paths").Index().String()},
+		[]jen.Code{jen.Id("*").Qual(fieldmaskpbImportPath, "FieldMask")},
+		"",
+		jen.If(jen.Len(jen.Id("paths")).Op("==").Lit(0)).Block(jen.Return(jen.Nil())),
+		jen.Return(jen.Op("&").Qual(fieldmaskpbImportPath, "FieldMask").Values(jen.Dict{jen.Id("Paths"): jen.Id("paths")})),
+	)
+	g.bindingsCode.NewLine()
+	g.bindingsCode.NewLine()
+}
+
+// genTimestampHelpers generates the helpers converting the *timestamppb.Timestamp fields, a nil timestamp converting to
+// the zero time and back, where AsTime would convert it to the unix epoch
+func (g *GenerateDTOFromProtoGo) genTimestampHelpers() {
+	g.bindingsCode.appendMultilineComment([]string{"timestampFromPB converts a pb timestamp to dto, nil converts to the zero time."})
+	g.bindingsCode.NewLine()
+	g.bindingsCode.appendFunction(
+		"timestampFromPB",
+		nil,
+		[]jen.Code{jen.Id("ts").Id("*").Qual(timestamppbImportPath, "Timestamp")},
+		[]jen.Code{jen.Qual("time", "Time")},
+		"",
+		jen.If(jen.Id("ts").Op("==").Nil()).Block(jen.Return(jen.Qual("time", "Time").Values())),
+		jen.Return(jen.Id("ts").Dot("AsTime").Call()),
+	)
+	g.bindingsCode.NewLine()
+	g.bindingsCode.NewLine()
+
+	g.bindingsCode.appendMultilineComment([]string{"timestampToPB converts a dto time to pb, the zero time converts to nil."})
+	g.bindingsCode.NewLine()
+	g.bindingsCode.appendFunction(
+		"timestampToPB",
+		nil,
+		[]jen.Code{jen.Id("t").Qual("time", "Time")},
+		[]jen.Code{jen.Id("*").Qual(timestamppbImportPath, "Timestamp")},
+		"",
+		jen.If(jen.Id("t").Dot("IsZero").Call()).Block(jen.Return(jen.Nil())),
+		jen.Return(jen.Qual(timestamppbImportPath, "New").Call(jen.Id("t"))),
+	)
+	g.bindingsCode.NewLine()
+	g.bindingsCode.NewLine()
+}
+
+// markFallibleBindings records the pb structs whose ToPB binding returns an error, that is every struct having a field
+// whose conversion can fail, either directly (e.g. structpb.NewStruct) or through a nested struct binding,
+// and the pb structs whose FromPB binding returns an error, that is every struct converted through json with
+// options.ReflectFallback and the structs nesting them, or every struct when options.FallibleBindings is set
+func (g *GenerateDTOFromProtoGo) markFallibleBindings(pbStructManifest map[string]*structState) {
+	g.fallibleToPB, g.fallibleFromPB = map[string]bool{}, map[string]bool{}
+	if g.options.FallibleBindings {
+		for name := range pbStructManifest {
+			g.fallibleToPB[name] = true
+			g.fallibleFromPB[name] = true
+		}
+		return
+	}
+	for name := range g.reflectFallbacks {
+		g.fallibleToPB[name] = true
+		g.fallibleFromPB[name] = true
+	}
+
+	// iterate until no more struct is marked, so that fallibility propagates through any depth of nesting
+	for changed := true; changed; {
+		changed = false
+		for name, structState := range pbStructManifest {
+			if g.fallibleToPB[name] && g.fallibleFromPB[name] {
+				continue
+			}
+			for _, field := range structState.Struct.Vars {
+				if !ast.IsExported(field.Name) || g.isSkippedField(name, field) || g.mappingSpec.override(name, field.Name) != nil {
+					continue
+				}
+				fieldType, _, _, _ := parseFieldType(field.TypeInfo)
+				fieldType, importPath := g.resolveFieldType(fieldType)
+				_, isStructType := pbStructManifest[fieldType]
+				isStructType = isStructType && importPath == ""
+				wellKnown, isWellKnown := g.wellKnownType(importPath, fieldType, field.Type)
+				if !g.fallibleToPB[name] && ((isStructType && g.fallibleToPB[fieldType]) || (!isStructType && isWellKnown && wellKnown.toPBReturnsError)) {
+					g.fallibleToPB[name] = true
+					changed = true
+				}
+				if !g.fallibleFromPB[name] && isStructType && g.fallibleFromPB[fieldType] {
+					g.fallibleFromPB[name] = true
+					changed = true
+				}
+			}
+		}
+	}
+}
+
+// dtoTypeName returns the name of the dto type generated for a pb struct, the one given to an imported pb struct
+// followed with options.FollowImports, see nameImportedDTO
+func (g *GenerateDTOFromProtoGo) dtoTypeName(pbStructName string) string {
+	if imported, ok := g.importedPBStructs[pbStructName]; ok {
+		pbStructName = imported.dtoName
+	}
+	return g.options.TypePrefix + pbStructName + g.options.TypeSuffix
+}
+
+// fromPBFuncName returns the name of the binding converting a pb struct to its dto, e.g. HelloRequestFromPB
+func (g *GenerateDTOFromProtoGo) fromPBFuncName(pbStructName string) string {
+	if g.fromPBTemplate == nil {
+		return fmt.Sprintf("%sFromPB", g.dtoTypeName(pbStructName))
+	}
+	return executeBindingNameTemplate(g.fromPBTemplate, g.dtoTypeName(pbStructName))
+}
+
+// toPBFuncName returns the name of the binding converting a dto to its pb struct, e.g. HelloRequestToPB
+func (g *GenerateDTOFromProtoGo) toPBFuncName(pbStructName string) string {
+	if g.toPBTemplate == nil {
+		return fmt.Sprintf("%sToPB", g.dtoTypeName(pbStructName))
+	}
+	return executeBindingNameTemplate(g.toPBTemplate, g.dtoTypeName(pbStructName))
+}
+
+// default templates of the binding names, e.g. HelloRequestFromPB / HelloRequestToPB
+const (
+	defaultFromPBTemplate = "{{.Name}}FromPB"
+	defaultToPBTemplate   = "{{.Name}}ToPB"
+)
+
+// bindingNameTemplate parses the template of the names of a kind of bindings, the default one if empty,
+// the template must give an exported go identifier depending on the name of the dto, e.g. FromProto{{.Name}}
+func bindingNameTemplate(kind, text, defaultText string) (*template.Template, error) {
+	if text == "" {
+		text = defaultText
+	}
+	tmpl, err := template.New(kind).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s template %s: %v", kind, text, err)
+	}
+	if err = tmpl.Execute(ioutil.Discard, bindingNameTemplateData{Name: "A"}); err != nil {
+		return nil, fmt.Errorf("invalid %s template %s: %v", kind, text, err)
+	}
+	a, b := executeBindingNameTemplate(tmpl, "A"), executeBindingNameTemplate(tmpl, "B")
+	if !token.IsIdentifier(a) || !ast.IsExported(a) || a == b || a == "A" {
+		return nil, fmt.Errorf("invalid %s template %s, expected an exported go identifier made of {{.Name}}, e.g. FromProto{{.Name}}", kind, text)
 	}
-	return nameNoStar, isSlice, isMap, mapKeyType
+	return tmpl, nil
 }
 
-func getBetweenBrackets(s string) string {
-	re := regexp.MustCompile(`\[([^\[\]]*)\]`)
-	allMatches := re.FindAllString(s, -1)
-	firstMatch := allMatches[0]
-	trimLeft := strings.Trim(firstMatch, `[`)
-	trimRight := strings.Trim(trimLeft, `]`)
-	return trimRight
+// dtoFileNameData is the data of the template of options.OutFileName
+type dtoFileNameData struct {
+	// Service is the name of the service, e.g. helloService
+	Service string
+}
+
+// dtoFileName returns the name of the dto file given by the text/template of options.OutFileName, e.g. helloService_dto.gen.go
+// for {{.Service}}_dto.gen.go, which must name a go file of the dto package, not a test nor a file ignored by go build
+func dtoFileName(text, serviceName string) (string, error) {
+	tmpl, err := template.New("out file name").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid out file name %s: %v", text, err)
+	}
+	var name strings.Builder
+	if err = tmpl.Execute(&name, dtoFileNameData{Service: serviceName}); err != nil {
+		return "", fmt.Errorf("invalid out file name %s: %v", text, err)
+	}
+	fileName := name.String()
+	if fileName != path.Base(fileName) || strings.Contains(fileName, `\`) || path.Ext(fileName) != ".go" ||
+		strings.HasSuffix(fileName, "_test.go") || strings.HasPrefix(fileName, ".") || strings.HasPrefix(fileName, "_") {
+		return "", fmt.Errorf("invalid out file name %s, expected the name of a go file of the dto package, e.g. {{.Service}}_dto.gen.go", text)
+	}
+	return fileName, nil
+}
+
+// bindingNameTemplateData is the data of the templates of the binding names
+type bindingNameTemplateData struct {
+	// Name is the name of the dto type, e.g. HelloRequest
+	Name string
+}
+
+// executeBindingNameTemplate returns the name of the binding of a dto given by a template checked by bindingNameTemplate
+func executeBindingNameTemplate(tmpl *template.Template, dtoTypeName string) string {
+	var name strings.Builder
+	if err := tmpl.Execute(&name, bindingNameTemplateData{Name: dtoTypeName}); err != nil {
+		logrus.Errorf("failed to execute the binding name template %s: %s", tmpl.Name(), err)
+	}
+	return name.String()
+}
+
+// isUnknownType tells if the pb type of a field assigned as is refers to a type which is neither builtin nor
+// declared in another package, e.g. the isHelloRequest_Value interface of a oneof, or an int32 type which is not a pb enum,
+// the dto would then refer to an undeclared type
+func isUnknownType(fieldState fieldState) bool {
+	if fieldState.isConverted() || fieldState.ImportPath != "" {
+		return false
+	}
+	typeExpr, err := goparser.ParseExpr(fieldState.PBType)
+	if err != nil {
+		return true
+	}
+	unknown := false
+	ast.Inspect(typeExpr, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.SelectorExpr:
+			// a type of another package, e.g. commonpb.Metadata
+			return false
+		case *ast.Ident:
+			// the key of a map may be a pb enum, see mapKeyType
+			if fieldState.MapKeyImportPath != "" && n.Name == fieldState.MapKeyType {
+				return true
+			}
+			if _, ok := types.Universe.Lookup(n.Name).(*types.TypeName); !ok {
+				unknown = true
+			}
+		}
+		return !unknown
+	})
+	return unknown
+}
+
+// fieldArrayPattern matches a fixed-size array type, capturing its length, e.g. [16]byte or [N]*Address
+var fieldArrayPattern = regexp.MustCompile(`^\[([^\[\]]+)\]`)
+
+// fieldArrayLen returns the length of a fixed-size array type, e.g. 16 for [16]byte, or an empty string if the type is not an array
+func fieldArrayLen(typeName string) string {
+	if match := fieldArrayPattern.FindStringSubmatch(typeName); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// collectionPtrType returns the collection a pointer to collection type points to, e.g. []*Foo for *[]*Foo,
+// ok is false for the other types, including the collections of pointers, e.g. []*Foo
+func collectionPtrType(typeName string) (collectionType string, ok bool) {
+	collectionType = strings.TrimPrefix(typeName, "*")
+	if collectionType == typeName || !strings.HasPrefix(collectionType, "[") && !strings.HasPrefix(collectionType, "map[") {
+		return typeName, false
+	}
+	return collectionType, true
+}
+
+// parseFieldType returns the element type of a slice, an array or a map type without its pointer, e.g. Foo for []*Foo,
+// along with the key type of a map, or the type itself without its pointer for the other types,
+// a pointer to a collection is parsed as the collection, e.g. *[]*Foo as []*Foo, see collectionPtrType
+// todo eric.wang, nested types such as slice of maps or map of slices are not supported by the generation yet, their element type being a collection
+func parseFieldType(typeInfo parser.TypeInfo) (nameNoStar string, isSlice bool, isMap bool, mapKeyType string) {
+	deref := func(typeInfo parser.TypeInfo) string {
+		if typeInfo.Pointers > 0 {
+			typeInfo.Pointers--
+		}
+		return typeInfo.String()
+	}
+	if typeInfo.Pointers > 1 || !typeInfo.Slice && !typeInfo.Map && typeInfo.ArrayLen == "" {
+		return deref(typeInfo), false, false, ""
+	}
+	if typeInfo.Map {
+		return deref(*typeInfo.Elem), false, true, typeInfo.Key.String()
+	}
+	return deref(*typeInfo.Elem), typeInfo.Slice, false, ""
 }
@@ -0,0 +1,50 @@
+package generator
+
+import (
+	"fmt"
+	"go/ast"
+	"strings"
+
+	"github.com/dave/jennifer/jen"
+)
+
+// embedBase returns the import path and the name of the base struct of options.EmbedBase, e.g. example.com/common/model
+// and BaseModel for example.com/common/model.BaseModel, the import path being the one of the dto package for an unqualified name
+func (g *GenerateDTOFromProtoGo) embedBase() (importPath, typeName string) {
+	dot := strings.LastIndex(g.options.EmbedBase, ".")
+	if dot < 0 {
+		return g.dtoImportPath, g.options.EmbedBase
+	}
+	return g.options.EmbedBase[:dot], g.options.EmbedBase[dot+1:]
+}
+
+// validateEmbedBase checks that options.EmbedBase names an exported type
+func (g *GenerateDTOFromProtoGo) validateEmbedBase() error {
+	if g.options.EmbedBase == "" {
+		return nil
+	}
+	if importPath, typeName := g.embedBase(); importPath == "" || !ast.IsExported(typeName) {
+		return fmt.Errorf("invalid embedded base %s, expected <import path>.<exported type>, e.g. example.com/common/model.BaseModel", g.options.EmbedBase)
+	}
+	return nil
+}
+
+// embedBaseField returns the anonymous field embedding the base struct of options.EmbedBase into a dto, e.g. model.BaseModel
+func (g *GenerateDTOFromProtoGo) embedBaseField() jen.Code {
+	return jen.Qual(g.embedBase())
+}
+
+// embedBaseMapping returns the mapping of the embedded base of a dto, keyed by <pb struct name>.<base type name> in the
+// mapping spec, e.g. HelloRequest.BaseModel, or nil if the bindings skip the base, in the expressions of the mapping,
+// $ is the pb struct, from_pb gives the base from it and to_pb is a statement setting its fields from orig, e.g.
+//
+//	HelloRequest.BaseModel:
+//	  from_pb: "model.BaseModel{CreatedAt: $.CreatedAt.AsTime()}"
+//	  to_pb: $.CreatedAt = timestamppb.New(orig.CreatedAt)
+func (g *GenerateDTOFromProtoGo) embedBaseMapping(pbStructName string) *fieldOverride {
+	if g.options.EmbedBase == "" {
+		return nil
+	}
+	_, typeName := g.embedBase()
+	return g.mappingSpec.override(pbStructName, typeName)
+}
@@ -0,0 +1,47 @@
+package generator
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/dave/jennifer/jen"
+)
+
+// name of the test file holding the benchmarks of the dto bindings, e.g. z_helloService_dto_bench_test.go
+const formatAutoGenDTOBenchFileName = `z_%s_dto_bench_test.go`
+
+// genBenchmarks generates a BenchmarkXxxFromPB / BenchmarkXxxToPB per generated dto, converting a zero value sample,
+// as a scaffold to track the cost of the conversions, e.g. with go test -bench . -benchmem
+func (g *GenerateDTOFromProtoGo) genBenchmarks(pbStructNames []string) error {
+	srcFile := g.newDTOFile()
+	g.genHeader(srcFile)
+	for importPath, alias := range g.importAliases {
+		srcFile.ImportAlias(importPath, alias)
+	}
+
+	code := NewPartialGenerator(srcFile.Empty())
+	code.NewLine()
+	benchmark := func(binding string, sample jen.Code) {
+		code.appendFunction(
+			"Benchmark"+binding,
+			nil,
+			[]jen.Code{jen.Id("b").Id("*").Qual("testing", "B")},
+			[]jen.Code{},
+			"",
+			jen.Id("sample").Op(":=").Op("&").Add(sample).Values(),
+			jen.Id("b").Dot("ReportAllocs").Call(),
+			jen.For(jen.Id("i").Op(":=").Lit(0), jen.Id("i").Op("<").Id("b").Dot("N"), jen.Id("i").Op("++")).Block(
+				g.bindingCall(binding, jen.Qual("context", "Background").Call(), jen.Id("sample")),
+			),
+		)
+		code.NewLine()
+		code.NewLine()
+	}
+	for _, pbStructName := range pbStructNames {
+		benchmark(g.fromPBFuncName(pbStructName), g.pbStructType(pbStructName))
+		benchmark(g.toPBFuncName(pbStructName), jen.Qual(g.dtoImportPath, g.dtoTypeName(pbStructName)))
+	}
+
+	benchFileFullPath := path.Join(g.dtoPackagePath, fmt.Sprintf(formatAutoGenDTOBenchFileName, g.serviceName))
+	return g.writeGeneratedFile(benchFileFullPath, srcFile.GoString())
+}
@@ -0,0 +1,102 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dave/jennifer/jen"
+	"github.com/kujtimiihoxha/kit/utils"
+)
+
+// genBuilder generates a builder of a dto with a chainable setter per field of the field manifest, e.g.
+//
+//	func NewHelloRequestBuilder() *HelloRequestBuilder
+//	func (h *HelloRequestBuilder) WithName(v string) *HelloRequestBuilder
+//	func (h *HelloRequestBuilder) WithTags(v ...string) *HelloRequestBuilder
+//	func (h *HelloRequestBuilder) Build() *HelloRequest
+//
+// the setters of the slices append to the field and the ones of the maps add the entries to the field, without altering
+// the dto already built, the other setters replace the field
+func (g *GenerateDTOFromProtoGo) genBuilder(currentPBStructName string, fieldManifest []fieldState) {
+	dtoTypeName := g.dtoTypeName(currentPBStructName)
+	builderName := dtoTypeName + "Builder"
+	recv := utils.ReceiverName(builderName, "v", "merged", "key", "value", "dto")
+	builder := jen.Id(recv).Id("*" + builderName)
+
+	g.code.appendMultilineComment([]string{
+		fmt.Sprintf("%s builds the %s dto with chainable setters, e.g. in tests.", builderName, dtoTypeName),
+	})
+	g.code.NewLine()
+	g.code.appendStruct(builderName, jen.Id("dto").Qual(g.dtoImportPath, dtoTypeName))
+
+	g.code.appendFunction(
+		"New"+builderName,
+		nil,
+		[]jen.Code{},
+		[]jen.Code{jen.Id("*" + builderName)},
+		"",
+		jen.Return(jen.Op("&").Id(builderName).Values()),
+	)
+	g.code.NewLine()
+	g.code.NewLine()
+
+	for _, fieldState := range fieldManifest {
+		field := jen.Id(recv).Dot("dto").Dot(fieldState.Name)
+		param := jen.Id("v").Add(g.dtoFieldType(fieldState))
+		body := []jen.Code{field.Clone().Op("=").Id("v")}
+		if elemType, ok := g.builderElemType(fieldState); ok {
+			// the full slice expression makes append copy the field, so the dto already built is left as is
+			param = jen.Id("v").Op("...").Add(elemType)
+			body = []jen.Code{
+				field.Clone().Op("=").Append(field.Clone().Index(jen.Empty(), jen.Len(field.Clone()), jen.Len(field.Clone())), jen.Id("v").Op("...")),
+			}
+		} else if fieldState.IsMap && fieldState.Override == nil {
+			body = []jen.Code{
+				jen.Id("merged").Op(":=").Make(g.dtoFieldType(fieldState), jen.Len(field.Clone()).Op("+").Len(jen.Id("v"))),
+				jen.For(jen.List(jen.Id("key"), jen.Id("value")).Op(":=").Range().Add(field.Clone())).Block(
+					jen.Id("merged").Index(jen.Id("key")).Op("=").Id("value"),
+				),
+				jen.For(jen.List(jen.Id("key"), jen.Id("value")).Op(":=").Range().Id("v")).Block(
+					jen.Id("merged").Index(jen.Id("key")).Op("=").Id("value"),
+				),
+				field.Clone().Op("=").Id("merged"),
+			}
+		}
+
+		g.code.appendFunction(
+			"With"+fieldState.Name,
+			builder.Clone(),
+			[]jen.Code{param},
+			[]jen.Code{jen.Id("*" + builderName)},
+			"",
+			append(body, jen.Return(jen.Id(recv)))...,
+		)
+		g.code.NewLine()
+		g.code.NewLine()
+	}
+
+	// the builder keeps building from a copy of the dto
+	g.code.appendFunction(
+		"Build",
+		builder.Clone(),
+		[]jen.Code{},
+		[]jen.Code{jen.Id("*").Qual(g.dtoImportPath, dtoTypeName)},
+		"",
+		jen.Id("dto").Op(":=").Id(recv).Dot("dto"),
+		jen.Return(jen.Op("&").Id("dto")),
+	)
+	g.code.NewLine()
+	g.code.NewLine()
+}
+
+// builderElemType returns the type of the elements a builder setter appends to a slice field, e.g. string for []string,
+// ok is false for the other fields and for []byte, which is set as a whole
+func (g *GenerateDTOFromProtoGo) builderElemType(fieldState fieldState) (jen.Code, bool) {
+	if !fieldState.IsSlice || fieldState.IsMap || fieldState.Override != nil || fieldState.PBType == "[]byte" {
+		return nil, false
+	}
+	if !fieldState.isConverted() && fieldState.ImportPath == "" || fieldState.Wrapper != "" {
+		return jen.Id(strings.TrimPrefix(fieldState.PBType, "[]")), true
+	}
+	return g.dtoElemType(fieldState), true
+}
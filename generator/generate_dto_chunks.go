@@ -0,0 +1,127 @@
+package generator
+
+import (
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+)
+
+// name of the numbered dto files of options.MaxStructsPerFile, e.g. z_helloService_dto_1.go
+const formatAutoGenDTOChunkFileName = `z_%s_dto_%d.go`
+
+// dtoChunks splits the pb structs to generate, in dependency order, into the groups generated each into its own
+// numbered dto file, so that a file mostly refers to the dto of the previous ones: a top level struct is grouped
+// with the structs it references not grouped yet, and the groups are gathered into files of at most
+// options.MaxStructsPerFile structs, a group exceeding it being a file of its own, there is a single group when
+// the structs do not exceed the threshold, generated into the dto file
+func (g *GenerateDTOFromProtoGo) dtoChunks(order, roots []string, pbStructManifest map[string]*structState) [][]string {
+	max := g.options.MaxStructsPerFile
+	if max <= 0 || len(order) <= max {
+		return [][]string{order}
+	}
+	isRoot := map[string]bool{}
+	for _, root := range roots {
+		isRoot[root] = true
+	}
+	position := map[string]int{}
+	for i, pbStructName := range order {
+		position[pbStructName] = i
+	}
+	grouped := map[string]bool{}
+	var group func(pbStructName string, members []string) []string
+	group = func(pbStructName string, members []string) []string {
+		if grouped[pbStructName] {
+			return members
+		}
+		grouped[pbStructName] = true
+		members = append(members, pbStructName)
+		for _, field := range pbStructManifest[pbStructName].Struct.Vars {
+			if referenced, ok := g.referencedStruct(pbStructName, field, pbStructManifest); ok {
+				members = group(referenced, members)
+			}
+		}
+		return members
+	}
+
+	chunks, chunk := [][]string{}, []string{}
+	for _, pbStructName := range order {
+		if !isRoot[pbStructName] || grouped[pbStructName] {
+			continue
+		}
+		members := group(pbStructName, nil)
+		sort.Slice(members, func(i, j int) bool { return position[members[i]] < position[members[j]] })
+		if len(chunk) > 0 && len(chunk)+len(members) > max {
+			chunks, chunk = append(chunks, chunk), []string{}
+		}
+		chunk = append(chunk, members...)
+	}
+	// every struct is referenced by a root, the ones which would not be are not left out
+	for _, pbStructName := range order {
+		if !grouped[pbStructName] {
+			chunk = append(chunk, pbStructName)
+		}
+	}
+	return append(chunks, chunk)
+}
+
+// useDTOChunkFile makes the dto of the next group of dtoChunks generated into a new numbered dto file,
+// along with their bindings unless options.Split is set
+func (g *GenerateDTOFromProtoGo) useDTOChunkFile() {
+	g.srcFile = g.newDTOFile()
+	for importPath, alias := range g.importAliases {
+		g.srcFile.ImportAlias(importPath, alias)
+	}
+	g.code = NewPartialGenerator(g.srcFile.Empty())
+	g.genHeader(g.srcFile)
+	g.code.NewLine()
+	if !g.options.Split {
+		g.bindingsSrcFile, g.bindingsCode = g.srcFile, g.code
+	}
+	g.chunkFiles = append(g.chunkFiles, g.srcFile)
+}
+
+// writeDTOChunkFiles writes the numbered dto files, see dtoChunks, and removes the generated ones left over from
+// a previous generation having more of them, or from before the structs were generated into the dto file only
+func (g *GenerateDTOFromProtoGo) writeDTOChunkFiles() error {
+	written := map[string]bool{}
+	for i, chunkFile := range g.chunkFiles {
+		filePath := path.Join(g.dtoPackagePath, fmt.Sprintf(formatAutoGenDTOChunkFileName, g.serviceName, i+1))
+		content := chunkFile.GoString()
+		g.generatedSources[filePath] = content
+		var err error
+		if g.mergesUserMethods() {
+			if content, err = g.preserveUserMethods(filePath, content); err != nil {
+				return err
+			}
+		}
+		if err = g.writeGeneratedFile(filePath, content); err != nil {
+			return err
+		}
+		written[path.Base(filePath)] = true
+	}
+	if g.options.Stdout {
+		return nil
+	}
+
+	names, err := g.fs.ReadDir(g.dtoPackagePath)
+	if err != nil {
+		return err
+	}
+	chunkFileName := g.dtoChunkFileName()
+	for _, name := range names {
+		if !chunkFileName.MatchString(name) || written[name] {
+			continue
+		}
+		filePath := path.Join(g.dtoPackagePath, name)
+		if src, err := g.fs.ReadFile(filePath); err != nil || !isGeneratedSource(src) {
+			continue
+		}
+		logrus.Info("removing the stale generated file: ", filePath)
+		if err := g.fs.Fs.Remove(filePath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dave/jennifer/jen"
+	"github.com/kujtimiihoxha/kit/parser"
+)
+
+// reserveDeclaredNames reserves the names the dto package declares, i.e. the dto types and their bindings,
+// for the field constants not to collide with them, see genFieldConstants
+func (g *GenerateDTOFromProtoGo) reserveDeclaredNames(pbGoFile *parser.File) {
+	g.declaredNames = map[string]bool{}
+	for _, pbStruct := range pbGoFile.Structures {
+		g.declaredNames[g.dtoTypeName(pbStruct.Name)] = true
+		g.declaredNames[g.fromPBFuncName(pbStruct.Name)] = true
+		g.declaredNames[g.toPBFuncName(pbStruct.Name)] = true
+	}
+	for _, definedType := range pbGoFile.DefinedTypes {
+		g.declaredNames[g.dtoTypeName(definedType.Name)] = true
+	}
+	for pbStructName := range g.importedPBStructs {
+		g.declaredNames[g.dtoTypeName(pbStructName)] = true
+		g.declaredNames[g.fromPBFuncName(pbStructName)] = true
+		g.declaredNames[g.toPBFuncName(pbStructName)] = true
+	}
+}
+
+// genFieldConstants generates a constant per field of a dto holding its json name, e.g. for field mask paths
+// or query projections, named <Dto>Field<Field>:
+//
+//	const (
+//		HelloRequestFieldName     = "name"
+//		HelloRequestFieldNickname = "nickname"
+//	)
+//
+// the constants are exported even for an unexported dto, a number is appended to a name already declared,
+// e.g. HelloRequestFieldName1, the fields not marshalled to json have no constant
+func (g *GenerateDTOFromProtoGo) genFieldConstants(currentPBStructName string, fieldManifest []fieldState) {
+	dtoTypeName := g.dtoTypeName(currentPBStructName)
+	defs := []jen.Code{}
+	for _, fieldState := range fieldManifest {
+		if fieldState.JSONName == "-" {
+			continue
+		}
+		name := strings.ToUpper(dtoTypeName[:1]) + dtoTypeName[1:] + "Field" + fieldState.Name
+		unique := name
+		for i := 1; g.declaredNames[unique]; i++ {
+			unique = fmt.Sprintf("%s%d", name, i)
+		}
+		if unique != name {
+			g.warnf("field constant %s is already declared, %s.%s is named %s", name, currentPBStructName, fieldState.Name, unique)
+		}
+		g.declaredNames[unique] = true
+		defs = append(defs, jen.Id(unique).Op("=").Lit(fieldState.JSONName))
+	}
+	if len(defs) == 0 {
+		return
+	}
+
+	g.code.appendMultilineComment([]string{fmt.Sprintf("the json names of the fields of %s", dtoTypeName)})
+	g.code.NewLine()
+	g.code.Raw().Const().Defs(defs...).Line()
+	g.code.NewLine()
+}
@@ -0,0 +1,87 @@
+package generator
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/dave/jennifer/jen"
+)
+
+// name of the test file checking that the dto still cover the pb structs, e.g. z_helloService_dto_drift_test.go
+const formatAutoGenDTODriftFileName = `z_%s_dto_drift_test.go`
+
+// genDriftCheck generates a test reflecting over every generated dto and its pb struct, which fails when pb.go got
+// a new field but the dto were not regenerated, a removed pb field already breaks the build of the bindings
+func (g *GenerateDTOFromProtoGo) genDriftCheck(pbStructNames []string) error {
+	srcFile := g.newDTOFile()
+	g.genHeader(srcFile)
+	for importPath, alias := range g.importAliases {
+		srcFile.ImportAlias(importPath, alias)
+	}
+
+	typeOf := func(t jen.Code) jen.Code {
+		return jen.Qual("reflect", "TypeOf").Call(jen.Parens(jen.Op("*").Add(t)).Parens(jen.Nil())).Dot("Elem").Call()
+	}
+	pairs := []jen.Code{}
+	for _, pbStructName := range pbStructNames {
+		pairs = append(pairs, jen.Values(
+			typeOf(g.pbStructType(pbStructName)),
+			typeOf(jen.Qual(g.dtoImportPath, g.dtoTypeName(pbStructName))),
+		))
+	}
+
+	// the fields excluded with @dto:skip are not covered on purpose
+	var declareSkipped, checkSkipped jen.Code = jen.Null(), jen.Null()
+	if len(g.skippedFields) > 0 {
+		skipped := jen.Dict{}
+		for _, skippedField := range g.skippedFields {
+			skipped[jen.Lit(skippedField)] = jen.True()
+		}
+		declareSkipped = jen.Id("skipped").Op(":=").Map(jen.String()).Bool().Values(skipped)
+		checkSkipped = jen.If(jen.Id("skipped").Index(jen.Id("pbType").Dot("Name").Call().Op("+").Lit(".").Op("+").Id("field").Dot("Name"))).Block(jen.Continue())
+	}
+
+	code := NewPartialGenerator(srcFile.Empty())
+	code.NewLine()
+	code.appendMultilineComment([]string{
+		"TestDTODrift fails when a pb struct has a field its dto does not cover, i.e. when the dto need to be regenerated.",
+	})
+	code.NewLine()
+	code.appendFunction(
+		"TestDTODrift",
+		nil,
+		[]jen.Code{jen.Id("t").Id("*").Qual("testing", "T")},
+		[]jen.Code{},
+		"",
+		// the pb and dto type of every generated dto
+		jen.Id("dtoTypes").Op(":=").Index().Index(jen.Lit(2)).Qual("reflect", "Type").Custom(jen.Options{
+			Open:      "{",
+			Close:     "}",
+			Separator: ",",
+			Multi:     true,
+		}, pairs...),
+		declareSkipped,
+		jen.For(jen.Id("_").Op(",").Id("types").Op(":=").Range().Id("dtoTypes")).Block(
+			jen.List(jen.Id("pbType"), jen.Id("dtoType")).Op(":=").List(jen.Id("types").Index(jen.Lit(0)), jen.Id("types").Index(jen.Lit(1))),
+			jen.For(jen.Id("i").Op(":=").Lit(0), jen.Id("i").Op("<").Id("pbType").Dot("NumField").Call(), jen.Id("i").Op("++")).Block(
+				jen.Id("field").Op(":=").Id("pbType").Dot("Field").Call(jen.Id("i")),
+				jen.Comment("unexported fields, e.g. the pb native fields, are not part of the dto"),
+				jen.If(jen.Id("field").Dot("PkgPath").Op("!=").Lit("")).Block(jen.Continue()),
+				checkSkipped,
+				jen.If(
+					jen.List(jen.Id("_"), jen.Id("ok")).Op(":=").Id("dtoType").Dot("FieldByName").Call(jen.Id("field").Dot("Name")),
+					jen.Op("!").Id("ok"),
+				).Block(
+					jen.Id("t").Dot("Errorf").Call(
+						jen.Lit("pb field %s.%s is not covered by dto %s, the dto need to be regenerated"),
+						jen.Id("pbType").Dot("Name").Call(), jen.Id("field").Dot("Name"), jen.Id("dtoType").Dot("Name").Call(),
+					),
+				),
+			),
+		),
+	)
+	code.NewLine()
+
+	driftFileFullPath := path.Join(g.dtoPackagePath, fmt.Sprintf(formatAutoGenDTODriftFileName, g.serviceName))
+	return g.writeGeneratedFile(driftFileFullPath, srcFile.GoString())
+}
@@ -0,0 +1,146 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/dave/jennifer/jen"
+)
+
+// the modes of options.JSONFloatMode for the NaN and infinite floats, which encoding/json fails to marshal
+const (
+	// JSONFloatNull marshals them as null
+	JSONFloatNull = "null"
+	// JSONFloatError fails the marshalling with an error naming the field
+	JSONFloatError = "error"
+	// JSONFloatString marshals them as "NaN", "Infinity" or "-Infinity", as protojson does
+	JSONFloatString = "string"
+)
+
+// isFloatField tells if a dto field is a float or an optional float, e.g. float64 or *float32,
+// the collections of floats are marshalled as is
+func isFloatField(fieldState fieldState) bool {
+	scalarType, ok := optionalScalarType(fieldState)
+	if !ok && !fieldState.isConverted() && fieldState.ImportPath == "" && fieldState.Override == nil {
+		scalarType = fieldState.PBType
+	}
+	return scalarType == "float32" || scalarType == "float64"
+}
+
+// jsonFloatFields returns what the MarshalJSON method of a dto needs for its float fields with options.JSONFloatMode,
+// i.e. the fields shadowing them along with their values for the null and string modes, the checks of their values for the error mode
+func (g *GenerateDTOFromProtoGo) jsonFloatFields(recv, currentPBStructName string, fieldManifest []fieldState) (shadows []jen.Code, values jen.Dict, checks []jen.Code) {
+	values = jen.Dict{}
+	if g.options.JSONFloatMode == "" {
+		return nil, values, nil
+	}
+	for _, fieldState := range fieldManifest {
+		if !isFloatField(fieldState) || fieldState.Redacted || fieldState.JSONName == "-" {
+			continue
+		}
+		g.usesJSONFloat = true
+		field := jen.Id(recv).Dot(fieldState.Name)
+		if g.options.JSONFloatMode == JSONFloatError {
+			checks = append(checks, jen.If(
+				jen.Err().Op(":=").Id("checkJSONFloat").Call(jen.Lit(g.dtoTypeName(currentPBStructName)+"."+fieldState.Name), field),
+				jen.Err().Op("!=").Nil(),
+			).Block(jen.Return(jen.Nil(), jen.Err())))
+			continue
+		}
+		shadows = append(shadows, jen.Id(fieldState.Name).Interface().Tag(map[string]string{"json": fieldState.JSONName}))
+		values[jen.Id(fieldState.Name)] = jen.Id("jsonFloat").Call(field)
+	}
+	return shadows, values, checks
+}
+
+// genJSONFloatHelpers generates the helpers of the MarshalJSON methods handling the NaN and infinite floats as of options.JSONFloatMode,
+// jsonFloat replacing them for the null and string modes, checkJSONFloat rejecting them for the error mode
+func (g *GenerateDTOFromProtoGo) genJSONFloatHelpers() {
+	isNaNOrInf := jen.Qual("math", "IsNaN").Call(jen.Id("f")).Op("||").Qual("math", "IsInf").Call(jen.Id("f"), jen.Lit(0))
+	switch g.options.JSONFloatMode {
+	case JSONFloatError:
+		g.code.appendMultilineComment([]string{"checkJSONFloat fails when v, the value of a float field, is NaN or infinite, which cannot be marshalled to json."})
+		g.code.NewLine()
+		g.code.appendFunction(
+			"checkJSONFloat",
+			nil,
+			[]jen.Code{jen.Id("field").String(), jen.Id("v").Interface()},
+			[]jen.Code{},
+			"error",
+			jen.If(jen.List(jen.Id("f"), jen.Id("ok")).Op(":=").Id("floatValue").Call(jen.Id("v")), jen.Id("ok").Op("&&").Parens(isNaNOrInf)).Block(
+				jen.Return(jen.Qual("fmt", "Errorf").Call(jen.Lit("%s is %v, which cannot be marshalled to json"), jen.Id("field"), jen.Id("f"))),
+			),
+			jen.Return(jen.Nil()),
+		)
+	case JSONFloatString:
+		g.code.appendMultilineComment([]string{"jsonFloat returns the value of a float field to marshal to json, the NaN and infinite floats as strings as in protojson."})
+		g.code.NewLine()
+		g.code.appendFunction(
+			"jsonFloat",
+			nil,
+			[]jen.Code{jen.Id("v").Interface()},
+			[]jen.Code{},
+			"interface{}",
+			jen.If(jen.List(jen.Id("f"), jen.Id("ok")).Op(":=").Id("floatValue").Call(jen.Id("v")), jen.Id("ok")).Block(
+				jen.Switch().Block(
+					jen.Case(jen.Qual("math", "IsNaN").Call(jen.Id("f"))).Block(jen.Return(jen.Lit("NaN"))),
+					jen.Case(jen.Qual("math", "IsInf").Call(jen.Id("f"), jen.Lit(1))).Block(jen.Return(jen.Lit("Infinity"))),
+					jen.Case(jen.Qual("math", "IsInf").Call(jen.Id("f"), jen.Lit(-1))).Block(jen.Return(jen.Lit("-Infinity"))),
+				),
+			),
+			jen.Return(jen.Id("v")),
+		)
+	default:
+		g.code.appendMultilineComment([]string{"jsonFloat returns the value of a float field to marshal to json, nil for the NaN and infinite floats."})
+		g.code.NewLine()
+		g.code.appendFunction(
+			"jsonFloat",
+			nil,
+			[]jen.Code{jen.Id("v").Interface()},
+			[]jen.Code{},
+			"interface{}",
+			jen.If(jen.List(jen.Id("f"), jen.Id("ok")).Op(":=").Id("floatValue").Call(jen.Id("v")), jen.Id("ok").Op("&&").Parens(isNaNOrInf)).Block(
+				jen.Return(jen.Nil()),
+			),
+			jen.Return(jen.Id("v")),
+		)
+	}
+	g.code.NewLine()
+	g.code.NewLine()
+
+	floatCase := func(floatType string, value jen.Code) jen.Code {
+		return jen.Case(jen.Id(floatType)).Block(jen.Return(value, jen.True()))
+	}
+	g.code.appendMultilineComment([]string{"floatValue returns the value of a float field, ok is false for a nil optional float."})
+	g.code.NewLine()
+	g.code.appendFunction(
+		"floatValue",
+		nil,
+		[]jen.Code{jen.Id("v").Interface()},
+		[]jen.Code{jen.Id("f").Float64(), jen.Id("ok").Bool()},
+		"",
+		jen.Switch(jen.Id("v").Op(":=").Id("v").Assert(jen.Type())).Block(
+			floatCase("float32", jen.Float64().Call(jen.Id("v"))),
+			floatCase("float64", jen.Id("v")),
+			jen.Case(jen.Op("*").Float32()).Block(
+				jen.If(jen.Id("v").Op("!=").Nil()).Block(jen.Return(jen.Float64().Call(jen.Op("*").Id("v")), jen.True())),
+			),
+			jen.Case(jen.Op("*").Float64()).Block(
+				jen.If(jen.Id("v").Op("!=").Nil()).Block(jen.Return(jen.Op("*").Id("v"), jen.True())),
+			),
+		),
+		jen.Return(jen.Lit(0), jen.False()),
+	)
+	g.code.NewLine()
+	g.code.NewLine()
+}
+
+// jsonFloatComment returns the sentence documenting how the MarshalJSON method of a dto handles its NaN and infinite floats
+func (g *GenerateDTOFromProtoGo) jsonFloatComment(dtoTypeName string) string {
+	switch g.options.JSONFloatMode {
+	case JSONFloatError:
+		return fmt.Sprintf("MarshalJSON fails when a float of %s is NaN or infinite.", dtoTypeName)
+	case JSONFloatString:
+		return fmt.Sprintf("MarshalJSON marshals the NaN and infinite floats of %s as strings.", dtoTypeName)
+	}
+	return fmt.Sprintf("MarshalJSON marshals the NaN and infinite floats of %s as null.", dtoTypeName)
+}
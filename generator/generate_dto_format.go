@@ -0,0 +1,39 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os/exec"
+	"strings"
+)
+
+// formatGeneratedSource formats a generated go source with options.Formatter, e.g. gofumpt or "goimports -local foo",
+// which is given the source on stdin and must write the formatted source on stdout,
+// the source is formatted with gofmt if the formatter is not found on PATH
+func (g *GenerateDTOFromProtoGo) formatGeneratedSource(src string) (string, error) {
+	formatter := g.options.Formatter
+	args := strings.Fields(formatter)
+	if len(args) == 0 {
+		return src, nil
+	}
+	if _, err := exec.LookPath(args[0]); err != nil {
+		g.warnf("formatter %s not found, falling back to gofmt", args[0])
+		formatted, err := format.Source([]byte(src))
+		if err != nil {
+			return "", err
+		}
+		return string(formatted), nil
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(src)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// return stderr to debug, the err of a failing formatter only holds its exit status
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("formatter %s: %v, %s", formatter, err, stderr.String())
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,68 @@
+package generator
+
+import (
+	"strings"
+
+	"github.com/dave/jennifer/jen"
+	"github.com/kujtimiihoxha/kit/utils"
+)
+
+// genGetters generates a nil-safe getter per field of the field manifest, as the getters of the pb structs, e.g.
+//
+//	func (h *HelloRequest) GetName() (v string)
+//	func (h *HelloRequest) GetNickname() (v string) // Nickname *string
+//
+// a getter returns the zero value when the dto is nil, the optional scalars, i.e. the pointers to a string, bool or number,
+// are dereferenced and also give the zero value when nil, the other fields are returned as is
+func (g *GenerateDTOFromProtoGo) genGetters(currentPBStructName string, fieldManifest []fieldState) {
+	dtoTypeName := g.dtoTypeName(currentPBStructName)
+	recv := utils.ReceiverName(dtoTypeName, "v")
+
+	for _, fieldState := range fieldManifest {
+		field := jen.Id(recv).Dot(fieldState.Name)
+		resultType := g.dtoFieldType(fieldState)
+		cond := jen.Id(recv).Op("!=").Nil()
+		value := field.Clone()
+		if scalarType, ok := optionalScalarType(fieldState); ok {
+			resultType = jen.Id(scalarType)
+			cond = cond.Op("&&").Add(field.Clone()).Op("!=").Nil()
+			value = jen.Op("*").Add(field.Clone())
+		}
+
+		g.code.appendFunction(
+			"Get"+fieldState.Name,
+			jen.Id(recv).Id("*"+dtoTypeName),
+			[]jen.Code{},
+			[]jen.Code{jen.Id("v").Add(resultType)},
+			"",
+			jen.If(cond).Block(jen.Id("v").Op("=").Add(value)),
+			jen.Return(jen.Id("v")),
+		)
+		g.code.NewLine()
+		g.code.NewLine()
+	}
+}
+
+// optionalScalarType returns the type of the scalar a dto field points to, e.g. string for a proto2 optional string
+// or a *wrapperspb.StringValue, both being a *string in dto, ok is false for the other fields
+func optionalScalarType(fieldState fieldState) (string, bool) {
+	if fieldState.IsMap || fieldState.IsSlice || fieldState.ArrayLen != "" || fieldState.Override != nil {
+		return "", false
+	}
+	if fieldState.WellKnown != nil && fieldState.WellKnown.importPath == wrapperspbImportPath {
+		for _, wrapper := range wrapperTypes {
+			if wrapper.name == fieldState.WellKnown.name && wrapper.goType != "[]byte" {
+				return wrapper.goType, true
+			}
+		}
+		return "", false
+	}
+	if fieldState.isConverted() || fieldState.ImportPath != "" || !strings.HasPrefix(fieldState.PBType, "*") {
+		return "", false
+	}
+	switch scalarType := strings.TrimPrefix(fieldState.PBType, "*"); scalarType {
+	case "string", "bool", "int32", "int64", "uint32", "uint64", "float32", "float64":
+		return scalarType, true
+	}
+	return "", false
+}
@@ -0,0 +1,150 @@
+package generator
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/dave/jennifer/jen"
+	"github.com/kujtimiihoxha/kit/parser"
+	"github.com/kujtimiihoxha/kit/utils"
+	"github.com/spf13/viper"
+)
+
+// name of the file holding the grpc transport wiring of the dto, e.g. z_helloService_grpc_bindings.go
+const formatAutoGenGRPCBindingsFileName = `z_%s_grpc_bindings.go`
+
+// genGRPCBindings generates the go-kit transport/grpc encode / decode funcs of every method of the service interface,
+// converting the gRPC requests / replies from / to their dto with the FromPB / ToPB bindings, e.g. for a method Foo:
+//
+//	DecodeFooRequest and EncodeFooResponse, used by the grpc server
+//	EncodeFooRequest and DecodeFooResponse, used by the grpc client
+//
+// a func is only generated when the dto of its FooRequest / FooResponse pb struct has been generated
+func (g *GenerateDTOFromProtoGo) genGRPCBindings(pbStructManifest map[string]*structState) error {
+	serviceFilePath := path.Join(
+		fmt.Sprintf(viper.GetString("gk_service_path_format"), utils.ToLowerSnakeCase(g.serviceName)),
+		viper.GetString("gk_service_file_name"),
+	)
+	serviceSrc, err := g.fs.ReadFile(serviceFilePath)
+	if err != nil {
+		return fmt.Errorf("err reading service file at: %s, err: %v", serviceFilePath, err)
+	}
+	serviceFile, err := parser.NewFileParser().Parse([]byte(serviceSrc))
+	if err != nil {
+		return fmt.Errorf("err parsing service file at: %s, err: %v", serviceFilePath, err)
+	}
+
+	interfaceName := utils.ToCamelCase(g.serviceName + "Service")
+	var serviceInterface *parser.Interface
+	for i, v := range serviceFile.Interfaces {
+		if v.Name == interfaceName {
+			serviceInterface = &serviceFile.Interfaces[i]
+			break
+		}
+	}
+	if serviceInterface == nil {
+		return fmt.Errorf("could not find the service interface %s in: %s", interfaceName, serviceFilePath)
+	}
+
+	srcFile := g.newDTOFile()
+	g.genHeader(srcFile)
+	for importPath, alias := range g.importAliases {
+		srcFile.ImportAlias(importPath, alias)
+	}
+	code := NewPartialGenerator(srcFile.Empty())
+	code.NewLine()
+
+	generated := func(pbStructName string) bool {
+		structState, ok := pbStructManifest[pbStructName]
+		if !ok || !structState.Visited {
+			g.warnf("skipping grpc bindings of %s as its dto is not generated", pbStructName)
+			return false
+		}
+		return true
+	}
+
+	for _, m := range serviceInterface.Methods {
+		if string(m.Name[0]) == strings.ToLower(string(m.Name[0])) {
+			continue
+		}
+		requestName := utils.ToCamelCase(m.Name) + "Request"
+		responseName := utils.ToCamelCase(m.Name) + "Response"
+
+		if generated(requestName) {
+			g.genGRPCConversion(code,
+				fmt.Sprintf("Decode%s", requestName),
+				"transport/grpc.DecodeRequestFunc that converts a gRPC request to its dto",
+				jen.Id("*").Qual(g.pbPackagePath, requestName),
+				g.fromPBFuncName(requestName),
+				g.fallibleFromPB[requestName],
+			)
+			g.genGRPCConversion(code,
+				fmt.Sprintf("Encode%s", requestName),
+				"transport/grpc.EncodeRequestFunc that converts a dto request to its gRPC request",
+				jen.Id("*").Qual(g.dtoImportPath, g.dtoTypeName(requestName)),
+				g.toPBFuncName(requestName),
+				g.fallibleToPB[requestName],
+			)
+		}
+		if generated(responseName) {
+			g.genGRPCConversion(code,
+				fmt.Sprintf("Encode%s", responseName),
+				"transport/grpc.EncodeResponseFunc that converts a dto response to its gRPC reply",
+				jen.Id("*").Qual(g.dtoImportPath, g.dtoTypeName(responseName)),
+				g.toPBFuncName(responseName),
+				g.fallibleToPB[responseName],
+			)
+			g.genGRPCConversion(code,
+				fmt.Sprintf("Decode%s", responseName),
+				"transport/grpc.DecodeResponseFunc that converts a gRPC reply to its dto",
+				jen.Id("*").Qual(g.pbPackagePath, responseName),
+				g.fromPBFuncName(responseName),
+				g.fallibleFromPB[responseName],
+			)
+		}
+	}
+
+	grpcBindingsFileFullPath := path.Join(g.dtoPackagePath, fmt.Sprintf(formatAutoGenGRPCBindingsFileName, g.serviceName))
+	return g.writeGeneratedFile(grpcBindingsFileFullPath, srcFile.GoString())
+}
+
+// genGRPCConversion generates a transport/grpc encode / decode func asserting its input is of type from,
+// and converting it with the given FromPB / ToPB binding, e.g.
+//
+//	func DecodeFooRequest(_ context.Context, r interface{}) (interface{}, error) {...}
+func (g *GenerateDTOFromProtoGo) genGRPCConversion(code *PartialGenerator, funcName, doc string, from jen.Code, binding string, fallible bool) {
+	conversion := g.bindingCall(binding, jen.Id("ctx"), jen.Id("v"))
+	// the context is only named when it is passed to the bindings
+	ctx := jen.Id("_")
+	if g.options.CtxBindings {
+		ctx = jen.Id("ctx")
+	}
+	result := jen.Return(conversion, jen.Nil())
+	if fallible {
+		result = jen.Return(conversion)
+	}
+
+	code.appendMultilineComment([]string{fmt.Sprintf("%s is a %s.", funcName, doc)})
+	code.NewLine()
+	code.appendFunction(
+		funcName,
+		nil,
+		[]jen.Code{
+			ctx.Qual("context", "Context"),
+			jen.Id("r").Interface(),
+		},
+		[]jen.Code{
+			jen.Interface(),
+			jen.Error(),
+		},
+		"",
+		jen.List(jen.Id("v"), jen.Id("ok")).Op(":=").Id("r").Assert(from),
+		jen.If(jen.Op("!").Id("ok")).Block(
+			jen.Return(jen.Nil(), jen.Qual("fmt", "Errorf").Call(jen.Lit(funcName+": unexpected type %T"), jen.Id("r"))),
+		),
+		result,
+	)
+	code.NewLine()
+	code.NewLine()
+}
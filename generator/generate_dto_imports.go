@@ -0,0 +1,292 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	goparser "go/parser"
+	"go/token"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/dave/jennifer/jen"
+	"github.com/kujtimiihoxha/kit/parser"
+	"github.com/kujtimiihoxha/kit/utils"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// importedPBStruct is a pb struct of a pb package of the project imported by pb.go, followed with options.FollowImports,
+// it is keyed in the manifest by its type as qualified in pb.go, e.g. commonpb.Metadata, see loadImportedPBStructs
+type importedPBStruct struct {
+	importPath string
+	name       string
+
+	// namespace is the name of its package in dto, e.g. Common for common/pkg/grpc/pb, see importedNamespace
+	namespace string
+
+	// dtoName is the name of its dto without the affixes, its own name or, when it collides with the name of
+	// another dto, its name in its namespace, e.g. CommonMetadata
+	dtoName string
+}
+
+// importedPackage holds the pb structs of a pb package imported by pb.go and what their field types refer to, i.e.
+// the types declared by the package and the packages its files import, keyed by the name they are referred to by
+type importedPackage struct {
+	namespace string
+	structs   map[string]parser.Struct
+	declared  map[string]bool
+	imports   map[string]string
+}
+
+// loadImportedPBStructs adds the pb structs of the pb packages of the project imported by pb.go to the manifest, when
+// they are referenced by the fields of pb.go or by the fields of the ones added, so that they get a dto, e.g. for
+// Common *commonpb.Metadata:
+//
+//	type Metadata struct {...}
+//	func MetadataFromPB(pb *commonpb.Metadata) *Metadata
+//
+// the dto of an imported pb struct is named after its package when another dto has its name, e.g. CommonMetadata and
+// UserMetadata for commonpb.Metadata and userpb.Metadata, the dto of pb.go keeping their name, the packages outside of
+// the project and the types which are not pb structs, e.g. the enums, are kept as is
+func (g *GenerateDTOFromProtoGo) loadImportedPBStructs(pbStructManifest map[string]*structState) error {
+	g.importedPBStructs = map[string]importedPBStruct{}
+	packages := map[string]*importedPackage{}
+	queue := []string{}
+	for _, structState := range pbStructManifest {
+		for _, field := range structState.Struct.Vars {
+			if ast.IsExported(field.Name) {
+				queue = append(queue, field.Type)
+			}
+		}
+	}
+	sort.Strings(queue)
+	for ; len(queue) > 0; queue = queue[1:] {
+		for _, qualifiedName := range qualifiedTypeNames(queue[0]) {
+			if _, ok := pbStructManifest[qualifiedName]; ok {
+				continue
+			}
+			dot := strings.Index(qualifiedName, ".")
+			importPath, ok := g.pbImports[qualifiedName[:dot]]
+			if !ok || importPath == g.pbPackagePath {
+				continue
+			}
+			pkg, err := g.importedPackage(importPath, packages)
+			if err != nil {
+				return err
+			} else if pkg == nil {
+				continue
+			}
+			pbStruct, ok := pkg.structs[qualifiedName[dot+1:]]
+			if !ok || len(pbStruct.TypeParams) > 0 {
+				continue
+			}
+
+			logrus.Info("following the imported pb struct: ", qualifiedName)
+			pbStruct.Name = qualifiedName
+			vars := []parser.NamedTypeValue{}
+			for _, field := range pbStruct.Vars {
+				field.Type = g.qualifyImportedType(field.Type, qualifiedName[:dot], pkg)
+				field.TypeInfo = parser.ParseType(field.Type)
+				vars = append(vars, field)
+				if ast.IsExported(field.Name) {
+					queue = append(queue, field.Type)
+				}
+			}
+			pbStruct.Vars = vars
+			pbStructManifest[qualifiedName] = &structState{Struct: pbStruct}
+			g.importedPBStructs[qualifiedName] = importedPBStruct{
+				importPath: importPath,
+				name:       qualifiedName[dot+1:],
+				namespace:  pkg.namespace,
+			}
+		}
+	}
+	return g.nameImportedDTO(pbStructManifest)
+}
+
+// nameImportedDTO names the dto of the imported pb structs, namespacing the names taken by another dto, see importedPBStruct
+func (g *GenerateDTOFromProtoGo) nameImportedDTO(pbStructManifest map[string]*structState) error {
+	counts := map[string]int{}
+	for pbStructName := range pbStructManifest {
+		if imported, ok := g.importedPBStructs[pbStructName]; ok {
+			counts[imported.name]++
+		} else {
+			counts[pbStructName]++
+		}
+	}
+	owners := map[string]string{}
+	for pbStructName := range pbStructManifest {
+		if _, ok := g.importedPBStructs[pbStructName]; !ok {
+			owners[pbStructName] = pbStructName
+		}
+	}
+	names := []string{}
+	for pbStructName := range g.importedPBStructs {
+		names = append(names, pbStructName)
+	}
+	sort.Strings(names)
+	for _, pbStructName := range names {
+		imported := g.importedPBStructs[pbStructName]
+		imported.dtoName = imported.name
+		if counts[imported.name] > 1 {
+			imported.dtoName = imported.namespace + imported.name
+		}
+		if owner, ok := owners[imported.dtoName]; ok {
+			return fmt.Errorf("cannot name the dto of %s and %s apart, both are named %s", owner, pbStructName, imported.dtoName)
+		}
+		owners[imported.dtoName] = pbStructName
+		g.importedPBStructs[pbStructName] = imported
+	}
+	return nil
+}
+
+// importedPackage returns the pb structs of the imported package importPath, read from the *.pb.go files of its folder
+// in the project, the import paths of the project being the ones of the pb package with another folder,
+// nil is returned for a package outside of the project
+func (g *GenerateDTOFromProtoGo) importedPackage(importPath string, packages map[string]*importedPackage) (*importedPackage, error) {
+	if pkg, ok := packages[importPath]; ok {
+		return pkg, nil
+	}
+	packages[importPath] = nil
+	modulePath := strings.TrimSuffix(g.pbPackagePath, path.Dir(g.protoGoFileFullPath))
+	folder := strings.TrimPrefix(importPath, modulePath)
+	if !strings.HasPrefix(importPath, modulePath) || folder == "" {
+		logrus.Info("not following the imported package ", importPath, ", it is outside of the project")
+		return nil, nil
+	}
+	if exists, err := g.fs.Exists(folder); err != nil {
+		return nil, fmt.Errorf("err checking the folder of the imported package %s at: %s, err: %v", importPath, folder, err)
+	} else if !exists {
+		logrus.Info("not following the imported package ", importPath, ", its folder is not in the project: ", folder)
+		return nil, nil
+	}
+	fileNames, err := g.fs.ReadDir(folder)
+	if err != nil {
+		return nil, fmt.Errorf("err reading the folder of the imported package %s at: %s, err: %v", importPath, folder, err)
+	}
+
+	pkg := &importedPackage{structs: map[string]parser.Struct{}, declared: map[string]bool{}, imports: map[string]string{}}
+	packageName := ""
+	for _, fileName := range fileNames {
+		if !strings.HasSuffix(fileName, ".pb.go") {
+			continue
+		}
+		filePath := path.Join(folder, fileName)
+		src, err := g.fs.ReadFile(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("err reading pb go file of the imported package %s at: %s, err: %v", importPath, filePath, err)
+		}
+		file, err := parser.NewFileParser().Parse([]byte(src))
+		if err != nil {
+			return nil, fmt.Errorf("err parsing pb go file of the imported package %s at: %s, err: %v", importPath, filePath, err)
+		}
+		packageName = file.Package
+		for _, pbStruct := range file.Structures {
+			pkg.structs[pbStruct.Name] = pbStruct
+			pkg.declared[pbStruct.Name] = true
+		}
+		for _, definedType := range file.DefinedTypes {
+			pkg.declared[definedType.Name] = true
+		}
+		for name, path := range file.ImportPaths {
+			pkg.imports[name] = path
+		}
+	}
+	pkg.namespace = importedNamespace(folder, packageName)
+	packages[importPath] = pkg
+	return pkg, nil
+}
+
+// importedNamespace returns the namespace of the dto of an imported pb package, the name of its service for the pb
+// package of a service, e.g. Common for common/pkg/grpc/pb, or else its package name without its pb suffix,
+// e.g. Common for commonpb
+func importedNamespace(folder, packageName string) string {
+	if service := strings.TrimSuffix(folder, "/pkg/grpc/pb"); service != folder {
+		return utils.ToCamelCase(service)
+	}
+	if name := strings.TrimSuffix(packageName, "pb"); name != "" {
+		return utils.ToCamelCase(name)
+	}
+	return utils.ToCamelCase(packageName)
+}
+
+// qualifiedTypeNames returns the types of other packages a type refers to, as qualified in it, e.g. commonpb.Metadata
+// for map[string]*commonpb.Metadata
+func qualifiedTypeNames(typ string) []string {
+	typeExpr, err := goparser.ParseExpr(typ)
+	if err != nil {
+		return nil
+	}
+	names := []string{}
+	ast.Inspect(typeExpr, func(node ast.Node) bool {
+		if selector, ok := node.(*ast.SelectorExpr); ok {
+			if pkg, ok := selector.X.(*ast.Ident); ok {
+				names = append(names, pkg.Name+"."+selector.Sel.Name)
+			}
+			return false
+		}
+		return true
+	})
+	return names
+}
+
+// qualifyImportedType returns the type of a field of an imported pb struct as it is referred to from pb.go, the types
+// of the package being qualified by the name pb.go imports it with, e.g. *commonpb.Tag for *Tag, and the types of
+// the packages it imports by the name pb.go imports them with, an alias being registered for the ones pb.go does not import
+func (g *GenerateDTOFromProtoGo) qualifyImportedType(typ, name string, pkg *importedPackage) string {
+	typeExpr, err := goparser.ParseExpr(typ)
+	if err != nil {
+		return typ
+	}
+	typeExpr = astutil.Apply(typeExpr, func(c *astutil.Cursor) bool {
+		switch n := c.Node().(type) {
+		case *ast.SelectorExpr:
+			if pkgIdent, ok := n.X.(*ast.Ident); ok {
+				if importPath, ok := pkg.imports[pkgIdent.Name]; ok {
+					c.Replace(&ast.SelectorExpr{X: ast.NewIdent(g.pbImportName(importPath, pkgIdent.Name)), Sel: n.Sel})
+				}
+			}
+			return false
+		case *ast.Ident:
+			if pkg.declared[n.Name] {
+				c.Replace(&ast.SelectorExpr{X: ast.NewIdent(name), Sel: n})
+			}
+		}
+		return true
+	}, nil).(ast.Expr)
+	var buf bytes.Buffer
+	if err = format.Node(&buf, token.NewFileSet(), typeExpr); err != nil {
+		return typ
+	}
+	return buf.String()
+}
+
+// pbImportName returns the name pb.go imports a package with, registering an alias for a package pb.go does not import,
+// e.g. for a package imported by an imported pb package
+func (g *GenerateDTOFromProtoGo) pbImportName(importPath, name string) string {
+	names := []string{}
+	for pbName, pbImportPath := range g.pbImports {
+		if pbImportPath == importPath {
+			names = append(names, pbName)
+		}
+	}
+	if len(names) > 0 {
+		sort.Strings(names)
+		return names[0]
+	}
+	g.addImportAlias(importPath, name)
+	g.pbImports[g.importAliases[importPath]] = importPath
+	return g.importAliases[importPath]
+}
+
+// pbStructType returns the type of a pb struct, qualified by its package, e.g. pb.HelloRequest or commonpb.Metadata
+// for an imported pb struct, see loadImportedPBStructs
+func (g *GenerateDTOFromProtoGo) pbStructType(pbStructName string) *jen.Statement {
+	if imported, ok := g.importedPBStructs[pbStructName]; ok {
+		return jen.Qual(imported.importPath, imported.name)
+	}
+	return jen.Qual(g.pbPackagePath, pbStructName)
+}
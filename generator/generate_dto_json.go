@@ -0,0 +1,46 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/dave/jennifer/jen"
+	"github.com/kujtimiihoxha/kit/utils"
+)
+
+// genJSONHelpers generates the helpers (un)marshalling a dto to json with encoding/json, e.g.
+//
+//	func HelloRequestFromJSON(data []byte) (*HelloRequest, error)
+//	func (h *HelloRequest) ToJSON() ([]byte, error)
+func (g *GenerateDTOFromProtoGo) genJSONHelpers(currentPBStructName string) {
+	dtoTypeName := g.dtoTypeName(currentPBStructName)
+	recv := utils.ReceiverName(dtoTypeName, "json")
+
+	g.code.appendMultilineComment([]string{fmt.Sprintf("%sFromJSON unmarshals a %s dto from json.", dtoTypeName, dtoTypeName)})
+	g.code.NewLine()
+	g.code.appendFunction(
+		dtoTypeName+"FromJSON",
+		nil,
+		[]jen.Code{jen.Id("data").Index().Byte()},
+		[]jen.Code{jen.Id("*" + dtoTypeName), jen.Error()},
+		"",
+		jen.Id("res").Op(":=").Op("&").Id(dtoTypeName).Values(),
+		jen.If(jen.Err().Op(":=").Qual("encoding/json", "Unmarshal").Call(jen.Id("data"), jen.Id("res")), jen.Err().Op("!=").Nil()).
+			Block(jen.Return(jen.Nil(), jen.Err())),
+		jen.Return(jen.Id("res"), jen.Nil()),
+	)
+	g.code.NewLine()
+	g.code.NewLine()
+
+	g.code.appendMultilineComment([]string{fmt.Sprintf("ToJSON marshals the %s dto to json.", dtoTypeName)})
+	g.code.NewLine()
+	g.code.appendFunction(
+		"ToJSON",
+		jen.Id(recv).Id("*"+dtoTypeName),
+		[]jen.Code{},
+		[]jen.Code{jen.Index().Byte(), jen.Error()},
+		"",
+		jen.Return(jen.Qual("encoding/json", "Marshal").Call(jen.Id(recv))),
+	)
+	g.code.NewLine()
+	g.code.NewLine()
+}
@@ -0,0 +1,95 @@
+package generator
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// name of the file listing the pb structs and fields to skip in every service of the project, read from the project folder
+const kitignoreFileName = ".kitignore"
+
+// kitignore holds the patterns of .kitignore, one per line, a struct name or a Struct.Field, both being glob patterns
+// as of path.Match, e.g.
+//
+//	# internal messages are not exposed
+//	*Internal
+//	HelloRequest.AuditedAt
+//	*.Secret*
+//
+// the text following a # is a comment, the excluded structs are skipped as with options.Exclude,
+// the ignored fields as with the @dto:skip directive
+type kitignore struct {
+	structs []string
+	// fields are the struct and field patterns of the Struct.Field lines
+	fields [][2]string
+}
+
+// parseKitignore parses the content of .kitignore, every pattern must be valid
+func parseKitignore(src string) (*kitignore, error) {
+	ignore := &kitignore{}
+	for i, line := range strings.Split(src, "\n") {
+		if comment := strings.Index(line, "#"); comment != -1 {
+			line = line[:comment]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		patterns := strings.SplitN(line, ".", 2)
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+				return nil, fmt.Errorf("invalid pattern %s at %s:%d", line, kitignoreFileName, i+1)
+			}
+		}
+		if len(patterns) == 1 {
+			ignore.structs = append(ignore.structs, line)
+		} else {
+			ignore.fields = append(ignore.fields, [2]string{patterns[0], patterns[1]})
+		}
+	}
+	return ignore, nil
+}
+
+// excludes tells if a pb struct matches one of the struct patterns
+func (k *kitignore) excludes(pbStructName string) bool {
+	if k == nil {
+		return false
+	}
+	for _, pattern := range k.structs {
+		if matched, _ := path.Match(pattern, pbStructName); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// ignores tells if a field of a pb struct matches one of the Struct.Field patterns
+func (k *kitignore) ignores(pbStructName, fieldName string) bool {
+	if k == nil {
+		return false
+	}
+	for _, pattern := range k.fields {
+		structMatched, _ := path.Match(pattern[0], pbStructName)
+		fieldMatched, _ := path.Match(pattern[1], fieldName)
+		if structMatched && fieldMatched {
+			return true
+		}
+	}
+	return false
+}
+
+// loadKitignore reads the .kitignore of the project folder if any
+func (g *GenerateDTOFromProtoGo) loadKitignore() error {
+	if exists, err := g.fs.Exists(kitignoreFileName); err != nil {
+		return fmt.Errorf("err checking %s, err: %v", kitignoreFileName, err)
+	} else if !exists {
+		return nil
+	}
+	src, err := g.fs.ReadFile(kitignoreFileName)
+	if err != nil {
+		return fmt.Errorf("err reading %s, err: %v", kitignoreFileName, err)
+	}
+	g.kitignore, err = parseKitignore(src)
+	return err
+}
@@ -0,0 +1,149 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	goparser "go/parser"
+	"go/token"
+	"path"
+	"sort"
+)
+
+// name of the manifest listing the generated files and symbols, e.g. z_helloService_dto.manifest.json
+const formatAutoGenDTOManifestFileName = `z_%s_dto.manifest.json`
+
+// dtoManifest lists what a generation generated, for tools to clean up the stale generated files or to detect drift,
+// the files are sorted and the symbols listed in the order of the sorted files, then of their declarations
+type dtoManifest struct {
+	Comment string              `json:"$comment"`
+	Source  string              `json:"source"`
+	Files   []string            `json:"files"`
+	Symbols []dtoManifestSymbol `json:"symbols"`
+}
+
+// dtoManifestSymbol is a generated symbol, Name being Type.Method for a method
+type dtoManifestSymbol struct {
+	Name string `json:"name"`
+	// Kind is one of type, func, method, const or var
+	Kind string `json:"kind"`
+	File string `json:"file"`
+	// Source is the pb struct or enum the symbol is generated from, empty for the helpers shared by the dto
+	Source string `json:"source,omitempty"`
+}
+
+// genManifest generates the manifest of the generated files, see dtoManifest, the source of a symbol being the pb struct or enum
+// of the dto it is named after, of its receiver, or referred to by its signature, or else the single one referred to by its body
+func (g *GenerateDTOFromProtoGo) genManifest(pbStructNames []string) error {
+	sources := map[string]string{}
+	for _, pbStructName := range pbStructNames {
+		sources[g.dtoTypeName(pbStructName)] = pbStructName
+		sources[g.fromPBFuncName(pbStructName)] = pbStructName
+		sources[g.toPBFuncName(pbStructName)] = pbStructName
+	}
+	for enum, generated := range g.pbEnums {
+		if generated {
+			sources[g.dtoTypeName(enum)] = enum
+		}
+	}
+
+	manifest := dtoManifest{Comment: "Code generated by kit g dto. DO NOT EDIT.", Source: g.protoGoFileFullPath, Files: []string{}, Symbols: []dtoManifestSymbol{}}
+	for filePath := range g.generatedSources {
+		manifest.Files = append(manifest.Files, filePath)
+	}
+	sort.Strings(manifest.Files)
+
+	files := map[string]*ast.File{}
+	for _, filePath := range manifest.Files {
+		if path.Ext(filePath) != ".go" {
+			continue
+		}
+		file, err := goparser.ParseFile(token.NewFileSet(), filePath, g.generatedSources[filePath], 0)
+		if err != nil {
+			return fmt.Errorf("err parsing %s, err: %v", filePath, err)
+		}
+		files[filePath] = file
+		// the types first, the methods being attributed to the source of their receiver
+		for _, decl := range file.Decls {
+			if genDecl, ok := decl.(*ast.GenDecl); ok && genDecl.Tok == token.TYPE {
+				for _, spec := range genDecl.Specs {
+					typeSpec := spec.(*ast.TypeSpec)
+					if _, ok := sources[typeSpec.Name.Name]; !ok {
+						sources[typeSpec.Name.Name] = referredSource(typeSpec.Type, sources, false)
+					}
+				}
+			}
+		}
+	}
+
+	for _, filePath := range manifest.Files {
+		file, ok := files[filePath]
+		if !ok {
+			continue
+		}
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						manifest.Symbols = append(manifest.Symbols, dtoManifestSymbol{spec.Name.Name, "type", filePath, sources[spec.Name.Name]})
+					case *ast.ValueSpec:
+						for _, name := range spec.Names {
+							manifest.Symbols = append(manifest.Symbols, dtoManifestSymbol{name.Name, decl.Tok.String(), filePath, referredSource(spec, sources, true)})
+						}
+					}
+				}
+			case *ast.FuncDecl:
+				symbol := dtoManifestSymbol{decl.Name.Name, "func", filePath, sources[decl.Name.Name]}
+				if decl.Recv != nil {
+					symbol.Name, symbol.Kind = receiverTypeName(decl)+"."+decl.Name.Name, "method"
+					symbol.Source = sources[receiverTypeName(decl)]
+				}
+				if symbol.Source == "" {
+					symbol.Source = referredSource(decl.Type, sources, false)
+				}
+				if symbol.Source == "" && decl.Body != nil {
+					symbol.Source = referredSource(decl.Body, sources, true)
+				}
+				manifest.Symbols = append(manifest.Symbols, symbol)
+			}
+		}
+	}
+
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	manifestFileFullPath := path.Join(g.dtoPackagePath, fmt.Sprintf(formatAutoGenDTOManifestFileName, g.serviceName))
+	return g.writeGeneratedFile(manifestFileFullPath, string(content)+"\n")
+}
+
+// referredSource returns the source of the first dto symbol a node refers to, or of the only one if unique is set, empty if there
+// is none or if unique is set and there are several, the identifiers qualified by a package, e.g. pb.HelloRequest, being ignored
+func referredSource(node ast.Node, sources map[string]string, unique bool) string {
+	found, ambiguous := "", false
+	var inspect func(node ast.Node) bool
+	inspect = func(node ast.Node) bool {
+		if ambiguous || !unique && found != "" {
+			return false
+		}
+		switch n := node.(type) {
+		case *ast.SelectorExpr:
+			ast.Inspect(n.X, inspect)
+			return false
+		case *ast.Ident:
+			if source := sources[n.Name]; source != "" && found != "" && source != found {
+				ambiguous = true
+			} else if source != "" {
+				found = source
+			}
+		}
+		return true
+	}
+	ast.Inspect(node, inspect)
+	if ambiguous {
+		return ""
+	}
+	return found
+}
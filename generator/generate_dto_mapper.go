@@ -0,0 +1,77 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/dave/jennifer/jen"
+)
+
+// genMapper generates the mapper interface of a dto and its default implementation calling the FromPB / ToPB bindings,
+// so that the conversions can be injected, and mocked in tests, e.g.
+//
+//	type HelloRequestMapper interface {
+//		FromPB(pb *pb.HelloRequest) *HelloRequest
+//		ToPB(orig *HelloRequest) *pb.HelloRequest
+//	}
+//
+//	type DefaultHelloRequestMapper struct{}
+func (g *GenerateDTOFromProtoGo) genMapper(pbStructName string) {
+	dtoTypeName := g.dtoTypeName(pbStructName)
+	mapperName := dtoTypeName + "Mapper"
+	defaultMapperName := "Default" + mapperName
+
+	fromPBParams := g.bindingParams(jen.Id("pb").Id("*").Add(g.pbStructType(pbStructName)))
+	fromPBResults := []jen.Code{jen.Id("*").Qual(g.dtoImportPath, dtoTypeName)}
+	if g.fallibleFromPB[pbStructName] {
+		fromPBResults = append(fromPBResults, jen.Error())
+	}
+	toPBParams := g.bindingParams(jen.Id("orig").Id("*").Qual(g.dtoImportPath, dtoTypeName))
+	toPBResults := []jen.Code{jen.Id("*").Add(g.pbStructType(pbStructName))}
+	if g.fallibleToPB[pbStructName] {
+		toPBResults = append(toPBResults, jen.Error())
+	}
+
+	g.bindingsCode.NewLine()
+	g.bindingsCode.appendMultilineComment([]string{
+		fmt.Sprintf("%s converts %s from / to pb, it can be injected instead of calling the bindings.", mapperName, dtoTypeName),
+	})
+	g.bindingsCode.NewLine()
+	g.bindingsCode.appendInterface(mapperName, []jen.Code{
+		jen.Id("FromPB").Params(fromPBParams...).Params(fromPBResults...),
+		jen.Id("ToPB").Params(toPBParams...).Params(toPBResults...),
+	})
+	g.bindingsCode.NewLine()
+
+	g.bindingsCode.appendMultilineComment([]string{
+		fmt.Sprintf("%s is the %s calling %s and %s.", defaultMapperName, mapperName, g.fromPBFuncName(pbStructName), g.toPBFuncName(pbStructName)),
+	})
+	g.bindingsCode.NewLine()
+	g.bindingsCode.appendStruct(defaultMapperName)
+	g.bindingsCode.NewLine()
+	g.bindingsCode.Raw().Var().Id("_").Id(mapperName).Op("=").Id(defaultMapperName).Values().Line()
+	g.bindingsCode.NewLine()
+
+	for _, method := range []struct {
+		name    string
+		params  []jen.Code
+		results []jen.Code
+		binding string
+		arg     string
+	}{
+		{"FromPB", fromPBParams, fromPBResults, g.fromPBFuncName(pbStructName), "pb"},
+		{"ToPB", toPBParams, toPBResults, g.toPBFuncName(pbStructName), "orig"},
+	} {
+		g.bindingsCode.appendMultilineComment([]string{fmt.Sprintf("%s calls %s.", method.name, method.binding)})
+		g.bindingsCode.NewLine()
+		g.bindingsCode.appendFunction(
+			method.name,
+			jen.Id(defaultMapperName),
+			method.params,
+			method.results,
+			"",
+			jen.Return(g.bindingCall(method.binding, jen.Id("ctx"), jen.Id(method.arg))),
+		)
+		g.bindingsCode.NewLine()
+		g.bindingsCode.NewLine()
+	}
+}
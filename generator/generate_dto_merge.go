@@ -0,0 +1,197 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	goparser "go/parser"
+	"go/token"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// mergesUserMethods tells if the regenerated dto files keep the methods added by hand to the dto types, which is
+// the default, see preserveUserMethods
+func (g *GenerateDTOFromProtoGo) mergesUserMethods() bool {
+	return !g.options.Force && !g.options.NoMerge
+}
+
+// userMethod is a method added by hand to a dto type of a generated dto file, e.g. a custom Validate, see collectUserMethods
+type userMethod struct {
+	receiver string
+	name     string
+
+	// src is the source of the method, its doc comment included
+	src string
+
+	// imports holds the import paths of its file it uses keyed by their alias, empty for the ones imported by name
+	imports map[string]string
+
+	// attached is set once it is carried over to a regenerated file
+	attached bool
+}
+
+// collectUserMethods reads the methods added by hand to the dto types of the existing dto file and numbered dto files,
+// see dtoChunks, before any of them is rewritten, so that a method follows its type to whichever file now declares it,
+// the generated sources being the ones about to be written, whose methods are regenerated rather than preserved
+func (g *GenerateDTOFromProtoGo) collectUserMethods(generatedSources []string) error {
+	g.userMethods = nil
+	generatedMethods := map[string]bool{}
+	for _, src := range generatedSources {
+		file, err := goparser.ParseFile(token.NewFileSet(), "", src, 0)
+		if err != nil {
+			return err
+		}
+		for _, decl := range file.Decls {
+			if funcDecl, ok := decl.(*ast.FuncDecl); ok && funcDecl.Recv != nil {
+				generatedMethods[receiverTypeName(funcDecl)+"."+funcDecl.Name.Name] = true
+			}
+		}
+	}
+
+	filePaths := []string{g.dtoFileFullPath}
+	if names, err := g.fs.ReadDir(g.dtoPackagePath); err == nil {
+		chunkFileName := g.dtoChunkFileName()
+		for _, name := range names {
+			if chunkFileName.MatchString(name) {
+				filePaths = append(filePaths, path.Join(g.dtoPackagePath, name))
+			}
+		}
+	}
+	for _, filePath := range filePaths {
+		if b, err := g.fs.Exists(filePath); err != nil {
+			return err
+		} else if !b {
+			continue
+		}
+		existingSrc, err := g.fs.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		// a file which is not generated is not overwritten, see writeGeneratedFile
+		if !isGeneratedSource(existingSrc) {
+			continue
+		}
+		existingFset := token.NewFileSet()
+		existing, err := goparser.ParseFile(existingFset, filePath, existingSrc, goparser.ParseComments)
+		if err != nil {
+			g.warnf("could not parse %s, the methods added to its dto types are not preserved, err: %v", filePath, err)
+			continue
+		}
+		for _, decl := range existing.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if !ok || funcDecl.Recv == nil || generatedMethods[receiverTypeName(funcDecl)+"."+funcDecl.Name.Name] {
+				continue
+			}
+			start := funcDecl.Pos()
+			if funcDecl.Doc != nil {
+				start = funcDecl.Doc.Pos()
+			}
+			method := &userMethod{
+				receiver: receiverTypeName(funcDecl),
+				name:     funcDecl.Name.Name,
+				src:      existingSrc[existingFset.Position(start).Offset:existingFset.Position(funcDecl.End()).Offset],
+				imports:  map[string]string{},
+			}
+			// the imports of the existing file used by the method, e.g. strings in strings.TrimSpace(orig.Name)
+			for _, importSpec := range existing.Imports {
+				importPath, _ := strconv.Unquote(importSpec.Path.Value)
+				name, alias := path.Base(importPath), ""
+				if importSpec.Name != nil {
+					name, alias = importSpec.Name.Name, importSpec.Name.Name
+				}
+				if regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\.`).MatchString(method.src) {
+					method.imports[importPath] = alias
+				}
+			}
+			g.userMethods = append(g.userMethods, method)
+		}
+	}
+	return nil
+}
+
+// preserveUserMethods carries over to a regenerated dto file, e.g. the dto file, the methods added by hand to the dto types
+// it declares, with the imports they use, so that they survive the regeneration even when their type moved from another
+// file, see collectUserMethods
+func (g *GenerateDTOFromProtoGo) preserveUserMethods(filePath, content string) (string, error) {
+	generatedFset := token.NewFileSet()
+	generated, err := goparser.ParseFile(generatedFset, filePath, content, goparser.ParseComments)
+	if err != nil {
+		return "", err
+	}
+	dtoTypes := map[string]bool{}
+	for _, decl := range generated.Decls {
+		if genDecl, ok := decl.(*ast.GenDecl); ok {
+			for _, spec := range genDecl.Specs {
+				if typeSpec, ok := spec.(*ast.TypeSpec); ok {
+					dtoTypes[typeSpec.Name.Name] = true
+				}
+			}
+		}
+	}
+
+	methods := []*userMethod{}
+	for _, method := range g.userMethods {
+		if !method.attached && dtoTypes[method.receiver] {
+			logrus.Info("preserving the method added to dto: ", method.receiver+"."+method.name, " in: ", filePath)
+			method.attached = true
+			methods = append(methods, method)
+		}
+	}
+	if len(methods) == 0 {
+		return content, nil
+	}
+
+	srcs := []string{}
+	for _, method := range methods {
+		srcs = append(srcs, method.src)
+	}
+	merged := content + "\n" + strings.Join(srcs, "\n\n") + "\n"
+	mergedFset := token.NewFileSet()
+	mergedFile, err := goparser.ParseFile(mergedFset, filePath, merged, goparser.ParseComments)
+	if err != nil {
+		return "", err
+	}
+	for _, method := range methods {
+		for importPath, alias := range method.imports {
+			astutil.AddNamedImport(mergedFset, mergedFile, alias, importPath)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, mergedFset, mergedFile); err != nil {
+		return "", err
+	}
+	// format.Source sorts the added imports
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		return "", err
+	}
+	return string(formatted), nil
+}
+
+// dtoChunkFileName matches the names of the numbered dto files, see formatAutoGenDTOChunkFileName
+func (g *GenerateDTOFromProtoGo) dtoChunkFileName() *regexp.Regexp {
+	return regexp.MustCompile(fmt.Sprintf(`^z_%s_dto_\d+\.go$`, regexp.QuoteMeta(g.serviceName)))
+}
+
+// receiverTypeName returns the name of the type of the receiver of a method, e.g. HelloRequest for (x *HelloRequest)
+func receiverTypeName(funcDecl *ast.FuncDecl) string {
+	if len(funcDecl.Recv.List) == 0 {
+		return ""
+	}
+	typeExpr := funcDecl.Recv.List[0].Type
+	if star, ok := typeExpr.(*ast.StarExpr); ok {
+		typeExpr = star.X
+	}
+	if ident, ok := typeExpr.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
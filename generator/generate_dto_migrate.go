@@ -0,0 +1,185 @@
+package generator
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/dave/jennifer/jen"
+	"github.com/kujtimiihoxha/kit/parser"
+	"github.com/kujtimiihoxha/kit/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// name of the file holding the converters between the dto of the previous version of the service and its dto,
+// e.g. z_helloService_migrate.go
+const formatAutoGenMigrateFileName = `z_%s_migrate.go`
+
+// genMigrations generates a SomethingV1ToV2 / SomethingV2ToV1 converter per dto whose pb struct is also declared in the pb.go
+// of options.MigrateFrom, the previous version of the service, whose dto are expected to be generated with the same options:
+//
+//	func HelloRequestV1ToV2(v1 *v1dto.HelloRequest) *HelloRequest
+//	func HelloRequestV2ToV1(v2 *HelloRequest) *v1dto.HelloRequest
+//
+// only the fields present in both versions with the same pb type are converted, the fields added or removed are left zero
+func (g *GenerateDTOFromProtoGo) genMigrations(pbStructNames []string, pbStructManifest map[string]*structState) error {
+	from := g.options.MigrateFrom
+	if from == g.serviceName {
+		return fmt.Errorf("cannot migrate the dto of %s from themselves", from)
+	}
+	fromPBGoFileFullPath := DTOSourcePath(from)
+	fromPBGoSrc, err := g.fs.ReadFile(fromPBGoFileFullPath)
+	if err != nil {
+		return fmt.Errorf("err reading pb go file to migrate from at: %s, err: %v", fromPBGoFileFullPath, err)
+	}
+	fromPBGoFile, err := parser.NewFileParser().Parse([]byte(fromPBGoSrc))
+	if err != nil {
+		return fmt.Errorf("err parsing pb go file to migrate from at: %s, err: %v", fromPBGoFileFullPath, err)
+	}
+	fromStructs := map[string]parser.Struct{}
+	for _, pbStruct := range fromPBGoFile.Structures {
+		fromStructs[pbStruct.Name] = pbStruct
+	}
+	migrated := map[string]bool{}
+	for _, pbStructName := range pbStructNames {
+		if _, ok := fromStructs[pbStructName]; ok && !g.reflectFallbacks[pbStructName] {
+			migrated[pbStructName] = true
+		}
+	}
+
+	srcFile := g.newDTOFile()
+	g.genHeader(srcFile)
+	for importPath, alias := range g.importAliases {
+		srcFile.ImportAlias(importPath, alias)
+	}
+	fromDTOImportPath := g.migrateFromDTOImportPath()
+	srcFile.ImportAlias(fromDTOImportPath, "v1dto")
+
+	code := NewPartialGenerator(srcFile.Empty())
+	code.NewLine()
+	for _, pbStructName := range pbStructNames {
+		if !migrated[pbStructName] {
+			logrus.Info("not migrating ", pbStructName, ", it is not declared in the pb.go of ", from)
+			continue
+		}
+		fromFields := map[string]parser.NamedTypeValue{}
+		for _, field := range fromStructs[pbStructName].Vars {
+			if !g.isSkippedField(pbStructName, field) {
+				fromFields[field.Name] = field
+			}
+		}
+		pbFields := map[string]parser.NamedTypeValue{}
+		for _, field := range pbStructManifest[pbStructName].Struct.Vars {
+			pbFields[field.Name] = field
+		}
+
+		fields := []fieldState{}
+		for _, fieldState := range g.fieldManifests[pbStructName] {
+			fromField, ok := fromFields[fieldState.Name]
+			if !ok || fromField.Type != pbFields[fieldState.Name].Type {
+				logrus.Info("not migrating ", pbStructName, ".", fieldState.Name, ", it is not declared with the same type in both versions")
+				continue
+			}
+			if !isMigratedAsIs(fieldState) && fieldState.IsStructType && !migrated[fieldState.TypeName] || isUnknownType(fieldState) {
+				g.warnf("not migrating %s.%s, its type %s cannot be converted between the versions", pbStructName, fieldState.Name, fieldState.PBType)
+				continue
+			}
+			fields = append(fields, fieldState)
+		}
+
+		g.genMigration(code, "V1ToV2", "v1", fromDTOImportPath, g.dtoImportPath, pbStructName, fields)
+		g.genMigration(code, "V2ToV1", "v2", g.dtoImportPath, fromDTOImportPath, pbStructName, fields)
+	}
+
+	migrateFileFullPath := path.Join(g.dtoPackagePath, fmt.Sprintf(formatAutoGenMigrateFileName, g.serviceName))
+	return g.writeGeneratedFile(migrateFileFullPath, srcFile.GoString())
+}
+
+// genMigration generates the converter of a dto from the package srcImportPath to the package dstImportPath named after
+// the direction, e.g. HelloRequestV1ToV2, converting the given fields, the nested dto and enums being converted as well
+func (g *GenerateDTOFromProtoGo) genMigration(code *PartialGenerator, direction, param, srcImportPath, dstImportPath, pbStructName string, fields []fieldState) {
+	dtoTypeName := g.dtoTypeName(pbStructName)
+	body := []jen.Code{
+		jen.If(jen.Id(param).Op("==").Nil()).Block(jen.Return(jen.Nil())).Line(),
+		jen.Id("res").Op(":=").Op("&").Qual(dstImportPath, dtoTypeName).Values(),
+	}
+	for _, fieldState := range fields {
+		src := jen.Id(param).Dot(fieldState.Name)
+		dst := jen.Id("res").Dot(fieldState.Name)
+		if isMigratedAsIs(fieldState) {
+			body = append(body, dst.Clone().Op("=").Add(src.Clone()))
+			continue
+		}
+
+		convert := func(value jen.Code) jen.Code {
+			if fieldState.IsEnum {
+				return jen.Qual(dstImportPath, g.dtoTypeName(fieldState.TypeName)).Call(value)
+			}
+			return jen.Id(g.dtoTypeName(fieldState.TypeName) + direction).Call(value)
+		}
+		switch {
+		case fieldState.IsMap || fieldState.IsSlice:
+			body = append(body, jen.If(src.Clone().Op("!=").Nil()).Block(
+				dst.Clone().Op("=").Make(g.migrationCollectionType(fieldState, dstImportPath), jen.Len(src.Clone())),
+				jen.For(jen.List(jen.Id("key"), jen.Id("value")).Op(":=").Range().Add(src.Clone())).Block(
+					dst.Clone().Index(jen.Id("key")).Op("=").Add(convert(jen.Id("value"))),
+				),
+			))
+		case fieldState.ArrayLen != "":
+			body = append(body, jen.For(jen.List(jen.Id("i"), jen.Id("value")).Op(":=").Range().Add(src.Clone())).Block(
+				dst.Clone().Index(jen.Id("i")).Op("=").Add(convert(jen.Id("value"))),
+			))
+		case fieldState.IsValueNested:
+			body = append(body, dst.Clone().Op("=").Op("*").Add(convert(jen.Op("&").Add(src.Clone()))))
+		case fieldState.IsOptionalEnum:
+			body = append(body, jen.If(src.Clone().Op("!=").Nil()).Block(
+				jen.Id("value").Op(":=").Add(convert(jen.Op("*").Add(src.Clone()))),
+				dst.Clone().Op("=").Op("&").Id("value"),
+			))
+		default:
+			body = append(body, dst.Clone().Op("=").Add(convert(src.Clone())))
+		}
+	}
+	body = append(body, jen.Return(jen.Id("res")))
+
+	code.appendFunction(
+		dtoTypeName+direction,
+		nil,
+		[]jen.Code{jen.Id(param).Id("*").Qual(srcImportPath, dtoTypeName)},
+		[]jen.Code{jen.Id("*").Qual(dstImportPath, dtoTypeName)},
+		"",
+		body...,
+	)
+	code.NewLine()
+	code.NewLine()
+}
+
+// isMigratedAsIs tells if the dto type of a field is the same in both versions, i.e. it neither refers to a dto nor to an enum
+func isMigratedAsIs(fieldState fieldState) bool {
+	return fieldState.Override != nil || fieldState.Wrapper != "" || !fieldState.IsStructType && !fieldState.IsEnum
+}
+
+// migrationCollectionType returns the type of a collection of dto or enums in the dto package importPath,
+// e.g. []*v1dto.Address
+func (g *GenerateDTOFromProtoGo) migrationCollectionType(fieldState fieldState, importPath string) jen.Code {
+	elemType := jen.Qual(importPath, g.dtoTypeName(fieldState.TypeName))
+	if !fieldState.IsEnum {
+		elemType = jen.Id("*").Add(elemType)
+	}
+	if fieldState.IsMap {
+		return jen.Map(fieldState.mapKeyType()).Add(elemType)
+	}
+	return jen.Index().Add(elemType)
+}
+
+// migrateFromDTOImportPath returns the import path of the dto package of options.MigrateFrom, resolved as the one of the service
+func (g *GenerateDTOFromProtoGo) migrateFromDTOImportPath() string {
+	from := g.options.MigrateFrom
+	dtoPackagePath := fmt.Sprintf(formatDTOPackagePath, from, from)
+	if g.options.InPBPackage {
+		dtoPackagePath = fmt.Sprintf(path.Join("%s", "pkg", "grpc", "pb"), from)
+	}
+	if importPath, err := utils.GetModuleImportPath(from, dtoPackagePath); err == nil {
+		return importPath
+	}
+	return dtoPackagePath
+}
@@ -0,0 +1,172 @@
+package generator
+
+import (
+	"fmt"
+	"go/ast"
+	goparser "go/parser"
+	"go/types"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kujtimiihoxha/kit/parser"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// name of the openapi document holding the component schemas of the dto, e.g. z_helloService_dto.openapi.yaml
+const formatAutoGenDTOOpenAPIFileName = `z_%s_dto.openapi.yaml`
+
+// openAPIDocument is an openapi 3 document without paths, for the gateways to refer to its component schemas
+type openAPIDocument struct {
+	OpenAPI    string                 `yaml:"openapi"`
+	Info       openAPIInfo            `yaml:"info"`
+	Paths      map[string]interface{} `yaml:"paths"`
+	Components struct {
+		Schemas map[string]*openAPISchema `yaml:"schemas"`
+	} `yaml:"components"`
+}
+
+// openAPIInfo is the info object of an openapi document, required by the spec
+type openAPIInfo struct {
+	Title   string `yaml:"title"`
+	Version string `yaml:"version"`
+}
+
+// openAPISchema is the subset of the openapi 3 schema object used to describe the dto, an empty schema accepts any value
+type openAPISchema struct {
+	Ref                  string                    `yaml:"$ref,omitempty"`
+	Type                 string                    `yaml:"type,omitempty"`
+	Format               string                    `yaml:"format,omitempty"`
+	Nullable             bool                      `yaml:"nullable,omitempty"`
+	AllOf                []*openAPISchema          `yaml:"allOf,omitempty"`
+	Enum                 []string                  `yaml:"enum,omitempty"`
+	Items                *openAPISchema            `yaml:"items,omitempty"`
+	AdditionalProperties *openAPISchema            `yaml:"additionalProperties,omitempty"`
+	Properties           map[string]*openAPISchema `yaml:"properties,omitempty"`
+	Required             []string                  `yaml:"required,omitempty"`
+}
+
+// genOpenAPI generates the openapi component schemas of the dto, a schema per dto and per dto enum keyed by dto type name,
+// as the definitions of the json schema, see genJSONSchema, the nested dto and the enums being referred to with $ref,
+// e.g. {"$ref": "#/components/schemas/Address"}, the enums are string enums of their names with options.EnumAsString,
+// int32 integers otherwise
+func (g *GenerateDTOFromProtoGo) genOpenAPI(pbStructNames []string, pbGoFile *parser.File) error {
+	document := openAPIDocument{OpenAPI: "3.0.3", Paths: map[string]interface{}{}}
+	document.Info = openAPIInfo{Title: fmt.Sprintf("%s dto", g.serviceName), Version: "1.0.0"}
+	document.Components.Schemas = map[string]*openAPISchema{}
+	for _, pbStructName := range pbStructNames {
+		schema := &openAPISchema{Type: "object", Properties: map[string]*openAPISchema{}}
+		for _, fieldState := range g.fieldManifests[pbStructName] {
+			if fieldState.JSONName == "-" {
+				continue
+			}
+			schema.Properties[fieldState.JSONName] = g.openAPIFieldSchema(fieldState)
+			if fieldState.Required {
+				schema.Required = append(schema.Required, fieldState.JSONName)
+			}
+		}
+		document.Components.Schemas[g.dtoTypeName(pbStructName)] = schema
+	}
+	for enum, generated := range g.pbEnums {
+		if !generated {
+			continue
+		}
+		schema := &openAPISchema{Type: "integer", Format: "int32"}
+		if g.options.EnumAsString {
+			schema = &openAPISchema{Type: "string", Enum: pbEnumNames(pbGoFile, enum)}
+		}
+		document.Components.Schemas[g.dtoTypeName(enum)] = schema
+	}
+
+	content, err := yaml.Marshal(document)
+	if err != nil {
+		return err
+	}
+	openAPIFileFullPath := path.Join(g.dtoPackagePath, fmt.Sprintf(formatAutoGenDTOOpenAPIFileName, g.serviceName))
+	return g.writeGeneratedFile(openAPIFileFullPath, "# Code generated by kit g dto. DO NOT EDIT.\n"+string(content))
+}
+
+// openAPIFieldSchema returns the schema of a dto field, the one of its json schema, see fieldSchema,
+// with the references to the enums and the nested dto resolved among the component schemas,
+// the nil fields marshalled as null being nullable, see isNullableField
+func (g *GenerateDTOFromProtoGo) openAPIFieldSchema(fieldState fieldState) *openAPISchema {
+	schema := openAPISchemaOf(g.fieldSchema(fieldState))
+	if fieldState.IsEnum && fieldState.Override == nil {
+		enumRef := &openAPISchema{Ref: "#/components/schemas/" + g.dtoTypeName(fieldState.TypeName)}
+		switch {
+		case fieldState.IsMap:
+			schema.AdditionalProperties = enumRef
+		case fieldState.IsSlice || fieldState.ArrayLen != "":
+			schema.Items = enumRef
+		default:
+			schema = enumRef
+		}
+	}
+	if !g.isNullableField(fieldState) {
+		return schema
+	} else if schema.Ref != "" {
+		// the siblings of a $ref are ignored, a nullable reference is wrapped in allOf
+		return &openAPISchema{AllOf: []*openAPISchema{schema}, Nullable: true}
+	}
+	schema.Nullable = true
+	return schema
+}
+
+// openAPISchemaOf converts a json schema to an openapi schema, base64 strings being of format byte
+func openAPISchemaOf(schema *jsonSchema) *openAPISchema {
+	if schema == nil {
+		return nil
+	}
+	converted := &openAPISchema{
+		Ref:                  strings.Replace(schema.Ref, "#/definitions/", "#/components/schemas/", 1),
+		Type:                 schema.Type,
+		Format:               schema.Format,
+		Items:                openAPISchemaOf(schema.Items),
+		AdditionalProperties: openAPISchemaOf(schema.AdditionalProperties),
+	}
+	if schema.ContentEncoding == "base64" {
+		converted.Format = "byte"
+	}
+	return converted
+}
+
+// pbEnumNames returns the names of the values of a pb enum ordered by number, read from its <Enum>_name map in pb.go, e.g.
+//
+//	var Status_name = map[int32]string{0: "UNKNOWN", 1: "OK"}
+func pbEnumNames(pbGoFile *parser.File, pbEnumName string) []string {
+	numbers, names := []int{}, map[int]string{}
+	for _, v := range pbGoFile.Vars {
+		if v.Name != pbEnumName+"_name" {
+			continue
+		}
+		expr, err := goparser.ParseExpr(v.Value)
+		if err != nil {
+			return nil
+		}
+		lit, ok := expr.(*ast.CompositeLit)
+		if !ok {
+			return nil
+		}
+		for _, elt := range lit.Elts {
+			kv, ok := elt.(*ast.KeyValueExpr)
+			if !ok {
+				continue
+			}
+			key, value := types.ExprString(kv.Key), types.ExprString(kv.Value)
+			number, err := strconv.Atoi(key)
+			name, unquoteErr := strconv.Unquote(value)
+			if err != nil || unquoteErr != nil {
+				continue
+			}
+			numbers = append(numbers, number)
+			names[number] = name
+		}
+	}
+	sort.Ints(numbers)
+	enumNames := []string{}
+	for _, number := range numbers {
+		enumNames = append(enumNames, names[number])
+	}
+	return enumNames
+}
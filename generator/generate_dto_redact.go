@@ -0,0 +1,95 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/dave/jennifer/jen"
+	"github.com/kujtimiihoxha/kit/parser"
+	"github.com/kujtimiihoxha/kit/utils"
+)
+
+// redactedMask replaces the value of the redacted fields in the String method and in the json of their dto
+const redactedMask = "[REDACTED]"
+
+// isRedactedField tells if a pb field holds sensitive data, e.g. a password, masked in its dto with the @dto:redact
+// directive of its comment, e.g.
+//
+//	// @dto:redact
+func isRedactedField(field parser.NamedTypeValue) bool {
+	for _, value := range commentDirectives(field.Comment, "dto") {
+		if value == "redact" {
+			return true
+		}
+	}
+	return false
+}
+
+// genMarshalJSON generates the MarshalJSON method of a dto having redacted fields, which are masked by fields
+// of the same json name shadowing them, e.g.
+//
+//	func (h HelloRequest) MarshalJSON() ([]byte, error) {
+//		type plain HelloRequest
+//		return json.Marshal(struct {
+//			plain
+//			Password string `json:"password"`
+//		}{plain: plain(h), Password: "[REDACTED]"})
+//	}
+//
+// or having float fields with options.JSONFloatMode, see jsonFloatFields,
+// the dto converted through json with options.ReflectFallback are left as is, their ToPB binding marshalling them
+func (g *GenerateDTOFromProtoGo) genMarshalJSON(currentPBStructName string, fieldManifest []fieldState) {
+	dtoTypeName := g.dtoTypeName(currentPBStructName)
+	recv := utils.ReceiverName(dtoTypeName, "plain", "json", "err")
+
+	shadows, values := []jen.Code{jen.Id("plain")}, jen.Dict{}
+	for _, fieldState := range fieldManifest {
+		if fieldState.Redacted && fieldState.JSONName != "-" {
+			shadows = append(shadows, jen.Id(fieldState.Name).String().Tag(map[string]string{"json": fieldState.JSONName}))
+			values[jen.Id(fieldState.Name)] = jen.Lit(redactedMask)
+		}
+	}
+	redacted := len(values) > 0
+	if g.reflectFallbackJSON[currentPBStructName] {
+		if redacted {
+			g.warnf("not masking the redacted fields of %s in json, it is converted through json (--reflect-fallback)", currentPBStructName)
+		}
+		return
+	}
+	floatShadows, floatValues, checks := g.jsonFloatFields(recv, currentPBStructName, fieldManifest)
+	if !redacted && len(floatShadows) == 0 && len(checks) == 0 {
+		return
+	}
+	shadows = append(shadows, floatShadows...)
+	for field, value := range floatValues {
+		values[field] = value
+	}
+
+	comments := []string{}
+	if redacted {
+		comments = append(comments, fmt.Sprintf("MarshalJSON masks the fields of %s annotated with @dto:redact.", dtoTypeName))
+	}
+	if len(floatShadows) > 0 || len(checks) > 0 {
+		comments = append(comments, g.jsonFloatComment(dtoTypeName))
+	}
+	var marshalled jen.Code = jen.Id("plain").Call(jen.Id(recv))
+	if len(values) > 0 {
+		values[jen.Id("plain")] = marshalled
+		marshalled = jen.Struct(shadows...).Values(values)
+	}
+
+	g.code.appendMultilineComment(comments)
+	g.code.NewLine()
+	g.code.appendFunction(
+		"MarshalJSON",
+		jen.Id(recv).Id(dtoTypeName),
+		[]jen.Code{},
+		[]jen.Code{jen.Index().Byte(), jen.Error()},
+		"",
+		append(checks,
+			jen.Type().Id("plain").Id(dtoTypeName),
+			jen.Return(jen.Qual("encoding/json", "Marshal").Call(marshalled)),
+		)...,
+	)
+	g.code.NewLine()
+	g.code.NewLine()
+}
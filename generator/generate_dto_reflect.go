@@ -0,0 +1,209 @@
+package generator
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strings"
+
+	"github.com/dave/jennifer/jen"
+	"github.com/kujtimiihoxha/kit/parser"
+	"github.com/kujtimiihoxha/kit/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// import path of the protobuf json encoding used by the reflection-based fallback bindings
+const protojsonImportPath = "google.golang.org/protobuf/encoding/protojson"
+
+// markReflectFallbacks records the pb structs converted through json with options.ReflectFallback, that is every struct
+// having a field of unknown type the static bindings cannot convert, e.g. the isHelloRequest_Value interface of a oneof,
+// and the structs reachable from them, whose dto is then also (un)marshalled to json by the fallback bindings
+func (g *GenerateDTOFromProtoGo) markReflectFallbacks(pbStructManifest map[string]*structState) {
+	g.reflectFallbacks, g.reflectFallbackJSON = map[string]bool{}, map[string]bool{}
+	if !g.options.ReflectFallback {
+		return
+	}
+	for name, structState := range pbStructManifest {
+		for _, field := range structState.Struct.Vars {
+			if g.hasUnknownType(name, field, pbStructManifest) {
+				logrus.Info("converting through json with --reflect-fallback: ", name, " its field ", field.Name, " is of unknown type")
+				g.reflectFallbacks[name] = true
+				break
+			}
+		}
+	}
+	for name := range pbStructManifest {
+		for fallback := range g.reflectFallbacks {
+			if g.isReachable(fallback, name, pbStructManifest) {
+				g.reflectFallbackJSON[name] = true
+				break
+			}
+		}
+	}
+}
+
+// hasUnknownType tells if a field of a pb struct is of unknown type before generating its dto, see isUnknownType
+func (g *GenerateDTOFromProtoGo) hasUnknownType(pbStructName string, field parser.NamedTypeValue, pbStructManifest map[string]*structState) bool {
+	if !ast.IsExported(field.Name) || g.isSkippedField(pbStructName, field) || g.mappingSpec.override(pbStructName, field.Name) != nil {
+		return false
+	}
+	fieldType, _, isMap, mapKeyType := parseFieldType(field.TypeInfo)
+	fieldType, importPath := g.resolveFieldType(fieldType)
+	_, isStructType := pbStructManifest[fieldType]
+	_, isEnum := g.pbEnums[fieldType]
+	mapKeyImportPath := ""
+	if isMap {
+		mapKeyType, mapKeyImportPath, _ = g.mapKeyType(mapKeyType)
+	}
+	return isUnknownType(fieldState{
+		PBType:           field.Type,
+		TypeName:         fieldType,
+		ImportPath:       importPath,
+		IsStructType:     isStructType && importPath == "",
+		IsEnum:           isEnum && importPath == "",
+		MapKeyType:       mapKeyType,
+		MapKeyImportPath: mapKeyImportPath,
+	})
+}
+
+// findPBOneofs returns the member structs of the oneofs of pb.go keyed by the interface of the oneof, in declaration order,
+// e.g. isHelloRequest_Value -> HelloRequest_Text, HelloRequest_Number, as generated by protoc:
+//
+//	type isHelloRequest_Value interface {
+//		isHelloRequest_Value()
+//	}
+//
+//	func (*HelloRequest_Text) isHelloRequest_Value() {}
+func findPBOneofs(pbGoFile *parser.File) map[string][]string {
+	oneofs := map[string][]string{}
+	for _, i := range pbGoFile.Interfaces {
+		if len(i.Methods) != 1 || i.Methods[0].Name != i.Name {
+			continue
+		}
+		for _, m := range pbGoFile.Methods {
+			if m.Name == i.Name && m.Struct.Type != "" {
+				oneofs[i.Name] = append(oneofs[i.Name], strings.TrimPrefix(m.Struct.Type, "*"))
+			}
+		}
+	}
+	return oneofs
+}
+
+// reflectFallbackTag returns the json tag of a dto field converted through json, the 64-bit integers being quoted
+// as in protojson, e.g. id,string, which encoding/json only supports for the scalars
+func (g *GenerateDTOFromProtoGo) reflectFallbackTag(pbStructName string, fieldState fieldState, jsonTagVal string) string {
+	if !g.reflectFallbackJSON[pbStructName] || fieldState.WellKnown != nil || fieldState.Override != nil {
+		return jsonTagVal
+	}
+	switch strings.TrimPrefix(fieldState.TypeName, "*") {
+	case "int64", "uint64":
+		if fieldState.IsSlice || fieldState.IsMap || fieldState.ArrayLen != "" {
+			g.warnf("%s.%s holds 64-bit integers quoted by protojson, which its dto cannot unmarshal, see --int64-as-string",
+				pbStructName, fieldState.Name)
+			return jsonTagVal
+		}
+		return jsonTagVal + ",string"
+	}
+	return jsonTagVal
+}
+
+// genOneofMemberFields returns the dto fields of the members of a oneof of a struct converted through json,
+// named after the single field of each member as protojson inlines them into the message, e.g. Text *string `json:"text,omitempty"`,
+// a scalar member is a pointer to its value, any other member holds its protojson, ok is false if the field is not a oneof
+func (g *GenerateDTOFromProtoGo) genOneofMemberFields(fieldName, oneofName string, pbStructManifest map[string]*structState) (dtoFields []jen.Code, ok bool) {
+	members, ok := g.pbOneofs[oneofName]
+	if !ok {
+		return nil, false
+	}
+	for _, member := range members {
+		memberState, ok := pbStructManifest[member]
+		if !ok || len(memberState.Struct.Vars) != 1 {
+			g.warnf("skipping oneof member %s of %s, expected a struct having a single field", member, oneofName)
+			continue
+		}
+		field := memberState.Struct.Vars[0]
+		_, jsonTagVal := utils.JsonTag(field.Name)
+		if jsonName, ok := protoJSONName(field.Tag); ok {
+			jsonTagVal = jsonName
+		}
+		jsonTagVal = strings.Split(jsonTagVal, ",")[0] + ",omitempty"
+
+		var dtoType jen.Code = jen.Qual("encoding/json", "RawMessage")
+		if field.Type == "[]byte" {
+			dtoType = jen.Index().Byte()
+		} else if _, isBuiltin := types.Universe.Lookup(field.Type).(*types.TypeName); isBuiltin && field.Type != "error" {
+			dtoType = jen.Op("*").Id(field.Type)
+			if field.Type == "int64" || field.Type == "uint64" {
+				jsonTagVal += ",string"
+			}
+		}
+		dtoFields = append(dtoFields, jen.Id(field.Name).Add(dtoType).Tag(map[string]string{"json": jsonTagVal}).
+			Comment(fmt.Sprintf("member of oneof %s, converted through json (--reflect-fallback)", fieldName)))
+	}
+	return dtoFields, true
+}
+
+// genReflectFallbackBindings generates the FromPB / ToPB bindings of a struct converted through json with options.ReflectFallback,
+// the pb message is marshalled with protojson and unmarshalled into the dto, and the other way around, which both may fail,
+// the required fields of proto2 are not checked, as by the static bindings:
+//
+//	func HelloRequestFromPB(pb *pb.HelloRequest) (*HelloRequest, error) {
+//		if pb == nil {
+//			return nil, nil
+//		}
+//
+//		data, err := protojson.MarshalOptions{AllowPartial: true, UseEnumNumbers: true}.Marshal(pb)
+//		if err != nil {
+//			return nil, err
+//		}
+//		res := &HelloRequest{}
+//		if err = json.Unmarshal(data, res); err != nil {
+//			return nil, err
+//		}
+//		return res, nil
+//	}
+func (g *GenerateDTOFromProtoGo) genReflectFallbackBindings(pbStructName string) {
+	dtoType := jen.Qual(g.dtoImportPath, g.dtoTypeName(pbStructName))
+	pbType := g.pbStructType(pbStructName)
+	returnErr := jen.If(jen.Err().Op("!=").Nil()).Block(jen.Return(jen.Nil(), jen.Err()))
+
+	g.bindingsCode.appendFunction(
+		g.fromPBFuncName(pbStructName),
+		nil,
+		g.bindingParams(jen.Id("pb").Id("*").Add(pbType)),
+		[]jen.Code{jen.Id("*").Add(dtoType), jen.Error()},
+		"",
+		jen.If(jen.Id("pb").Op("==").Nil()).Block(jen.Return(jen.Nil(), jen.Nil())).Line(),
+		jen.Comment(fmt.Sprintf("%s has fields of unknown type, it is converted through json (--reflect-fallback)", pbStructName)),
+		jen.List(jen.Id("data"), jen.Err()).Op(":=").Qual(protojsonImportPath, "MarshalOptions").
+			Values(jen.Dict{jen.Id("AllowPartial"): jen.True(), jen.Id("UseEnumNumbers"): jen.True()}).Dot("Marshal").Call(jen.Id("pb")),
+		returnErr,
+		jen.Id("res").Op(":=").Op("&").Add(dtoType).Values(),
+		jen.If(jen.Err().Op("=").Qual("encoding/json", "Unmarshal").Call(jen.Id("data"), jen.Id("res")), jen.Err().Op("!=").Nil()).
+			Block(jen.Return(jen.Nil(), jen.Err())),
+		jen.Return(jen.Id("res"), jen.Nil()),
+	)
+	g.bindingsCode.NewLine()
+	g.bindingsCode.NewLine()
+
+	g.bindingsCode.appendFunction(
+		g.toPBFuncName(pbStructName),
+		nil,
+		g.bindingParams(jen.Id("orig").Id("*").Add(dtoType)),
+		[]jen.Code{jen.Id("*").Add(pbType), jen.Error()},
+		"",
+		jen.If(jen.Id("orig").Op("==").Nil()).Block(jen.Return(jen.Nil(), jen.Nil())).Line(),
+		jen.List(jen.Id("data"), jen.Err()).Op(":=").Qual("encoding/json", "Marshal").Call(jen.Id("orig")),
+		returnErr,
+		jen.Id("res").Op(":=").Op("&").Add(pbType).Values(),
+		// the dto fields unknown to pb, if any, are dropped
+		jen.If(
+			jen.Err().Op("=").Parens(jen.Qual(protojsonImportPath, "UnmarshalOptions").Values(jen.Dict{jen.Id("AllowPartial"): jen.True(), jen.Id("DiscardUnknown"): jen.True()})).
+				Dot("Unmarshal").Call(jen.Id("data"), jen.Id("res")),
+			jen.Err().Op("!=").Nil(),
+		).Block(jen.Return(jen.Nil(), jen.Err())),
+		jen.Return(jen.Id("res"), jen.Nil()),
+	)
+	g.bindingsCode.NewLine()
+	g.bindingsCode.NewLine()
+}
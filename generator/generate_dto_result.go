@@ -0,0 +1,42 @@
+package generator
+
+import (
+	"fmt"
+	"go/token"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DTOResult reports what a dto generation did, for tools wrapping `kit g dto` to not parse its logs
+type DTOResult struct {
+	// Files are the generated files written, the ones left unchanged are not reported
+	Files []string `json:"files"`
+	// Structs are the names of the dto structs generated
+	Structs []string `json:"structs"`
+	// Warnings are the warnings logged while generating
+	Warnings []string `json:"warnings"`
+	// Error is the error the generation failed with, if any
+	Error string `json:"error,omitempty"`
+}
+
+// Result returns the report of the generation, which is complete once Generate returned
+func (g *GenerateDTOFromProtoGo) Result() DTOResult {
+	// report empty lists rather than null in json
+	result := DTOResult{Files: []string{}, Structs: []string{}, Warnings: []string{}}
+	result.Files = append(result.Files, g.result.Files...)
+	result.Structs = append(result.Structs, g.result.Structs...)
+	result.Warnings = append(result.Warnings, g.result.Warnings...)
+	return result
+}
+
+// warnf logs a warning and records it in the report of the generation
+func (g *GenerateDTOFromProtoGo) warnf(format string, args ...interface{}) {
+	logrus.Warnf(format, args...)
+	g.result.Warnings = append(g.result.Warnings, fmt.Sprintf(format, args...))
+}
+
+// pbPosition returns where a struct or a field is declared in pb.go, e.g. pkg/pb/hello.pb.go:12:2, for the diagnostics
+// to point to it
+func (g *GenerateDTOFromProtoGo) pbPosition(position token.Position) string {
+	return fmt.Sprintf("%s:%d:%d", g.protoGoFileFullPath, position.Line, position.Column)
+}
@@ -0,0 +1,295 @@
+package generator
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/dave/jennifer/jen"
+	"github.com/kujtimiihoxha/kit/utils"
+)
+
+// name of the test file checking that the dto bindings round trip, e.g. z_helloService_dto_roundtrip_test.go
+const formatAutoGenDTORoundTripFileName = `z_%s_dto_roundtrip_test.go`
+
+// genRoundTripTests generates a test converting a sample of every pb struct having a dto to its dto and back,
+// failing when the result is not deeply equal to the sample, i.e. when the FromPB / ToPB bindings are not symmetric
+func (g *GenerateDTOFromProtoGo) genRoundTripTests(pbStructNames []string) error {
+	srcFile := g.newDTOFile()
+	g.genHeader(srcFile)
+	for importPath, alias := range g.importAliases {
+		srcFile.ImportAlias(importPath, alias)
+	}
+
+	g.samplePtrs = newSamplePtrs("roundTripPtr")
+	subtests := []jen.Code{}
+	for _, pbStructName := range pbStructNames {
+		fromPB := jen.Id("converted").Op(":=").Add(g.bindingCall(g.fromPBFuncName(pbStructName), jen.Qual("context", "Background").Call(), jen.Id("sample")))
+		if g.fallibleFromPB[pbStructName] {
+			fromPB = jen.List(jen.Id("converted"), jen.Err()).Op(":=").Add(g.bindingCall(g.fromPBFuncName(pbStructName), jen.Qual("context", "Background").Call(), jen.Id("sample"))).Line().
+				If(jen.Err().Op("!=").Nil()).Block(jen.Id("t").Dot("Fatal").Call(jen.Err()))
+		}
+		toPB := jen.Id("got").Op(":=").Add(g.bindingCall(g.toPBFuncName(pbStructName), jen.Qual("context", "Background").Call(), jen.Id("converted")))
+		if g.fallibleToPB[pbStructName] {
+			toPB = jen.List(jen.Id("got"), jen.Err()).Op(":=").Add(g.bindingCall(g.toPBFuncName(pbStructName), jen.Qual("context", "Background").Call(), jen.Id("converted"))).Line().
+				If(jen.Err().Op("!=").Nil()).Block(jen.Id("t").Dot("Fatal").Call(jen.Err()))
+		}
+
+		subtests = append(subtests, jen.Id("t").Dot("Run").Call(
+			jen.Lit(pbStructName),
+			jen.Func().Params(jen.Id("t").Id("*").Qual("testing", "T")).Block(
+				jen.Id("sample").Op(":=").Add(g.structSample(pbStructName, map[string]bool{})),
+				fromPB,
+				toPB,
+				jen.If(jen.Op("!").Qual("reflect", "DeepEqual").Call(jen.Id("got"), jen.Id("sample"))).Block(
+					jen.Id("t").Dot("Errorf").Call(jen.Lit(pbStructName+" does not round trip, got %+v, want %+v"), jen.Id("got"), jen.Id("sample")),
+				),
+			),
+		))
+	}
+
+	code := NewPartialGenerator(srcFile.Empty())
+	code.NewLine()
+	code.appendMultilineComment([]string{
+		"TestDTORoundTrip converts a sample of every pb struct having a dto to its dto and back, and fails when the result",
+		"differs from the sample, i.e. when the FromPB / ToPB bindings are not symmetric.",
+	})
+	code.NewLine()
+	code.appendFunction(
+		"TestDTORoundTrip",
+		nil,
+		[]jen.Code{jen.Id("t").Id("*").Qual("testing", "T")},
+		[]jen.Code{},
+		"",
+		subtests...,
+	)
+	code.NewLine()
+	code.NewLine()
+	g.genSamplePtrFuncs(code)
+
+	roundTripFileFullPath := path.Join(g.dtoPackagePath, fmt.Sprintf(formatAutoGenDTORoundTripFileName, g.serviceName))
+	return g.writeGeneratedFile(roundTripFileFullPath, srcFile.GoString())
+}
+
+// structSample returns a pb struct literal setting every field of its dto to a sample value, e.g. &pb.Address{City: "a"},
+// the structs being sampled are not sampled again, so that a recursive field is left nil
+func (g *GenerateDTOFromProtoGo) structSample(pbStructName string, sampling map[string]bool) jen.Code {
+	sampling[pbStructName] = true
+	defer delete(sampling, pbStructName)
+
+	fields := jen.Dict{}
+	for _, fieldState := range g.fieldManifests[pbStructName] {
+		if sample, ok := g.fieldSample(fieldState, sampling); ok {
+			fields[jen.Id(fieldState.Name)] = sample
+		}
+	}
+	return jen.Op("&").Add(g.pbStructType(pbStructName)).Values(fields)
+}
+
+// fieldSample returns the sample value of a pb field, collections holding a single sample element,
+// false is returned when no sample can be made, e.g. for overridden fields, the field is then left zero
+func (g *GenerateDTOFromProtoGo) fieldSample(fieldState fieldState, sampling map[string]bool) (jen.Code, bool) {
+	if fieldState.Override != nil {
+		return nil, false
+	}
+	if fieldState.Wrapper != "" {
+		wrapped := fieldState
+		wrapped.Wrapper = ""
+		sample, ok := g.fieldSample(wrapped, sampling)
+		if !ok {
+			return nil, false
+		}
+		return jen.Op("&").Add(g.pbStructType(fieldState.Wrapper)).Values(jen.Dict{jen.Id(fieldState.WrappedField): sample}), true
+	}
+
+	if fieldState.IsCollectionPtr {
+		// e.g. &[]string{"a"}, the bytes being sampled by a conversion are left nil
+		collection := fieldState
+		collection.IsCollectionPtr = false
+		sample, ok := g.fieldSample(collection, sampling)
+		if !ok || fieldState.IsSlice && fieldState.TypeName == "byte" {
+			return nil, false
+		}
+		return jen.Op("&").Add(sample), true
+	}
+
+	if fieldState.IsSlice && fieldState.TypeName == "byte" {
+		return primitiveSample(fieldState.PBType)
+	}
+	elem, hasElem := g.elemSample(fieldState, sampling)
+	if !hasElem && !fieldState.isConverted() {
+		return nil, false
+	}
+	// the bindings of converted fields always make collections, so a collection whose element cannot be sampled
+	// is sampled empty, not nil
+	key, hasKey := primitiveSample(fieldState.MapKeyType)
+	if fieldState.MapEntry != "" {
+		entries := []jen.Code{}
+		if hasElem && hasKey {
+			entries = append(entries, jen.Values(jen.Dict{jen.Id("Key"): key, jen.Id("Value"): elem}))
+		}
+		return jen.Index().Id("*").Add(g.pbStructType(fieldState.MapEntry)).Values(entries...), true
+	}
+
+	var collectionType jen.Code = jen.Id(fieldState.PBType)
+	if fieldState.isConverted() && fieldState.IsMap {
+		collectionType = jen.Map(fieldState.mapKeyType()).Add(g.pbElemType(fieldState))
+	} else if fieldState.isConverted() && fieldState.IsSlice {
+		collectionType = jen.Index().Add(g.pbElemType(fieldState))
+	} else if fieldState.isConverted() && fieldState.ArrayLen != "" {
+		collectionType = jen.Index(jen.Id(fieldState.ArrayLen)).Add(g.pbElemType(fieldState))
+	}
+	if fieldState.IsMap {
+		if !hasElem || !hasKey {
+			return jen.Add(collectionType).Values(), true
+		}
+		return jen.Add(collectionType).Values(jen.Dict{key: elem}), true
+	} else if fieldState.IsSlice {
+		if !hasElem {
+			return jen.Add(collectionType).Values(), true
+		}
+		return jen.Add(collectionType).Values(elem), true
+	} else if fieldState.ArrayLen != "" {
+		// an array is never nil, a zero array is sampled by leaving the field zero
+		if !hasElem {
+			return nil, false
+		}
+		return jen.Add(collectionType).Values(elem), true
+	}
+	if hasElem && isPtrSample(fieldState) {
+		// e.g. roundTripPtrInt32(1) for a proto3 optional int32
+		return g.samplePtr(fieldState, elem), true
+	}
+	return elem, hasElem
+}
+
+// samplePtrs collects the pointer types of the samples of a generated file, the sample of a pointer to a scalar or an enum,
+// e.g. of a proto3 optional field, being made by a func of the file taking the address of its value, see genSamplePtrFuncs
+type samplePtrs struct {
+	// prefix of the funcs, so that the ones of the files of the dto package do not collide, e.g. roundTripPtr for roundTripPtrInt32
+	prefix string
+
+	// types holds the types pointed to keyed by the name of their func
+	types map[string]jen.Code
+}
+
+func newSamplePtrs(prefix string) samplePtrs {
+	return samplePtrs{prefix: prefix, types: map[string]jen.Code{}}
+}
+
+// isPtrSample tells if the sample of a pb field is a pointer to a scalar or an enum, e.g. Nickname *string,
+// the pointers to structs and well known types being sampled by a literal or a constructor
+func isPtrSample(fieldState fieldState) bool {
+	return !fieldState.IsStructType && fieldState.WellKnown == nil && !fieldState.IsMap && !fieldState.IsSlice &&
+		fieldState.ArrayLen == "" && strings.HasPrefix(fieldState.PBType, "*")
+}
+
+// samplePtr returns the sample of a pointer field, the address of the sample of its value, e.g. roundTripPtrInt32(1)
+func (g *GenerateDTOFromProtoGo) samplePtr(fieldState fieldState, value jen.Code) jen.Code {
+	var valueType jen.Code = jen.Id(fieldState.TypeName)
+	if fieldState.IsEnum {
+		valueType = jen.Qual(g.pbPackagePath, fieldState.TypeName)
+	}
+	funcName := g.samplePtrs.prefix + utils.ToUpperFirst(fieldState.TypeName)
+	g.samplePtrs.types[funcName] = valueType
+	return jen.Id(funcName).Call(value)
+}
+
+// genSamplePtrFuncs generates the funcs taking the address of the samples of the pointer fields, e.g.
+//
+//	func roundTripPtrInt32(v int32) *int32 {
+//		return &v
+//	}
+func (g *GenerateDTOFromProtoGo) genSamplePtrFuncs(code *PartialGenerator) {
+	funcNames := []string{}
+	for funcName := range g.samplePtrs.types {
+		funcNames = append(funcNames, funcName)
+	}
+	sort.Strings(funcNames)
+	for _, funcName := range funcNames {
+		valueType := g.samplePtrs.types[funcName]
+		code.appendFunction(
+			funcName,
+			nil,
+			[]jen.Code{jen.Id("v").Add(valueType)},
+			[]jen.Code{jen.Id("*").Add(valueType)},
+			"",
+			jen.Return(jen.Op("&").Id("v")),
+		)
+		code.NewLine()
+		code.NewLine()
+	}
+}
+
+// elemSample returns the sample value of a pb field, or of its elements for a collection
+func (g *GenerateDTOFromProtoGo) elemSample(fieldState fieldState, sampling map[string]bool) (jen.Code, bool) {
+	if fieldState.IsStructType {
+		if sampling[fieldState.TypeName] {
+			return nil, false
+		}
+		return g.structSample(fieldState.TypeName, sampling), true
+	} else if fieldState.IsEnum {
+		return jen.Qual(g.pbPackagePath, fieldState.TypeName).Call(jen.Lit(1)), true
+	} else if fieldState.WellKnown != nil {
+		return wellKnownSample(*fieldState.WellKnown)
+	} else if fieldState.ImportPath != "" {
+		// types of other packages are shared by pb and dto, they need no sample
+		return nil, false
+	}
+	return primitiveSample(fieldState.TypeName)
+}
+
+// wellKnownSample returns a sample value of a well known type, which is the same once converted to dto and back
+func wellKnownSample(wellKnown wellKnownType) (jen.Code, bool) {
+	switch wellKnown.importPath {
+	case "":
+		return jen.Lit(1), true
+	case wrapperspbImportPath:
+		for _, wrapper := range wrapperTypes {
+			if wrapper.name != wellKnown.name {
+				continue
+			}
+			value, _ := primitiveSample(wrapper.goType)
+			return jen.Qual(wrapperspbImportPath, wrapper.constructor).Call(value), true
+		}
+	case durationpbImportPath:
+		return jen.Op("&").Qual(durationpbImportPath, "Duration").Values(jen.Dict{jen.Id("Seconds"): jen.Lit(1)}), true
+	case timestamppbImportPath:
+		return jen.Op("&").Qual(timestamppbImportPath, "Timestamp").Values(jen.Dict{jen.Id("Seconds"): jen.Lit(1)}), true
+	case emptypbImportPath:
+		return jen.Op("&").Qual(emptypbImportPath, "Empty").Values(), true
+	case fieldmaskpbImportPath:
+		return jen.Op("&").Qual(fieldmaskpbImportPath, "FieldMask").Values(jen.Dict{jen.Id("Paths"): jen.Index().String().Values(jen.Lit("a"))}), true
+	case structpbImportPath:
+		if wellKnown.name == "Value" {
+			return jen.Qual(structpbImportPath, "NewStringValue").Call(jen.Lit("a")), true
+		}
+		return jen.Op("&").Qual(structpbImportPath, "Struct").Values(jen.Dict{
+			jen.Id("Fields"): jen.Map(jen.String()).Id("*").Qual(structpbImportPath, "Value").Values(jen.Dict{
+				jen.Lit("a"): jen.Qual(structpbImportPath, "NewStringValue").Call(jen.Lit("a")),
+			}),
+		}), true
+	case anypbImportPath:
+		return jen.Op("&").Qual(anypbImportPath, "Any").Values(jen.Dict{
+			jen.Id("TypeUrl"): jen.Lit("a"),
+			jen.Id("Value"):   jen.Index().Byte().Parens(jen.Lit("a")),
+		}), true
+	}
+	return nil, false
+}
+
+// primitiveSample returns a non zero value of a go primitive type, e.g. "a" for string
+func primitiveSample(typeName string) (jen.Code, bool) {
+	switch typeName {
+	case "string":
+		return jen.Lit("a"), true
+	case "[]byte":
+		return jen.Index().Byte().Parens(jen.Lit("a")), true
+	case "bool":
+		return jen.True(), true
+	case "byte", "int32", "int64", "uint32", "uint64", "float32", "float64":
+		return jen.Lit(1), true
+	}
+	return nil, false
+}
@@ -0,0 +1,60 @@
+package generator
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/dave/jennifer/jen"
+)
+
+// name of the file holding the sample dto builders, e.g. z_helloService_dto_samples.go
+const formatAutoGenDTOSamplesFileName = `z_%s_dto_samples.go`
+
+// genSamples generates a Sample<Dto> func per dto, returning a dto whose fields hold deterministic non zero sample values,
+// for table tests and examples, e.g.
+//
+//	func SampleHelloRequest() *HelloRequest
+//
+// the dto is converted from the pb sample of the round trip tests, see structSample, so a struct referring to itself
+// is sampled once, its recursive fields being left nil, and the fields which cannot be sampled are left zero, e.g. the
+// overridden fields or the types of other packages
+func (g *GenerateDTOFromProtoGo) genSamples(pbStructNames []string) error {
+	srcFile := g.newDTOFile()
+	g.genHeader(srcFile)
+	for importPath, alias := range g.importAliases {
+		srcFile.ImportAlias(importPath, alias)
+	}
+
+	g.samplePtrs = newSamplePtrs("samplePtr")
+	code := NewPartialGenerator(srcFile.Empty())
+	code.NewLine()
+	for _, pbStructName := range pbStructNames {
+		dtoTypeName := g.dtoTypeName(pbStructName)
+		fromPB := g.bindingCall(g.fromPBFuncName(pbStructName), jen.Qual("context", "Background").Call(), g.structSample(pbStructName, map[string]bool{}))
+		body := []jen.Code{jen.Return(fromPB)}
+		if g.fallibleFromPB[pbStructName] {
+			// the samples are valid, a conversion error is a bug of the bindings
+			body = []jen.Code{
+				jen.List(jen.Id("sample"), jen.Err()).Op(":=").Add(fromPB),
+				jen.If(jen.Err().Op("!=").Nil()).Block(jen.Panic(jen.Err())),
+				jen.Return(jen.Id("sample")),
+			}
+		}
+		code.appendMultilineComment([]string{fmt.Sprintf("Sample%s returns a %s dto whose fields hold deterministic non zero sample values.", dtoTypeName, dtoTypeName)})
+		code.NewLine()
+		code.appendFunction(
+			"Sample"+dtoTypeName,
+			nil,
+			[]jen.Code{},
+			[]jen.Code{},
+			"*"+dtoTypeName,
+			body...,
+		)
+		code.NewLine()
+		code.NewLine()
+	}
+	g.genSamplePtrFuncs(code)
+
+	samplesFileFullPath := path.Join(g.dtoPackagePath, fmt.Sprintf(formatAutoGenDTOSamplesFileName, g.serviceName))
+	return g.writeGeneratedFile(samplesFileFullPath, srcFile.GoString())
+}
@@ -0,0 +1,176 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// name of the json schema describing the dto, e.g. z_helloService_dto.schema.json
+const formatAutoGenDTOSchemaFileName = `z_%s_dto.schema.json`
+
+// jsonSchema is the subset of json schema (draft-07) used to describe the dto, an empty schema accepts any value
+type jsonSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Comment              string                 `json:"$comment,omitempty"`
+	Ref                  string                 `json:"$ref,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	ContentEncoding      string                 `json:"contentEncoding,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	AdditionalProperties *jsonSchema            `json:"additionalProperties,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Definitions          map[string]*jsonSchema `json:"definitions,omitempty"`
+	AnyOf                []*jsonSchema          `json:"anyOf,omitempty"`
+
+	// nullable is set for the fields marshalled as null when nil, see isNullableField
+	nullable bool
+}
+
+// MarshalJSON marshals a nullable schema as a union with null, e.g. {"type": ["string", "null"]},
+// or {"anyOf": [{"$ref": "#/definitions/Address"}, {"type": "null"}]} for a nested dto,
+// the empty schema already accepting null
+func (s *jsonSchema) MarshalJSON() ([]byte, error) {
+	type plainSchema jsonSchema
+	if !s.nullable || s.Ref == "" && s.Type == "" {
+		return json.Marshal((*plainSchema)(s))
+	} else if s.Ref != "" {
+		return json.Marshal(&jsonSchema{AnyOf: []*jsonSchema{{Ref: s.Ref}, {Type: "null"}}})
+	}
+	return json.Marshal(&struct {
+		Type []string `json:"type"`
+		*plainSchema
+	}{[]string{s.Type, "null"}, (*plainSchema)(s)})
+}
+
+// genJSONSchema generates the json schema of the dto, holding a definition per dto keyed by dto type name,
+// nested dto are referred to with $ref, e.g. {"$ref": "#/definitions/Address"}, and the required properties
+// are the fields a dto constructor takes, see isRequiredField, the nil fields marshalled as null also accept null
+func (g *GenerateDTOFromProtoGo) genJSONSchema(pbStructNames []string) error {
+	schema := &jsonSchema{
+		Schema:      "http://json-schema.org/draft-07/schema#",
+		Comment:     "Code generated by kit g dto. DO NOT EDIT.",
+		Definitions: map[string]*jsonSchema{},
+	}
+	for _, pbStructName := range pbStructNames {
+		definition := &jsonSchema{Type: "object", Properties: map[string]*jsonSchema{}}
+		for _, fieldState := range g.fieldManifests[pbStructName] {
+			// the fields tagged json:"-" are not marshalled
+			if fieldState.JSONName == "-" {
+				continue
+			}
+			fieldSchema := g.fieldSchema(fieldState)
+			fieldSchema.nullable = g.isNullableField(fieldState)
+			definition.Properties[fieldState.JSONName] = fieldSchema
+			if fieldState.Required {
+				definition.Required = append(definition.Required, fieldState.JSONName)
+			}
+		}
+		schema.Definitions[g.dtoTypeName(pbStructName)] = definition
+	}
+
+	content, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return err
+	}
+	schemaFileFullPath := path.Join(g.dtoPackagePath, fmt.Sprintf(formatAutoGenDTOSchemaFileName, g.serviceName))
+	return g.writeGeneratedFile(schemaFileFullPath, string(content)+"\n")
+}
+
+// fieldSchema returns the schema of a dto field, collections being arrays or objects of their elements
+func (g *GenerateDTOFromProtoGo) fieldSchema(fieldState fieldState) *jsonSchema {
+	if fieldState.Override != nil {
+		// the type of an overridden field is unknown
+		return &jsonSchema{}
+	}
+	if fieldState.IsMap {
+		return &jsonSchema{Type: "object", AdditionalProperties: g.elemSchema(fieldState)}
+	} else if fieldState.IsSlice && fieldState.TypeName == "byte" {
+		return &jsonSchema{Type: "string", ContentEncoding: "base64"}
+	} else if fieldState.IsSlice || fieldState.ArrayLen != "" {
+		// unlike []byte, a byte array is marshalled as an array of numbers
+		return &jsonSchema{Type: "array", Items: g.elemSchema(fieldState)}
+	}
+	return g.elemSchema(fieldState)
+}
+
+// isNullableField tells if a dto field is marshalled as null when nil, i.e. a pointer, e.g. a nested dto or an optional scalar,
+// a slice or a map, its json tag having no omitempty
+func (g *GenerateDTOFromProtoGo) isNullableField(fieldState fieldState) bool {
+	if fieldState.Override != nil {
+		// the type of an overridden field is unknown, its schema accepts any value
+		return false
+	}
+	dtoType := fmt.Sprintf("%#v", g.dtoFieldType(fieldState))
+	return strings.HasPrefix(dtoType, "*") || strings.HasPrefix(dtoType, "[]") || strings.HasPrefix(dtoType, "map[")
+}
+
+// elemSchema returns the schema of a dto field, or of its elements for a collection
+func (g *GenerateDTOFromProtoGo) elemSchema(fieldState fieldState) *jsonSchema {
+	if fieldState.IsStructType {
+		return &jsonSchema{Ref: "#/definitions/" + g.dtoTypeName(fieldState.TypeName)}
+	} else if fieldState.IsEnum && g.options.EnumAsString {
+		return &jsonSchema{Type: "string"}
+	} else if fieldState.IsEnum {
+		return &jsonSchema{Type: "integer", Format: "int32"}
+	} else if fieldState.WellKnown != nil {
+		return wellKnownSchema(*fieldState.WellKnown)
+	} else if fieldState.ImportPath != "" {
+		// types of other packages are kept as is, their json representation is unknown
+		return &jsonSchema{}
+	}
+	return primitiveSchema(fieldState.TypeName)
+}
+
+// wellKnownSchema returns the schema of the dto representation of a well known type
+func wellKnownSchema(wellKnown wellKnownType) *jsonSchema {
+	switch wellKnown.importPath {
+	case "":
+		// 64-bit integers mapped to strings, see int64AsStringTypes
+		return &jsonSchema{Type: "string", Format: wellKnown.name}
+	case wrapperspbImportPath:
+		for _, wrapper := range wrapperTypes {
+			if wrapper.name == wellKnown.name {
+				return primitiveSchema(wrapper.goType)
+			}
+		}
+	case durationpbImportPath:
+		// time.Duration is marshalled to json as its number of nanoseconds
+		return &jsonSchema{Type: "integer", Format: "int64"}
+	case timestamppbImportPath:
+		// time.Time is marshalled to json as an RFC 3339 string
+		return &jsonSchema{Type: "string", Format: "date-time"}
+	case emptypbImportPath:
+		return &jsonSchema{Type: "object"}
+	case fieldmaskpbImportPath:
+		return &jsonSchema{Type: "array", Items: &jsonSchema{Type: "string"}}
+	case structpbImportPath, anypbImportPath:
+		if wellKnown.name != "Value" {
+			return &jsonSchema{Type: "object"}
+		}
+	}
+	return &jsonSchema{}
+}
+
+// primitiveSchema returns the schema of a go primitive type, e.g. {"type": "integer", "format": "int32"} for int32
+func primitiveSchema(typeName string) *jsonSchema {
+	switch typeName {
+	case "string":
+		return &jsonSchema{Type: "string"}
+	case "[]byte":
+		return &jsonSchema{Type: "string", ContentEncoding: "base64"}
+	case "bool":
+		return &jsonSchema{Type: "boolean"}
+	case "int32", "int64", "uint32", "uint64":
+		return &jsonSchema{Type: "integer", Format: typeName}
+	case "byte":
+		return &jsonSchema{Type: "integer"}
+	case "float32":
+		return &jsonSchema{Type: "number", Format: "float"}
+	case "float64":
+		return &jsonSchema{Type: "number", Format: "double"}
+	}
+	return &jsonSchema{}
+}
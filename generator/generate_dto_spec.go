@@ -0,0 +1,154 @@
+package generator
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/dave/jennifer/jen"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// mappingSpecSource is the placeholder of the converted value in the expressions of a mapping spec
+const mappingSpecSource = "$"
+
+// mappingSpecQualifiedIdent matches the package qualified identifiers in the types / expressions of a mapping spec,
+// e.g. timestamppb.New
+var mappingSpecQualifiedIdent = regexp.MustCompile(`\b([A-Za-z_][A-Za-z0-9_]*)\.([A-Za-z_][A-Za-z0-9_]*)`)
+
+// mappingSpec holds the manual overrides of the dto fields, read from a yaml file, e.g.
+//
+//	imports:
+//	  time: time
+//	  timestamppb: google.golang.org/protobuf/types/known/timestamppb
+//	fields:
+//	  HelloRequest.CreatedAt:
+//	    type: time.Time
+//	    from_pb: $.AsTime()
+//	    to_pb: timestamppb.New($)
+//	unions:
+//	  Event:
+//	    discriminator: type
+//	    variants:
+//	      created: EventCreated
+//	      deleted: EventDeleted
+//
+// fields are keyed by <pb struct name>.<field name>, $ is replaced by the converted value in the expressions,
+// and the packages used by the types / expressions are resolved with imports, keyed by package name,
+// the pb package can be referred to as pb unless imports says otherwise,
+// unions are keyed by the name of their dto, see unionSpec
+type mappingSpec struct {
+	Imports map[string]string         `yaml:"imports"`
+	Fields  map[string]*fieldOverride `yaml:"fields"`
+	Unions  map[string]*unionSpec     `yaml:"unions"`
+}
+
+// fieldOverride replaces the automatic mapping of a dto field
+type fieldOverride struct {
+	// Type is the type of the dto field, the pb field type is kept if empty
+	Type string `yaml:"type"`
+
+	// FromPB and ToPB are the expressions converting the field from pb to dto and back, the value is assigned as is if empty
+	FromPB string `yaml:"from_pb"`
+	ToPB   string `yaml:"to_pb"`
+}
+
+// unionSpec groups pb structs as the variants of a tagged union dto, holding one of their dto and (un)marshalled to json
+// as that dto along with a discriminator field telling which, see genUnion
+type unionSpec struct {
+	// Discriminator is the json name of the field telling the variant, type if empty
+	Discriminator string `yaml:"discriminator"`
+
+	// Variants maps the values of the discriminator to the pb structs of the variants
+	Variants map[string]string `yaml:"variants"`
+}
+
+// parseMappingSpec parses and validates a yaml mapping spec
+func parseMappingSpec(src string) (*mappingSpec, error) {
+	spec := &mappingSpec{}
+	if err := yaml.UnmarshalStrict([]byte(src), spec); err != nil {
+		return nil, err
+	}
+	for key, override := range spec.Fields {
+		if parts := strings.Split(key, "."); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid field %s, fields must be keyed by <pb struct name>.<field name>", key)
+		}
+		if override == nil {
+			return nil, fmt.Errorf("field %s has no override", key)
+		}
+	}
+	for name, union := range spec.Unions {
+		if !token.IsIdentifier(name) || !ast.IsExported(name) {
+			return nil, fmt.Errorf("invalid union %s, unions must be keyed by an exported go identifier", name)
+		}
+		if union == nil || len(union.Variants) == 0 {
+			return nil, fmt.Errorf("union %s has no variants", name)
+		}
+		if union.Discriminator == "" {
+			union.Discriminator = "type"
+		}
+		variantValues := map[string]string{}
+		for _, value := range unionValues(union) {
+			pbStructName := union.Variants[value]
+			if pbStructName == "" {
+				return nil, fmt.Errorf("union %s has no pb struct for the variant %s", name, value)
+			}
+			if other, ok := variantValues[pbStructName]; ok {
+				return nil, fmt.Errorf("union %s has incompatible discriminators %s and %s for the same variant %s", name, other, value, pbStructName)
+			}
+			variantValues[pbStructName] = value
+		}
+	}
+	return spec, nil
+}
+
+// override returns the override of a field of a pb struct, if any
+func (s *mappingSpec) override(pbStructName, fieldName string) *fieldOverride {
+	if s == nil {
+		return nil
+	}
+	return s.Fields[pbStructName+"."+fieldName]
+}
+
+// code renders a type / expression of the spec, qualifying the identifiers of the spec imports, or of the standard packages
+// used by the bindings, e.g. time.Time, so they get imported in the generated file, and replacing the $ placeholder by src
+func (s *mappingSpec) code(expr string, src jen.Code) jen.Code {
+	st := &jen.Statement{}
+	for i, part := range strings.Split(expr, mappingSpecSource) {
+		if i > 0 {
+			st.Add(src)
+		}
+		last := 0
+		for _, match := range mappingSpecQualifiedIdent.FindAllStringSubmatchIndex(part, -1) {
+			importPath, ok := s.Imports[part[match[2]:match[3]]]
+			if !ok {
+				importPath, ok = stdImportPath(part[match[2]:match[3]])
+			}
+			if !ok {
+				continue
+			}
+			if match[0] > last {
+				st.Op(part[last:match[0]])
+			}
+			st.Qual(importPath, part[match[4]:match[5]])
+			last = match[1]
+		}
+		if last < len(part) {
+			st.Op(part[last:])
+		}
+	}
+	return st
+}
+
+// stdImportPath returns the import path of a standard package used by the bindings by its name, e.g. encoding/json for json
+func stdImportPath(name string) (string, bool) {
+	for _, importPath := range bindingStdImports {
+		if path.Base(importPath) == name {
+			return importPath, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,59 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dave/jennifer/jen"
+	"github.com/kujtimiihoxha/kit/utils"
+)
+
+// genStringer generates the String method of a dto listing its fields of the field manifest, e.g.
+//
+//	func (h *HelloRequest) String() string {
+//		if h == nil {
+//			return "<nil>"
+//		}
+//		return fmt.Sprintf("HelloRequest{Name:%q Address:%v}", h.Name, h.Address)
+//	}
+//
+// the nested dto are printed by their own String method, the fields annotated with @dto:redact are masked
+func (g *GenerateDTOFromProtoGo) genStringer(currentPBStructName string, fieldManifest []fieldState) {
+	dtoTypeName := g.dtoTypeName(currentPBStructName)
+	recv := utils.ReceiverName(dtoTypeName, "fmt")
+
+	var verbs []string
+	args := []jen.Code{}
+	for _, fieldState := range fieldManifest {
+		field := jen.Id(recv).Dot(fieldState.Name)
+		verb := "%v"
+		if fieldState.Redacted {
+			verbs = append(verbs, fieldState.Name+":"+redactedMask)
+			continue
+		} else if fieldState.PBType == "string" && (fieldState.Override == nil || fieldState.Override.Type == "") {
+			verb = "%q"
+		} else if fieldState.IsValueNested {
+			// String has a pointer receiver
+			field = jen.Op("&").Add(field)
+		}
+		verbs = append(verbs, fieldState.Name+":"+verb)
+		args = append(args, field)
+	}
+
+	format := fmt.Sprintf("%s{%s}", dtoTypeName, strings.Join(verbs, " "))
+	var res jen.Code = jen.Qual("fmt", "Sprintf").Call(append([]jen.Code{jen.Lit(format)}, args...)...)
+	if len(args) == 0 {
+		res = jen.Lit(format)
+	}
+	g.code.appendFunction(
+		"String",
+		jen.Id(recv).Id("*"+dtoTypeName),
+		[]jen.Code{},
+		[]jen.Code{},
+		"string",
+		jen.If(jen.Id(recv).Op("==").Nil()).Block(jen.Return(jen.Lit("<nil>"))),
+		jen.Return(res),
+	)
+	g.code.NewLine()
+	g.code.NewLine()
+}
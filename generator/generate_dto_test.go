@@ -1,12 +1,28 @@
 package generator
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	goparser "go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/dave/jennifer/jen"
 	"github.com/kujtimiihoxha/kit/fs"
 	"github.com/kujtimiihoxha/kit/parser"
+	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -88,8 +104,8 @@ func TestGenerateDTO(t *testing.T) {
 				dtoPackagePath:      "test/pkg/test/dto",
 				dtoFileFullPath:     "test/pkg/test/dto/z_test_dto.go",
 			},
-			wantErr: false,
-			wantResult: "// THIS FILE IS AUTO GENERATED, DO NOT EDIT!!\npackage dto\n",
+			wantErr:    false,
+			wantResult: "// Code generated by kit g dto. DO NOT EDIT.\n// source: test/pkg/grpc/pb/z_test.pb.go\npackage dto\n",
 		},
 		{
 			name: "generator creates dto struct + bindings if pb.go file contains *Request / *Response struct",
@@ -113,14 +129,15 @@ func TestGenerateDTO(t *testing.T) {
 				pbPackagePath:       "test/pkg/grpc/pb",
 			},
 			wantErr: false,
-			wantResult: `// THIS FILE IS AUTO GENERATED, DO NOT EDIT!!
+			wantResult: `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
 package dto
 
-import pb "test/pkg/grpc/pb"
+import testpb "test/pkg/grpc/pb"
 
 type TestRequest struct{}
 
-func TestRequestFromPB(pb *pb.TestRequest) *TestRequest {
+func TestRequestFromPB(pb *testpb.TestRequest) *TestRequest {
 	if pb == nil {
 		return nil
 	}
@@ -128,12 +145,12 @@ func TestRequestFromPB(pb *pb.TestRequest) *TestRequest {
 	return &TestRequest{}
 }
 
-func TestRequestToPB(orig *TestRequest) *pb.TestRequest {
+func TestRequestToPB(orig *TestRequest) *testpb.TestRequest {
 	if orig == nil {
 		return nil
 	}
 
-	return &pb.TestRequest{}
+	return &testpb.TestRequest{}
 }
 `,
 		},
@@ -171,15 +188,16 @@ func TestRequestToPB(orig *TestRequest) *pb.TestRequest {
 				targetPBStructName:  "Something",
 			},
 			wantErr: false,
-			wantResult: `// THIS FILE IS AUTO GENERATED, DO NOT EDIT!!
+			wantResult: `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
 package dto
 
-import pb "test/pkg/grpc/pb"
+import testpb "test/pkg/grpc/pb"
 
 type StructVal struct {
-	AString string` + " `json:\"aString\"`\n}"+`
+	AString string` + " `json:\"aString\"`\n}" + `
 
-func StructValFromPB(pb *pb.StructVal) *StructVal {
+func StructValFromPB(pb *testpb.StructVal) *StructVal {
 	if pb == nil {
 		return nil
 	}
@@ -187,21 +205,21 @@ func StructValFromPB(pb *pb.StructVal) *StructVal {
 	return &StructVal{AString: pb.AString}
 }
 
-func StructValToPB(orig *StructVal) *pb.StructVal {
+func StructValToPB(orig *StructVal) *testpb.StructVal {
 	if orig == nil {
 		return nil
 	}
 
-	return &pb.StructVal{AString: orig.AString}
+	return &testpb.StructVal{AString: orig.AString}
 }
 
 type Something struct {
-	Name        string                ` + "`json:\"name\"`"+`
-	StructMap   map[string]*StructVal ` + "`json:\"structMap\"`" +`
+	Name        string                ` + "`json:\"name\"`" + `
+	StructMap   map[string]*StructVal ` + "`json:\"structMap\"`" + `
 	StructSlice []*StructVal          ` + "`json:\"structSlice\"`" + `
 }
 
-func SomethingFromPB(pb *pb.Something) *Something {
+func SomethingFromPB(pb *testpb.Something) *Something {
 	if pb == nil {
 		return nil
 	}
@@ -221,20 +239,20 @@ func SomethingFromPB(pb *pb.Something) *Something {
 	}
 }
 
-func SomethingToPB(orig *Something) *pb.Something {
+func SomethingToPB(orig *Something) *testpb.Something {
 	if orig == nil {
 		return nil
 	}
 
-	m := make(map[string]*pb.StructVal, len(orig.StructMap))
+	m := make(map[string]*testpb.StructVal, len(orig.StructMap))
 	for k, v := range orig.StructMap {
 		m[k] = StructValToPB(v)
 	}
-	aSlice := make([]*pb.StructVal, 0, len(orig.StructSlice))
+	aSlice := make([]*testpb.StructVal, 0, len(orig.StructSlice))
 	for _, v := range orig.StructSlice {
 		aSlice = append(aSlice, StructValToPB(v))
 	}
-	return &pb.Something{
+	return &testpb.Something{
 		Name:        orig.Name,
 		StructMap:   m,
 		StructSlice: aSlice,
@@ -251,6 +269,7 @@ func SomethingToPB(orig *Something) *pb.Something {
 			}
 			g.protoGoFileFullPath = tt.fields.protoGoFileFullPath
 			g.dtoPackagePath = tt.fields.dtoPackagePath
+			g.dtoImportPath = tt.fields.dtoPackagePath
 			g.dtoFileFullPath = tt.fields.dtoFileFullPath
 			g.pbPackagePath = tt.fields.pbPackagePath
 			g.targetPBStructName = tt.fields.targetPBStructName
@@ -263,3 +282,7975 @@ func SomethingToPB(orig *Something) *pb.Something {
 		})
 	}
 }
+
+// newTestDTOGenerator returns a dto generator for service "test" backed by an in-memory pb.go containing pbSrc
+func newTestDTOGenerator(pbSrc string) *GenerateDTOFromProtoGo {
+	b := BaseGenerator{}
+	b.srcFile = jen.NewFilePath("test/pkg/test/dto")
+	b.InitPg()
+	f := fs.NewDefaultFs("")
+	f.MkdirAll("test/pkg/grpc/pb")
+	f.WriteFile("test/pkg/grpc/pb/z_test.pb.go", pbSrc, true)
+	b.fs = f
+
+	return &GenerateDTOFromProtoGo{
+		BaseGenerator:       b,
+		serviceName:         "test",
+		protoGoFileFullPath: "test/pkg/grpc/pb/z_test.pb.go",
+		dtoPackagePath:      "test/pkg/test/dto",
+		dtoFileFullPath:     "test/pkg/test/dto/z_test_dto.go",
+		dtoImportPath:       "test/pkg/test/dto",
+		pbPackagePath:       "test/pkg/grpc/pb",
+	}
+}
+
+// assertGeneratedInOrder asserts that each of the dto structs and its bindings are generated exactly once,
+// and that the structs appear in the given order
+func assertGeneratedInOrder(t *testing.T, content string, structNames ...string) {
+	lastIndex := -1
+	for _, name := range structNames {
+		for _, decl := range []string{
+			fmt.Sprintf("type %s struct", name),
+			fmt.Sprintf("func %sFromPB(", name),
+			fmt.Sprintf("func %sToPB(", name),
+		} {
+			assert.Equal(t, 1, strings.Count(content, decl), decl)
+		}
+
+		index := strings.Index(content, fmt.Sprintf("type %s struct", name))
+		assert.True(t, index > lastIndex, "%s is not generated in dependency order", name)
+		lastIndex = index
+	}
+}
+
+func TestGenerateDTONestedReferences(t *testing.T) {
+	setDefaults()
+	tests := []struct {
+		name      string
+		pbSrc     string
+		wantOrder []string
+	}{
+		{
+			name: "chain of nested structs A->B->C->D->E",
+			pbSrc: `package pb
+			type ARequest struct {
+				B *B
+			}
+			type B struct {
+				C *C
+			}
+			type C struct {
+				D *D
+			}
+			type D struct {
+				E *E
+			}
+			type E struct {
+				Name string
+			}`,
+			wantOrder: []string{"E", "D", "C", "B", "ARequest"},
+		},
+		{
+			name: "diamond references A->B, A->C, B->D, C->D",
+			pbSrc: `package pb
+			type ARequest struct {
+				B *B
+				C *C
+			}
+			type B struct {
+				D *D
+			}
+			type C struct {
+				Ds []*D
+			}
+			type D struct {
+				Name string
+			}`,
+			wantOrder: []string{"D", "B", "C", "ARequest"},
+		},
+		{
+			name: "diamond references shared by two top level structs",
+			pbSrc: `package pb
+			type ARequest struct {
+				D *D
+			}
+			type AResponse struct {
+				Ds map[string]*D
+				A  *ARequest
+			}
+			type D struct {
+				Name string
+			}`,
+			wantOrder: []string{"D", "ARequest", "AResponse"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestDTOGenerator(tt.pbSrc)
+			if err := g.Generate(); err != nil {
+				t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+			}
+			content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+			assertGeneratedInOrder(t, content, tt.wantOrder...)
+			typeCheckDTO(t, tt.pbSrc, content)
+		})
+	}
+}
+
+func TestGenerateDTOProto3OptionalFields(t *testing.T) {
+	setDefaults()
+	// the fields of a proto3 message, optional ones being pointers read by getters
+	pbSrc := `package pb
+	type Status int32
+	const (
+		Status_UNKNOWN Status = 0
+		Status_ACTIVE  Status = 1
+	)
+	var (
+		Status_name  = map[int32]string{0: "UNKNOWN", 1: "ACTIVE"}
+		Status_value = map[string]int32{"UNKNOWN": 0, "ACTIVE": 1}
+	)
+	type Address struct {
+		City string
+	}
+	type HelloRequest struct {
+		Name           string
+		OptionalBool   *bool
+		OptionalInt32  *int32
+		OptionalInt64  *int64
+		OptionalDouble *float64
+		OptionalString *string
+		OptionalBytes  []byte
+		OptionalStatus *Status
+		Status         Status
+		Home           *Address
+		Tags           []string
+	}
+	func (x *HelloRequest) GetOptionalInt32() int32 {
+		if x != nil && x.OptionalInt32 != nil {
+			return *x.OptionalInt32
+		}
+		return 0
+	}
+	func (x *HelloRequest) GetOptionalStatus() Status {
+		if x != nil && x.OptionalStatus != nil {
+			return *x.OptionalStatus
+		}
+		return Status_UNKNOWN
+	}
+	type HelloResponse struct {
+		Greeting *string
+	}`
+	for _, enumAsString := range []bool{false, true} {
+		t.Run(fmt.Sprintf("enum as string %v", enumAsString), func(t *testing.T) {
+			g := newTestDTOGenerator(pbSrc)
+			g.fs.WriteFile("test/pkg/service/service.go", `package service
+			import "context"
+			type TestService interface {
+				Hello(ctx context.Context, name string) (greeting string, err error)
+			}`, true)
+			g.options.EnumAsString = enumAsString
+			g.options.WithConstructors = true
+			g.options.WithGetters = true
+			g.options.WithBuilder = true
+			g.options.WithStringer = true
+			g.options.WithJSONHelpers = true
+			g.options.WithFieldConstants = true
+			g.options.MapperInterface = true
+			g.options.WithRegistry = true
+			g.options.GRPCBindings = true
+			g.options.WithSamples = true
+			g.options.WithRoundTripTests = true
+			g.options.WithBenchmarks = true
+			g.options.WithDriftCheck = true
+			if err := g.Generate(); err != nil {
+				t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+			}
+			fileNames, _ := g.fs.ReadDir(g.dtoPackagePath)
+			dtoSrcs := []string{}
+			for _, fileName := range fileNames {
+				src, _ := g.fs.ReadFile(g.dtoPackagePath + "/" + fileName)
+				dtoSrcs = append(dtoSrcs, src)
+			}
+			assert.Equal(t, []string{
+				"z_test_dto.go",
+				"z_test_dto_bench_test.go",
+				"z_test_dto_drift_test.go",
+				"z_test_dto_roundtrip_test.go",
+				"z_test_dto_samples.go",
+				"z_test_grpc_bindings.go",
+			}, fileNames)
+			typeCheckDTO(t, pbSrc, dtoSrcs...)
+		})
+	}
+}
+
+func TestGenerateDTOTypePrefixSuffix(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type UserRequest struct {
+		Address   *Address
+		Addresses []*Address
+	}
+	type Address struct {
+		Street string
+	}`)
+	g.options = DTOOptions{TypePrefix: "Api", TypeSuffix: "DTO"}
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import testpb "test/pkg/grpc/pb"
+
+type ApiAddressDTO struct {
+	Street string `+"`json:\"street\"`"+`
+}
+
+func ApiAddressDTOFromPB(pb *testpb.Address) *ApiAddressDTO {
+	if pb == nil {
+		return nil
+	}
+
+	return &ApiAddressDTO{Street: pb.Street}
+}
+
+func ApiAddressDTOToPB(orig *ApiAddressDTO) *testpb.Address {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.Address{Street: orig.Street}
+}
+
+type ApiUserRequestDTO struct {
+	Address   *ApiAddressDTO   `+"`json:\"address\"`"+`
+	Addresses []*ApiAddressDTO `+"`json:\"addresses\"`"+`
+}
+
+func ApiUserRequestDTOFromPB(pb *testpb.UserRequest) *ApiUserRequestDTO {
+	if pb == nil {
+		return nil
+	}
+
+	aSlice := make([]*ApiAddressDTO, 0, len(pb.Addresses))
+	for _, v := range pb.Addresses {
+		aSlice = append(aSlice, ApiAddressDTOFromPB(v))
+	}
+	return &ApiUserRequestDTO{
+		Address:   ApiAddressDTOFromPB(pb.Address),
+		Addresses: aSlice,
+	}
+}
+
+func ApiUserRequestDTOToPB(orig *ApiUserRequestDTO) *testpb.UserRequest {
+	if orig == nil {
+		return nil
+	}
+
+	aSlice := make([]*testpb.Address, 0, len(orig.Addresses))
+	for _, v := range orig.Addresses {
+		aSlice = append(aSlice, ApiAddressDTOToPB(v))
+	}
+	return &testpb.UserRequest{
+		Address:   ApiAddressDTOToPB(orig.Address),
+		Addresses: aSlice,
+	}
+}
+`, content)
+}
+
+func TestGenerateDTOSplitBindings(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		Name string
+	}`)
+	g.options = DTOOptions{Split: true}
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+
+	types, _ := g.fs.ReadFile("test/pkg/test/dto/z_test_dto.go")
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+type HelloRequest struct {
+	Name string `+"`json:\"name\"`"+`
+}
+`, types)
+
+	bindings, _ := g.fs.ReadFile("test/pkg/test/dto/z_test_dto_bindings.go")
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import testpb "test/pkg/grpc/pb"
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	return &HelloRequest{Name: pb.Name}
+}
+
+func HelloRequestToPB(orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.HelloRequest{Name: orig.Name}
+}
+`, bindings)
+}
+
+func TestGenerateDTOStructpb(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	import structpb "google.golang.org/protobuf/types/known/structpb"
+	type MetaRequest struct {
+		Meta   *Meta
+		Values []*structpb.Value
+	}
+	type Meta struct {
+		Attributes *structpb.Struct
+		Labels map[string]*structpb.Value
+	}`)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import (
+	"fmt"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	testpb "test/pkg/grpc/pb"
+)
+
+type Meta struct {
+	Attributes map[string]interface{} `+"`json:\"attributes\"`"+`
+	Labels     map[string]interface{} `+"`json:\"labels\"`"+`
+}
+
+func MetaFromPB(pb *testpb.Meta) *Meta {
+	if pb == nil {
+		return nil
+	}
+
+	m := make(map[string]interface{}, len(pb.Labels))
+	for k, v := range pb.Labels {
+		m[k] = v.AsInterface()
+	}
+	return &Meta{
+		Attributes: pb.Attributes.AsMap(),
+		Labels:     m,
+	}
+}
+
+func MetaToPB(orig *Meta) (*testpb.Meta, error) {
+	if orig == nil {
+		return nil, nil
+	}
+
+	attributes, err := structpb.NewStruct(orig.Attributes)
+	if err != nil {
+		return nil, conversionError("Meta", "Attributes", err)
+	}
+	m := make(map[string]*structpb.Value, len(orig.Labels))
+	for k, v := range orig.Labels {
+		e, err := structpb.NewValue(v)
+		if err != nil {
+			return nil, conversionError("Meta", "Labels", err)
+		}
+		m[k] = e
+	}
+	return &testpb.Meta{
+		Attributes: attributes,
+		Labels:     m,
+	}, nil
+}
+
+type MetaRequest struct {
+	Meta   *Meta         `+"`json:\"meta\"`"+`
+	Values []interface{} `+"`json:\"values\"`"+`
+}
+
+func MetaRequestFromPB(pb *testpb.MetaRequest) *MetaRequest {
+	if pb == nil {
+		return nil
+	}
+
+	aSlice := make([]interface{}, 0, len(pb.Values))
+	for _, v := range pb.Values {
+		aSlice = append(aSlice, v.AsInterface())
+	}
+	return &MetaRequest{
+		Meta:   MetaFromPB(pb.Meta),
+		Values: aSlice,
+	}
+}
+
+func MetaRequestToPB(orig *MetaRequest) (*testpb.MetaRequest, error) {
+	if orig == nil {
+		return nil, nil
+	}
+
+	meta, err := MetaToPB(orig.Meta)
+	if err != nil {
+		return nil, conversionError("MetaRequest", "Meta", err)
+	}
+	aSlice := make([]*structpb.Value, 0, len(orig.Values))
+	for _, v := range orig.Values {
+		e, err := structpb.NewValue(v)
+		if err != nil {
+			return nil, conversionError("MetaRequest", "Values", err)
+		}
+		aSlice = append(aSlice, e)
+	}
+	return &testpb.MetaRequest{
+		Meta:   meta,
+		Values: aSlice,
+	}, nil
+}
+
+// ConversionError is the error of a binding failing to convert a field, Field being the path of the field in the Type dto, e.g. Address.Location.
+type ConversionError struct {
+	Field string
+	Type  string
+	Err   error
+}
+
+func (e *ConversionError) Error() string {
+	return fmt.Sprintf("converting %s.%s: %v", e.Type, e.Field, e.Err)
+}
+
+func (e *ConversionError) Unwrap() error {
+	return e.Err
+}
+
+// conversionError wraps the error converting a field of a dto, the field of a nested dto being prefixed with the field holding it.
+func conversionError(typeName string, field string, err error) error {
+	if nested, ok := err.(*ConversionError); ok {
+		return &ConversionError{
+			Err:   nested.Err,
+			Field: field + "." + nested.Field,
+			Type:  typeName,
+		}
+	}
+	return &ConversionError{
+		Err:   err,
+		Field: field,
+		Type:  typeName,
+	}
+}
+`, content)
+}
+
+func TestGenerateDTOWithConstructors(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type CreateUserRequest struct {
+		Name    string
+		Type    int32
+		Email   string ` + "`validate:\"email\"`" + `
+		Profile *Profile ` + "`validate:\"required\"`" + `
+		Manager *Profile
+		Tags    []string
+	}
+	type Profile struct {
+		Bio string
+	}`)
+	g.options.WithConstructors = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+
+	// optional fields (pointers, collections, validate tag without required) are not in the signature
+	assert.Contains(t, content, "func NewCreateUserRequest(name string, typeArg int32, profile *Profile) *CreateUserRequest {")
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import testpb "test/pkg/grpc/pb"
+
+type Profile struct {
+	Bio string `+"`json:\"bio\"`"+`
+}
+
+func NewProfile(bio string) *Profile {
+	return &Profile{Bio: bio}
+}
+
+func ProfileFromPB(pb *testpb.Profile) *Profile {
+	if pb == nil {
+		return nil
+	}
+
+	return &Profile{Bio: pb.Bio}
+}
+
+func ProfileToPB(orig *Profile) *testpb.Profile {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.Profile{Bio: orig.Bio}
+}
+
+type CreateUserRequest struct {
+	Name    string   `+"`json:\"name\"`"+`
+	Type    int32    `+"`json:\"type\"`"+`
+	Email   string   `+"`json:\"email\"`"+`
+	Profile *Profile `+"`json:\"profile\"`"+`
+	Manager *Profile `+"`json:\"manager\"`"+`
+	Tags    []string `+"`json:\"tags\"`"+`
+}
+
+func NewCreateUserRequest(name string, typeArg int32, profile *Profile) *CreateUserRequest {
+	return &CreateUserRequest{
+		Name:    name,
+		Profile: profile,
+		Type:    typeArg,
+	}
+}
+
+func CreateUserRequestFromPB(pb *testpb.CreateUserRequest) *CreateUserRequest {
+	if pb == nil {
+		return nil
+	}
+
+	return &CreateUserRequest{
+		Email:   pb.Email,
+		Manager: ProfileFromPB(pb.Manager),
+		Name:    pb.Name,
+		Profile: ProfileFromPB(pb.Profile),
+		Tags:    pb.Tags,
+		Type:    pb.Type,
+	}
+}
+
+func CreateUserRequestToPB(orig *CreateUserRequest) *testpb.CreateUserRequest {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.CreateUserRequest{
+		Email:   orig.Email,
+		Manager: ProfileToPB(orig.Manager),
+		Name:    orig.Name,
+		Profile: ProfileToPB(orig.Profile),
+		Tags:    orig.Tags,
+		Type:    orig.Type,
+	}
+}
+`, content)
+}
+
+func TestGenerateDTOEnumAsString(t *testing.T) {
+	setDefaults()
+	pbSrc := `package pb
+	type Status int32
+	const (
+		Status_UNKNOWN Status = 0
+		Status_ACTIVE  Status = 1
+	)
+	var (
+		Status_name = map[int32]string{
+			0: "UNKNOWN",
+			1: "ACTIVE",
+		}
+		Status_value = map[string]int32{
+			"UNKNOWN": 0,
+			"ACTIVE":  1,
+		}
+	)
+	type UserRequest struct {
+		Status  Status
+		History []Status
+	}`
+	g := newTestDTOGenerator(pbSrc)
+	g.options.EnumAsString = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import (
+	"encoding/json"
+	"fmt"
+	testpb "test/pkg/grpc/pb"
+)
+
+type Status int32
+
+func (s Status) MarshalJSON() ([]byte, error) {
+	if name, ok := testpb.Status_name[int32(s)]; ok {
+		return json.Marshal(name)
+	}
+	return json.Marshal(int32(s))
+}
+
+func (s *Status) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		if value, ok := testpb.Status_value[name]; ok {
+			*s = Status(value)
+			return nil
+		}
+		return fmt.Errorf("unknown Status name: %q", name)
+	}
+	var value int32
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	*s = Status(value)
+	return nil
+}
+
+type UserRequest struct {
+	Status  Status   `+"`json:\"status\"`"+`
+	History []Status `+"`json:\"history\"`"+`
+}
+
+func UserRequestFromPB(pb *testpb.UserRequest) *UserRequest {
+	if pb == nil {
+		return nil
+	}
+
+	aSlice := make([]Status, 0, len(pb.History))
+	for _, v := range pb.History {
+		aSlice = append(aSlice, Status(v))
+	}
+	return &UserRequest{
+		History: aSlice,
+		Status:  Status(pb.Status),
+	}
+}
+
+func UserRequestToPB(orig *UserRequest) *testpb.UserRequest {
+	if orig == nil {
+		return nil
+	}
+
+	aSlice := make([]testpb.Status, 0, len(orig.History))
+	for _, v := range orig.History {
+		aSlice = append(aSlice, testpb.Status(v))
+	}
+	return &testpb.UserRequest{
+		History: aSlice,
+		Status:  testpb.Status(orig.Status),
+	}
+}
+`, content)
+	typeCheckDTO(t, pbSrc, content)
+}
+
+func TestGenerateDTOOptionalEnum(t *testing.T) {
+	setDefaults()
+	pbSrc := `package pb
+	type ForeignEnum int32
+	const (
+		ForeignEnum_FOREIGN_FOO ForeignEnum = 0
+		ForeignEnum_FOREIGN_BAR ForeignEnum = 1
+	)
+	var (
+		ForeignEnum_name = map[int32]string{
+			0: "FOREIGN_FOO",
+			1: "FOREIGN_BAR",
+		}
+		ForeignEnum_value = map[string]int32{
+			"FOREIGN_FOO": 0,
+			"FOREIGN_BAR": 1,
+		}
+	)
+	type TestAllTypesRequest struct {
+		SingularForeignEnum ForeignEnum
+		OptionalForeignEnum *ForeignEnum
+	}
+	func (x *TestAllTypesRequest) GetOptionalForeignEnum() ForeignEnum {
+		if x != nil && x.OptionalForeignEnum != nil {
+			return *x.OptionalForeignEnum
+		}
+		return ForeignEnum_FOREIGN_FOO
+	}`
+	g := newTestDTOGenerator(pbSrc)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import testpb "test/pkg/grpc/pb"
+
+type ForeignEnum int32
+
+type TestAllTypesRequest struct {
+	SingularForeignEnum ForeignEnum  `+"`json:\"singularForeignEnum\"`"+`
+	OptionalForeignEnum *ForeignEnum `+"`json:\"optionalForeignEnum\"`"+`
+}
+
+func TestAllTypesRequestFromPB(pb *testpb.TestAllTypesRequest) *TestAllTypesRequest {
+	if pb == nil {
+		return nil
+	}
+
+	var optionalForeignEnum *ForeignEnum
+	if pb.OptionalForeignEnum != nil {
+		v := ForeignEnum(*pb.OptionalForeignEnum)
+		optionalForeignEnum = &v
+	}
+	return &TestAllTypesRequest{
+		OptionalForeignEnum: optionalForeignEnum,
+		SingularForeignEnum: ForeignEnum(pb.SingularForeignEnum),
+	}
+}
+
+func TestAllTypesRequestToPB(orig *TestAllTypesRequest) *testpb.TestAllTypesRequest {
+	if orig == nil {
+		return nil
+	}
+
+	var optionalForeignEnum *testpb.ForeignEnum
+	if orig.OptionalForeignEnum != nil {
+		v := testpb.ForeignEnum(*orig.OptionalForeignEnum)
+		optionalForeignEnum = &v
+	}
+	return &testpb.TestAllTypesRequest{
+		OptionalForeignEnum: optionalForeignEnum,
+		SingularForeignEnum: testpb.ForeignEnum(orig.SingularForeignEnum),
+	}
+}
+`, content)
+	typeCheckDTO(t, pbSrc, content)
+}
+
+func TestGenerateDTOExclude(t *testing.T) {
+	setDefaults()
+	pbSrc := `package pb
+	type HelloRequest struct {
+		Address *Address
+	}
+	type HelloResponse struct {
+		Name string
+	}
+	type AuditInternalRequest struct {
+		Name string
+	}
+	type Address struct {
+		Street string
+	}`
+	tests := []struct {
+		name        string
+		exclude     []string
+		wantErr     string
+		wantStructs []string
+		notStructs  []string
+	}{
+		{
+			name:        "excluding top level structs by name and glob",
+			exclude:     []string{"HelloResponse", "*Internal*"},
+			wantStructs: []string{"Address", "HelloRequest"},
+			notStructs:  []string{"HelloResponse", "AuditInternalRequest"},
+		},
+		{
+			name:    "excluding a struct referenced by another struct is reported",
+			exclude: []string{"Address"},
+			wantErr: "struct Address is excluded but referenced by field HelloRequest.Address at test/pkg/grpc/pb/z_test.pb.go:3:3",
+		},
+		{
+			name:    "invalid pattern is reported",
+			exclude: []string{"[Hello"},
+			wantErr: "invalid exclude pattern: [Hello",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestDTOGenerator(pbSrc)
+			g.options.Exclude = tt.exclude
+			err := g.Generate()
+			if tt.wantErr != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tt.wantErr)
+				}
+				return
+			}
+
+			assert.NoError(t, err)
+			content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+			assertGeneratedInOrder(t, content, tt.wantStructs...)
+			for _, name := range tt.notStructs {
+				assert.NotContains(t, content, fmt.Sprintf("type %s struct", name))
+			}
+		})
+	}
+}
+
+func TestGenerateDTOImportAliases(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	import (
+		commonpb "example.com/common/pb"
+		"example.com/legacy/testpb"
+		"example.com/user/pb"
+		_ "example.com/side/effect"
+	)
+	type HelloRequest struct {
+		Name string
+	}`)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+
+	assert.Equal(t, map[string]string{
+		"test/pkg/grpc/pb":          "testpb",
+		"example.com/common/pb":     "commonpb",
+		"example.com/legacy/testpb": "testpb1",
+		"example.com/user/pb":       "pb",
+	}, g.importAliases)
+	assert.Equal(t, "helloservicepb", pbImportAlias("hello-Service"))
+}
+
+func TestGenerateDTOFallibleBindings(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		Name      string
+		Address   *Address
+		Addresses map[string]*Address
+		History   []*Address
+	}
+	type Address struct {
+		Street string
+	}`)
+	g.options.FallibleBindings = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import (
+	"fmt"
+	testpb "test/pkg/grpc/pb"
+)
+
+type Address struct {
+	Street string `+"`json:\"street\"`"+`
+}
+
+func AddressFromPB(pb *testpb.Address) (*Address, error) {
+	if pb == nil {
+		return nil, nil
+	}
+
+	return &Address{Street: pb.Street}, nil
+}
+
+func AddressToPB(orig *Address) (*testpb.Address, error) {
+	if orig == nil {
+		return nil, nil
+	}
+
+	return &testpb.Address{Street: orig.Street}, nil
+}
+
+type HelloRequest struct {
+	Name      string              `+"`json:\"name\"`"+`
+	Address   *Address            `+"`json:\"address\"`"+`
+	Addresses map[string]*Address `+"`json:\"addresses\"`"+`
+	History   []*Address          `+"`json:\"history\"`"+`
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) (*HelloRequest, error) {
+	if pb == nil {
+		return nil, nil
+	}
+
+	address, err := AddressFromPB(pb.Address)
+	if err != nil {
+		return nil, conversionError("HelloRequest", "Address", err)
+	}
+	m := make(map[string]*Address, len(pb.Addresses))
+	for k, v := range pb.Addresses {
+		e, err := AddressFromPB(v)
+		if err != nil {
+			return nil, conversionError("HelloRequest", "Addresses", err)
+		}
+		m[k] = e
+	}
+	aSlice := make([]*Address, 0, len(pb.History))
+	for _, v := range pb.History {
+		e, err := AddressFromPB(v)
+		if err != nil {
+			return nil, conversionError("HelloRequest", "History", err)
+		}
+		aSlice = append(aSlice, e)
+	}
+	return &HelloRequest{
+		Address:   address,
+		Addresses: m,
+		History:   aSlice,
+		Name:      pb.Name,
+	}, nil
+}
+
+func HelloRequestToPB(orig *HelloRequest) (*testpb.HelloRequest, error) {
+	if orig == nil {
+		return nil, nil
+	}
+
+	address, err := AddressToPB(orig.Address)
+	if err != nil {
+		return nil, conversionError("HelloRequest", "Address", err)
+	}
+	m := make(map[string]*testpb.Address, len(orig.Addresses))
+	for k, v := range orig.Addresses {
+		e, err := AddressToPB(v)
+		if err != nil {
+			return nil, conversionError("HelloRequest", "Addresses", err)
+		}
+		m[k] = e
+	}
+	aSlice := make([]*testpb.Address, 0, len(orig.History))
+	for _, v := range orig.History {
+		e, err := AddressToPB(v)
+		if err != nil {
+			return nil, conversionError("HelloRequest", "History", err)
+		}
+		aSlice = append(aSlice, e)
+	}
+	return &testpb.HelloRequest{
+		Address:   address,
+		Addresses: m,
+		History:   aSlice,
+		Name:      orig.Name,
+	}, nil
+}
+
+// ConversionError is the error of a binding failing to convert a field, Field being the path of the field in the Type dto, e.g. Address.Location.
+type ConversionError struct {
+	Field string
+	Type  string
+	Err   error
+}
+
+func (e *ConversionError) Error() string {
+	return fmt.Sprintf("converting %s.%s: %v", e.Type, e.Field, e.Err)
+}
+
+func (e *ConversionError) Unwrap() error {
+	return e.Err
+}
+
+// conversionError wraps the error converting a field of a dto, the field of a nested dto being prefixed with the field holding it.
+func conversionError(typeName string, field string, err error) error {
+	if nested, ok := err.(*ConversionError); ok {
+		return &ConversionError{
+			Err:   nested.Err,
+			Field: field + "." + nested.Field,
+			Type:  typeName,
+		}
+	}
+	return &ConversionError{
+		Err:   err,
+		Field: field,
+		Type:  typeName,
+	}
+}
+`, content)
+}
+
+func TestGenerateDTOAnnotateFieldNumbers(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		state         protoimpl.MessageState
+		Name          string ` + "`protobuf:\"bytes,1,opt,name=name,proto3\" json:\"name,omitempty\"`" + `
+		Tags          []string ` + "`protobuf:\"bytes,3,rep,name=tags,proto3\"`" + `
+		Untagged      int32
+	}`)
+	g.options.AnnotateFieldNumbers = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import testpb "test/pkg/grpc/pb"
+
+type HelloRequest struct {
+	Name     string   `+"`json:\"name\"`"+` // proto field 1
+	Tags     []string `+"`json:\"tags\"`"+` // proto field 3
+	Untagged int32    `+"`json:\"untagged\"`"+`
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	return &HelloRequest{
+		Name:     pb.Name,
+		Tags:     pb.Tags,
+		Untagged: pb.Untagged,
+	}
+}
+
+func HelloRequestToPB(orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.HelloRequest{
+		Name:     orig.Name,
+		Tags:     orig.Tags,
+		Untagged: orig.Untagged,
+	}
+}
+`, content)
+}
+
+func TestGenerateDTOHeaderMarker(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		Name string
+	}`)
+	g.options.Split = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+
+	marker := regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+	for _, file := range []string{g.dtoFileFullPath, g.dtoBindingsFileFullPath()} {
+		content, _ := g.fs.ReadFile(file)
+		assert.True(t, marker.MatchString(strings.SplitN(content, "\n", 2)[0]), "missing generated code marker in %s", file)
+	}
+}
+
+func TestGenerateDTOQualifiedFieldTypes(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	import (
+		commonpb "example.com/common/pb"
+		spb "google.golang.org/protobuf/types/known/structpb"
+	)
+	type HelloRequest struct {
+		Kind      commonpb.Kind
+		Meta      *commonpb.Metadata
+		History   []*commonpb.Metadata
+		Labels    map[string]*commonpb.Metadata
+		Address   *Address
+		Attributes *spb.Struct
+	}
+	type Address struct {
+		Street string
+	}`)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import (
+	commonpb "example.com/common/pb"
+	"fmt"
+	spb "google.golang.org/protobuf/types/known/structpb"
+	testpb "test/pkg/grpc/pb"
+)
+
+type Address struct {
+	Street string `+"`json:\"street\"`"+`
+}
+
+func AddressFromPB(pb *testpb.Address) *Address {
+	if pb == nil {
+		return nil
+	}
+
+	return &Address{Street: pb.Street}
+}
+
+func AddressToPB(orig *Address) *testpb.Address {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.Address{Street: orig.Street}
+}
+
+type HelloRequest struct {
+	Kind       commonpb.Kind                 `+"`json:\"kind\"`"+`
+	Meta       *commonpb.Metadata            `+"`json:\"meta\"`"+`
+	History    []*commonpb.Metadata          `+"`json:\"history\"`"+`
+	Labels     map[string]*commonpb.Metadata `+"`json:\"labels\"`"+`
+	Address    *Address                      `+"`json:\"address\"`"+`
+	Attributes map[string]interface{}        `+"`json:\"attributes\"`"+`
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	return &HelloRequest{
+		Address:    AddressFromPB(pb.Address),
+		Attributes: pb.Attributes.AsMap(),
+		History:    pb.History,
+		Kind:       pb.Kind,
+		Labels:     pb.Labels,
+		Meta:       pb.Meta,
+	}
+}
+
+func HelloRequestToPB(orig *HelloRequest) (*testpb.HelloRequest, error) {
+	if orig == nil {
+		return nil, nil
+	}
+
+	attributes, err := spb.NewStruct(orig.Attributes)
+	if err != nil {
+		return nil, conversionError("HelloRequest", "Attributes", err)
+	}
+	return &testpb.HelloRequest{
+		Address:    AddressToPB(orig.Address),
+		Attributes: attributes,
+		History:    orig.History,
+		Kind:       orig.Kind,
+		Labels:     orig.Labels,
+		Meta:       orig.Meta,
+	}, nil
+}
+
+// ConversionError is the error of a binding failing to convert a field, Field being the path of the field in the Type dto, e.g. Address.Location.
+type ConversionError struct {
+	Field string
+	Type  string
+	Err   error
+}
+
+func (e *ConversionError) Error() string {
+	return fmt.Sprintf("converting %s.%s: %v", e.Type, e.Field, e.Err)
+}
+
+func (e *ConversionError) Unwrap() error {
+	return e.Err
+}
+
+// conversionError wraps the error converting a field of a dto, the field of a nested dto being prefixed with the field holding it.
+func conversionError(typeName string, field string, err error) error {
+	if nested, ok := err.(*ConversionError); ok {
+		return &ConversionError{
+			Err:   nested.Err,
+			Field: field + "." + nested.Field,
+			Type:  typeName,
+		}
+	}
+	return &ConversionError{
+		Err:   err,
+		Field: field,
+		Type:  typeName,
+	}
+}
+`, content)
+}
+
+func TestGenerateDTOProto3ZeroOmit(t *testing.T) {
+	setDefaults()
+	pbSrc := `package pb
+	type Status int32
+	var Status_name = map[int32]string{0: "UNKNOWN", 1: "ACTIVE"}
+	type HelloRequest struct {
+		Name    string
+		Age     int32
+		Active  bool
+		Status  Status
+		Tags    []string
+		Address *Address
+	}
+	type Address struct {
+		Street string
+	}`
+	g := newTestDTOGenerator(pbSrc)
+	g.options.Proto3ZeroOmit = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import testpb "test/pkg/grpc/pb"
+
+type Address struct {
+	Street string `+"`json:\"street\"`"+`
+}
+
+func AddressFromPB(pb *testpb.Address) *Address {
+	if pb == nil {
+		return nil
+	}
+
+	return &Address{Street: pb.Street}
+}
+
+func AddressToPB(orig *Address) *testpb.Address {
+	if orig == nil {
+		return nil
+	}
+
+	res := &testpb.Address{}
+	// proto3 does not serialize scalar zero values, so zero scalar fields are left unset (--proto3-zero-omit)
+	if orig.Street != "" {
+		res.Street = orig.Street
+	}
+	return res
+}
+
+type Status int32
+
+type HelloRequest struct {
+	Name    string   `+"`json:\"name\"`"+`
+	Age     int32    `+"`json:\"age\"`"+`
+	Active  bool     `+"`json:\"active\"`"+`
+	Status  Status   `+"`json:\"status\"`"+`
+	Tags    []string `+"`json:\"tags\"`"+`
+	Address *Address `+"`json:\"address\"`"+`
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	return &HelloRequest{
+		Active:  pb.Active,
+		Address: AddressFromPB(pb.Address),
+		Age:     pb.Age,
+		Name:    pb.Name,
+		Status:  Status(pb.Status),
+		Tags:    pb.Tags,
+	}
+}
+
+func HelloRequestToPB(orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	res := &testpb.HelloRequest{
+		Address: AddressToPB(orig.Address),
+		Tags:    orig.Tags,
+	}
+	// proto3 does not serialize scalar zero values, so zero scalar fields are left unset (--proto3-zero-omit)
+	if orig.Name != "" {
+		res.Name = orig.Name
+	}
+	if orig.Age != 0 {
+		res.Age = orig.Age
+	}
+	if orig.Active {
+		res.Active = orig.Active
+	}
+	if orig.Status != 0 {
+		res.Status = testpb.Status(orig.Status)
+	}
+	return res
+}
+`, content)
+	typeCheckDTO(t, pbSrc, content)
+}
+
+func TestGenerateDTOPBPackageName(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package hellopb
+	type HelloRequest struct {
+		Name string
+	}`)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Contains(t, content, `import testpb "test/pkg/grpc/pb"`)
+	assert.Contains(t, content, "func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {")
+}
+
+func TestGenerateDTOCopySlices(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		Tags     []string
+		Scores   []int32
+		Payload  []byte
+		Labels   map[string]string
+		History  []*Address
+	}
+	type Address struct {
+		Street string
+	}`)
+	g.options.CopySlices = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import testpb "test/pkg/grpc/pb"
+
+type Address struct {
+	Street string `+"`json:\"street\"`"+`
+}
+
+func AddressFromPB(pb *testpb.Address) *Address {
+	if pb == nil {
+		return nil
+	}
+
+	return &Address{Street: pb.Street}
+}
+
+func AddressToPB(orig *Address) *testpb.Address {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.Address{Street: orig.Street}
+}
+
+type HelloRequest struct {
+	Tags    []string          `+"`json:\"tags\"`"+`
+	Scores  []int32           `+"`json:\"scores\"`"+`
+	Payload []byte            `+"`json:\"payload\"`"+`
+	Labels  map[string]string `+"`json:\"labels\"`"+`
+	History []*Address        `+"`json:\"history\"`"+`
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	aSlice := make([]*Address, 0, len(pb.History))
+	for _, v := range pb.History {
+		aSlice = append(aSlice, AddressFromPB(v))
+	}
+	return &HelloRequest{
+		History: aSlice,
+		Labels:  pb.Labels,
+		Payload: append([]byte(nil), pb.Payload...),
+		Scores:  append([]int32(nil), pb.Scores...),
+		Tags:    append([]string(nil), pb.Tags...),
+	}
+}
+
+func HelloRequestToPB(orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	aSlice := make([]*testpb.Address, 0, len(orig.History))
+	for _, v := range orig.History {
+		aSlice = append(aSlice, AddressToPB(v))
+	}
+	return &testpb.HelloRequest{
+		History: aSlice,
+		Labels:  orig.Labels,
+		Payload: append([]byte(nil), orig.Payload...),
+		Scores:  append([]int32(nil), orig.Scores...),
+		Tags:    append([]string(nil), orig.Tags...),
+	}
+}
+`, content)
+}
+
+func TestGenerateDTOGRPCBindings(t *testing.T) {
+	setDefaults()
+	pbSrc := `package pb
+	import structpb "google.golang.org/protobuf/types/known/structpb"
+	type FooRequest struct {
+		Name string
+	}
+	type FooResponse struct {
+		Meta *structpb.Struct
+	}
+	type BarRequest struct {
+		Id string
+	}`
+	g := newTestDTOGenerator(pbSrc)
+	g.options.GRPCBindings = true
+	g.fs.WriteFile("test/pkg/service/service.go", `package service
+	import "context"
+	type TestService interface {
+		Foo(ctx context.Context, name string) (meta map[string]interface{}, err error)
+		Bar(ctx context.Context, id string) (err error)
+	}`, true)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile("test/pkg/test/dto/z_test_grpc_bindings.go")
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import (
+	"context"
+	"fmt"
+	testpb "test/pkg/grpc/pb"
+)
+
+// DecodeFooRequest is a transport/grpc.DecodeRequestFunc that converts a gRPC request to its dto.
+func DecodeFooRequest(_ context.Context, r interface{}) (interface{}, error) {
+	v, ok := r.(*testpb.FooRequest)
+	if !ok {
+		return nil, fmt.Errorf("DecodeFooRequest: unexpected type %T", r)
+	}
+	return FooRequestFromPB(v), nil
+}
+
+// EncodeFooRequest is a transport/grpc.EncodeRequestFunc that converts a dto request to its gRPC request.
+func EncodeFooRequest(_ context.Context, r interface{}) (interface{}, error) {
+	v, ok := r.(*FooRequest)
+	if !ok {
+		return nil, fmt.Errorf("EncodeFooRequest: unexpected type %T", r)
+	}
+	return FooRequestToPB(v), nil
+}
+
+// EncodeFooResponse is a transport/grpc.EncodeResponseFunc that converts a dto response to its gRPC reply.
+func EncodeFooResponse(_ context.Context, r interface{}) (interface{}, error) {
+	v, ok := r.(*FooResponse)
+	if !ok {
+		return nil, fmt.Errorf("EncodeFooResponse: unexpected type %T", r)
+	}
+	return FooResponseToPB(v)
+}
+
+// DecodeFooResponse is a transport/grpc.DecodeResponseFunc that converts a gRPC reply to its dto.
+func DecodeFooResponse(_ context.Context, r interface{}) (interface{}, error) {
+	v, ok := r.(*testpb.FooResponse)
+	if !ok {
+		return nil, fmt.Errorf("DecodeFooResponse: unexpected type %T", r)
+	}
+	return FooResponseFromPB(v), nil
+}
+
+// DecodeBarRequest is a transport/grpc.DecodeRequestFunc that converts a gRPC request to its dto.
+func DecodeBarRequest(_ context.Context, r interface{}) (interface{}, error) {
+	v, ok := r.(*testpb.BarRequest)
+	if !ok {
+		return nil, fmt.Errorf("DecodeBarRequest: unexpected type %T", r)
+	}
+	return BarRequestFromPB(v), nil
+}
+
+// EncodeBarRequest is a transport/grpc.EncodeRequestFunc that converts a dto request to its gRPC request.
+func EncodeBarRequest(_ context.Context, r interface{}) (interface{}, error) {
+	v, ok := r.(*BarRequest)
+	if !ok {
+		return nil, fmt.Errorf("EncodeBarRequest: unexpected type %T", r)
+	}
+	return BarRequestToPB(v), nil
+}
+`, content)
+	dto, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	typeCheckDTOPackages(t, wellKnownStubs(pbSrc), dto, content)
+}
+
+func TestGenerateDTOMappingSpec(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	import timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	type HelloRequest struct {
+		Name      string
+		CreatedAt *timestamppb.Timestamp
+		Amount    int64
+		Address   *Address
+	}
+	type Address struct {
+		Street string
+	}`)
+	g.options.MappingSpec = "test/dto_mapping.yaml"
+	g.fs.WriteFile(g.options.MappingSpec, `
+imports:
+  time: time
+  timestamppb: google.golang.org/protobuf/types/known/timestamppb
+fields:
+  HelloRequest.CreatedAt:
+    type: time.Time
+    from_pb: $.AsTime()
+    to_pb: timestamppb.New($)
+  HelloRequest.Amount:
+    type: float64
+    from_pb: float64($) / 100
+    to_pb: int64($ * 100)
+  HelloRequest.Address:
+    type: string
+    from_pb: $.GetStreet()
+    to_pb: "&pb.Address{Street: $}"
+`, true)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import (
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	testpb "test/pkg/grpc/pb"
+	"time"
+)
+
+type HelloRequest struct {
+	Name      string    `+"`json:\"name\"`"+`
+	CreatedAt time.Time `+"`json:\"createdAt\"`"+`
+	Amount    float64   `+"`json:\"amount\"`"+`
+	Address   string    `+"`json:\"address\"`"+`
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	return &HelloRequest{
+		Address:   pb.Address.GetStreet(),
+		Amount:    float64(pb.Amount) / 100,
+		CreatedAt: pb.CreatedAt.AsTime(),
+		Name:      pb.Name,
+	}
+}
+
+func HelloRequestToPB(orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.HelloRequest{
+		Address:   &testpb.Address{Street: orig.Address},
+		Amount:    int64(orig.Amount * 100),
+		CreatedAt: timestamppb.New(orig.CreatedAt),
+		Name:      orig.Name,
+	}
+}
+`, content)
+}
+
+func TestGenerateDTOMappingSpecUnknownField(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		Name string
+	}`)
+	g.options.MappingSpec = "test/dto_mapping.yaml"
+	g.fs.WriteFile(g.options.MappingSpec, `
+fields:
+  HelloRequest.Nickname:
+    type: string
+`, true)
+
+	assert.EqualError(t, g.Generate(), "mapping spec field HelloRequest.Nickname refers to an unknown field of pb struct HelloRequest")
+}
+
+func TestGenerateDTOSkipsUnexportedFields(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		state         protoimpl.MessageState
+		sizeCache     protoimpl.SizeCache
+		unknownFields protoimpl.UnknownFields
+		Name          string
+		cachedHash    uint64
+		internal      *Address
+	}
+	type Address struct {
+		Street string
+	}`)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import testpb "test/pkg/grpc/pb"
+
+type HelloRequest struct {
+	Name string `+"`json:\"name\"`"+`
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	return &HelloRequest{Name: pb.Name}
+}
+
+func HelloRequestToPB(orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.HelloRequest{Name: orig.Name}
+}
+`, content)
+}
+
+func TestGenerateDTOHandWrittenFiles(t *testing.T) {
+	setDefaults()
+	pbSrc := `package pb
+	type HelloRequest struct {
+		Name string
+	}`
+	handWritten := "package dto\n\nfunc (h *HelloRequest) Validate() error { return nil }\n"
+
+	g := newTestDTOGenerator(pbSrc)
+	g.fs.WriteFile("test/pkg/test/dto/validate.go", handWritten, true)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	// regenerating overwrites the generated file and leaves the hand-written one untouched
+	g = newTestDTOGenerator(pbSrc)
+	g.fs.WriteFile("test/pkg/test/dto/validate.go", handWritten, true)
+	g.fs.WriteFile(g.dtoFileFullPath, "// Code generated by kit g dto. DO NOT EDIT.\npackage dto\n", true)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile("test/pkg/test/dto/validate.go")
+	assert.Equal(t, handWritten, content)
+
+	g = newTestDTOGenerator(pbSrc)
+	g.fs.WriteFile(g.dtoFileFullPath, handWritten, true)
+	assert.EqualError(t, g.Generate(), "refusing to overwrite test/pkg/test/dto/z_test_dto.go, it is not a generated file")
+
+	g = newTestDTOGenerator(pbSrc)
+	g.dtoFileFullPath = "test/pkg/test/dto/dto.go"
+	assert.EqualError(t, g.Generate(), "refusing to write test/pkg/test/dto/dto.go, generated file names must start with z_")
+}
+
+func TestGenerateDTOStableOrder(t *testing.T) {
+	setDefaults()
+	g1 := newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		Address *Address
+		Contact *Contact
+		Name    string
+	}
+	type Address struct {
+		Country *Country
+		Street  string
+	}
+	type Contact struct {
+		Address *Address
+		Next    *Contact
+	}
+	type Country struct {
+		Code string
+	}`)
+	g2 := newTestDTOGenerator(`package pb
+	type Country struct {
+		Code string
+	}
+	type Contact struct {
+		Next    *Contact
+		Address *Address
+	}
+	type HelloRequest struct {
+		Name    string
+		Contact *Contact
+		Address *Address
+	}
+	type Address struct {
+		Street  string
+		Country *Country
+	}`)
+	for _, g := range []*GenerateDTOFromProtoGo{g1, g2} {
+		if err := g.Generate(); err != nil {
+			t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+		}
+	}
+
+	content1, _ := g1.fs.ReadFile(g1.dtoFileFullPath)
+	content2, _ := g2.fs.ReadFile(g2.dtoFileFullPath)
+	assertGeneratedInOrder(t, content1, "Country", "Address", "Contact", "HelloRequest")
+	assertGeneratedInOrder(t, content2, "Country", "Address", "Contact", "HelloRequest")
+}
+
+func TestGenerateDTOAnyAsRaw(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	import anypb "google.golang.org/protobuf/types/known/anypb"
+	type HelloRequest struct {
+		Name    string
+		Details *anypb.Any
+		Extras  []*anypb.Any
+	}`)
+	g.options.AnyAsRaw = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import (
+	"encoding/json"
+	"fmt"
+	anypb "google.golang.org/protobuf/types/known/anypb"
+	testpb "test/pkg/grpc/pb"
+)
+
+type HelloRequest struct {
+	Name    string            `+"`json:\"name\"`"+`
+	Details json.RawMessage   `+"`json:\"details\"`"+`
+	Extras  []json.RawMessage `+"`json:\"extras\"`"+`
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	aSlice := make([]json.RawMessage, 0, len(pb.Extras))
+	for _, v := range pb.Extras {
+		aSlice = append(aSlice, anyToRawMessage(v))
+	}
+	return &HelloRequest{
+		Details: anyToRawMessage(pb.Details),
+		Extras:  aSlice,
+		Name:    pb.Name,
+	}
+}
+
+func HelloRequestToPB(orig *HelloRequest) (*testpb.HelloRequest, error) {
+	if orig == nil {
+		return nil, nil
+	}
+
+	details, err := rawMessageToAny(orig.Details)
+	if err != nil {
+		return nil, conversionError("HelloRequest", "Details", err)
+	}
+	aSlice := make([]*anypb.Any, 0, len(orig.Extras))
+	for _, v := range orig.Extras {
+		e, err := rawMessageToAny(v)
+		if err != nil {
+			return nil, conversionError("HelloRequest", "Extras", err)
+		}
+		aSlice = append(aSlice, e)
+	}
+	return &testpb.HelloRequest{
+		Details: details,
+		Extras:  aSlice,
+		Name:    orig.Name,
+	}, nil
+}
+
+// anyAsRaw is the json representation of a pb Any in dto, i.e. {"type_url":"...","value":"..."}
+// limitation: the payload is not decoded, value holds the base64 encoded protobuf bytes of the message,
+// so it is only readable by a client knowing the message type of type_url.
+type anyAsRaw struct {
+	TypeURL string `+"`json:\"type_url\"`"+`
+	Value   []byte `+"`json:\"value\"`"+`
+}
+
+// anyToRawMessage converts a pb Any to its json representation, see anyAsRaw.
+func anyToRawMessage(a *anypb.Any) json.RawMessage {
+	if a == nil {
+		return nil
+	}
+	raw, _ := json.Marshal(anyAsRaw{
+		TypeURL: a.TypeUrl,
+		Value:   a.Value,
+	})
+	return raw
+}
+
+// rawMessageToAny converts the json representation of a pb Any back to a pb Any, see anyAsRaw.
+func rawMessageToAny(raw json.RawMessage) (*anypb.Any, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var a anyAsRaw
+	if err := json.Unmarshal(raw, &a); err != nil {
+		return nil, err
+	}
+	return &anypb.Any{
+		TypeUrl: a.TypeURL,
+		Value:   a.Value,
+	}, nil
+}
+
+// ConversionError is the error of a binding failing to convert a field, Field being the path of the field in the Type dto, e.g. Address.Location.
+type ConversionError struct {
+	Field string
+	Type  string
+	Err   error
+}
+
+func (e *ConversionError) Error() string {
+	return fmt.Sprintf("converting %s.%s: %v", e.Type, e.Field, e.Err)
+}
+
+func (e *ConversionError) Unwrap() error {
+	return e.Err
+}
+
+// conversionError wraps the error converting a field of a dto, the field of a nested dto being prefixed with the field holding it.
+func conversionError(typeName string, field string, err error) error {
+	if nested, ok := err.(*ConversionError); ok {
+		return &ConversionError{
+			Err:   nested.Err,
+			Field: field + "." + nested.Field,
+			Type:  typeName,
+		}
+	}
+	return &ConversionError{
+		Err:   err,
+		Field: field,
+		Type:  typeName,
+	}
+}
+`, content)
+}
+
+func TestGenerateDTOWithRegistry(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		Name string
+	}
+	type HelloResponse struct {
+		Message string
+	}`)
+	g.options.WithRegistry = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import testpb "test/pkg/grpc/pb"
+
+type HelloRequest struct {
+	Name string `+"`json:\"name\"`"+`
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	return &HelloRequest{Name: pb.Name}
+}
+
+func HelloRequestToPB(orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.HelloRequest{Name: orig.Name}
+}
+
+type HelloResponse struct {
+	Message string `+"`json:\"message\"`"+`
+}
+
+func HelloResponseFromPB(pb *testpb.HelloResponse) *HelloResponse {
+	if pb == nil {
+		return nil
+	}
+
+	return &HelloResponse{Message: pb.Message}
+}
+
+func HelloResponseToPB(orig *HelloResponse) *testpb.HelloResponse {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.HelloResponse{Message: orig.Message}
+}
+
+// FromPBRegistry maps the name of every pb struct having a dto to its FromPB binding.
+// A pb message which is not of the registered type is converted to a nil dto.
+var FromPBRegistry = map[string]func(interface{}) interface{}{
+	"HelloRequest": func(pbMsg interface{}) interface{} {
+		v, _ := pbMsg.(*testpb.HelloRequest)
+		return HelloRequestFromPB(v)
+	},
+	"HelloResponse": func(pbMsg interface{}) interface{} {
+		v, _ := pbMsg.(*testpb.HelloResponse)
+		return HelloResponseFromPB(v)
+	},
+}
+`, content)
+}
+
+func TestGenerateDTOFromPBRangeSource(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type Entry struct {
+		Value string
+	}
+	type HelloRequest struct {
+		Item    []*Entry
+		Lookup  map[string]*Entry
+		History []*Entry
+	}`)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+
+	file, err := goparser.ParseFile(token.NewFileSet(), "z_test_dto.go", content, 0)
+	if err != nil {
+		t.Fatalf("generated dto does not parse: %v", err)
+	}
+	// every FromPB binding must range over the fields of its pb param, e.g. range pb.Item
+	ranged := map[string]bool{}
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || !strings.HasSuffix(fn.Name.Name, "FromPB") {
+			continue
+		}
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			rs, ok := n.(*ast.RangeStmt)
+			if !ok {
+				return true
+			}
+			sel, ok := rs.X.(*ast.SelectorExpr)
+			if !ok {
+				t.Errorf("%s ranges over %T, want a pb field selector", fn.Name.Name, rs.X)
+				return true
+			}
+			if id, ok := sel.X.(*ast.Ident); !ok || id.Name != "pb" {
+				t.Errorf("%s ranges over %s of %v, want pb.%s", fn.Name.Name, sel.Sel.Name, sel.X, sel.Sel.Name)
+			}
+			ranged[sel.Sel.Name] = true
+			return true
+		})
+	}
+	assert.Equal(t, map[string]bool{"Item": true, "Lookup": true, "History": true}, ranged)
+	assert.Contains(t, content, "for _, v := range pb.Item {")
+	assert.Contains(t, content, "for k, v := range pb.Lookup {")
+}
+
+func TestNewGenerateDTOFromProtoModulePath(t *testing.T) {
+	setDefaults()
+	defer fs.NewDefaultFs("")
+	tests := []struct {
+		name          string
+		goModPath     string
+		goMod         string
+		pbImportPath  string
+		dtoImportPath string
+		warnings      []string
+	}{
+		{
+			name:          "no go.mod",
+			pbImportPath:  "hello/pkg/grpc/pb",
+			dtoImportPath: "hello/pkg/hello/dto",
+			warnings: []string{
+				"could not read the go.mod of hello, the generated imports are relative, err: no module path found in hello/go.mod or go.mod",
+			},
+		},
+		{
+			name:          "go.mod of the service",
+			goModPath:     "hello/go.mod",
+			goMod:         "module github.com/acme/hello\n\ngo 1.12\n",
+			pbImportPath:  "github.com/acme/hello/pkg/grpc/pb",
+			dtoImportPath: "github.com/acme/hello/pkg/hello/dto",
+		},
+		{
+			name:          "go.mod of the project",
+			goModPath:     "go.mod",
+			goMod:         "module github.com/acme/services\n\ngo 1.12\n",
+			pbImportPath:  "github.com/acme/services/hello/pkg/grpc/pb",
+			dtoImportPath: "github.com/acme/services/hello/pkg/hello/dto",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := fs.NewDefaultFs("")
+			if tt.goModPath != "" {
+				f.WriteFile(tt.goModPath, tt.goMod, true)
+			}
+			g := NewGenerateDTOFromProto("hello", "", DTOOptions{}).(*GenerateDTOFromProtoGo)
+			assert.Equal(t, tt.pbImportPath, g.pbPackagePath)
+			assert.Equal(t, tt.dtoImportPath, g.dtoImportPath)
+			assert.Equal(t, "hello/pkg/hello/dto", g.dtoPackagePath)
+			assert.ElementsMatch(t, tt.warnings, g.Result().Warnings)
+		})
+	}
+}
+
+func TestGenerateDTOModuleImports(t *testing.T) {
+	setDefaults()
+	defer fs.NewDefaultFs("")
+	f := fs.NewDefaultFs("")
+	f.WriteFile("hello/go.mod", "module github.com/acme/hello\n", true)
+	f.MkdirAll("hello/pkg/grpc/pb")
+	f.WriteFile("hello/pkg/grpc/pb/z_hello.pb.go", `package pb
+	type HelloRequest struct {
+		Name string
+	}`, true)
+
+	g := NewGenerateDTOFromProto("hello", "", DTOOptions{}).(*GenerateDTOFromProtoGo)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := f.ReadFile("hello/pkg/hello/dto/z_hello_dto.go")
+	assert.Contains(t, content, `hellopb "github.com/acme/hello/pkg/grpc/pb"`)
+	assert.Contains(t, content, "func HelloRequestFromPB(pb *hellopb.HelloRequest) *HelloRequest {")
+}
+
+func TestGenerateDTOLegacyMapEntries(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type Address struct {
+		City string
+	}
+	type HelloRequest_LabelsEntry struct {
+		Key   string
+		Value string
+	}
+	type HelloRequest_AddressesEntry struct {
+		Key   string
+		Value *Address
+	}
+	type HelloRequest struct {
+		Name   string
+		Labels []*HelloRequest_LabelsEntry
+	}
+	type HelloResponse struct {
+		Addresses []*HelloRequest_AddressesEntry
+	}`)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import testpb "test/pkg/grpc/pb"
+
+type Address struct {
+	City string `+"`json:\"city\"`"+`
+}
+
+func AddressFromPB(pb *testpb.Address) *Address {
+	if pb == nil {
+		return nil
+	}
+
+	return &Address{City: pb.City}
+}
+
+func AddressToPB(orig *Address) *testpb.Address {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.Address{City: orig.City}
+}
+
+type HelloRequest struct {
+	Name   string            `+"`json:\"name\"`"+`
+	Labels map[string]string `+"`json:\"labels\"`"+`
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	m := make(map[string]string, len(pb.Labels))
+	for _, entry := range pb.Labels {
+		m[entry.Key] = entry.Value
+	}
+	return &HelloRequest{
+		Labels: m,
+		Name:   pb.Name,
+	}
+}
+
+func HelloRequestToPB(orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	entries := make([]*testpb.HelloRequest_LabelsEntry, 0, len(orig.Labels))
+	for k, v := range orig.Labels {
+		entries = append(entries, &testpb.HelloRequest_LabelsEntry{
+			Key:   k,
+			Value: v,
+		})
+	}
+	return &testpb.HelloRequest{
+		Labels: entries,
+		Name:   orig.Name,
+	}
+}
+
+type HelloResponse struct {
+	Addresses map[string]*Address `+"`json:\"addresses\"`"+`
+}
+
+func HelloResponseFromPB(pb *testpb.HelloResponse) *HelloResponse {
+	if pb == nil {
+		return nil
+	}
+
+	m := make(map[string]*Address, len(pb.Addresses))
+	for _, entry := range pb.Addresses {
+		m[entry.Key] = AddressFromPB(entry.Value)
+	}
+	return &HelloResponse{Addresses: m}
+}
+
+func HelloResponseToPB(orig *HelloResponse) *testpb.HelloResponse {
+	if orig == nil {
+		return nil
+	}
+
+	entries := make([]*testpb.HelloRequest_AddressesEntry, 0, len(orig.Addresses))
+	for k, v := range orig.Addresses {
+		entries = append(entries, &testpb.HelloRequest_AddressesEntry{
+			Key:   k,
+			Value: AddressToPB(v),
+		})
+	}
+	return &testpb.HelloResponse{Addresses: entries}
+}
+`, content)
+}
+
+func TestGenerateDTOValueNested(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type Address struct {
+		City string
+	}
+	type HelloRequest struct {
+		Name      string
+		Address   *Address
+		History   []*Address
+		Forwarded *HelloRequest
+	}`)
+	g.options.ValueNested = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import testpb "test/pkg/grpc/pb"
+
+type Address struct {
+	City string `+"`json:\"city\"`"+`
+}
+
+func AddressFromPB(pb *testpb.Address) *Address {
+	if pb == nil {
+		return nil
+	}
+
+	return &Address{City: pb.City}
+}
+
+func AddressToPB(orig *Address) *testpb.Address {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.Address{City: orig.City}
+}
+
+type HelloRequest struct {
+	Name      string        `+"`json:\"name\"`"+`
+	Address   Address       `+"`json:\"address\"`"+`
+	History   []*Address    `+"`json:\"history\"`"+`
+	Forwarded *HelloRequest `+"`json:\"forwarded\"`"+`
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	var address Address
+	if pb.Address != nil {
+		address = *AddressFromPB(pb.Address)
+	}
+	aSlice := make([]*Address, 0, len(pb.History))
+	for _, v := range pb.History {
+		aSlice = append(aSlice, AddressFromPB(v))
+	}
+	return &HelloRequest{
+		Address:   address,
+		Forwarded: HelloRequestFromPB(pb.Forwarded),
+		History:   aSlice,
+		Name:      pb.Name,
+	}
+}
+
+func HelloRequestToPB(orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	aSlice := make([]*testpb.Address, 0, len(orig.History))
+	for _, v := range orig.History {
+		aSlice = append(aSlice, AddressToPB(v))
+	}
+	return &testpb.HelloRequest{
+		Address:   AddressToPB(&orig.Address),
+		Forwarded: HelloRequestToPB(orig.Forwarded),
+		History:   aSlice,
+		Name:      orig.Name,
+	}
+}
+`, content)
+}
+
+func TestGenerateDTOWithDriftCheck(t *testing.T) {
+	setDefaults()
+	pbSrc := `package pb
+	type Address struct {
+		City string
+	}
+	type HelloRequest struct {
+		Name    string
+		Address *Address
+	}`
+	g := newTestDTOGenerator(pbSrc)
+	g.options.WithDriftCheck = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile("test/pkg/test/dto/z_test_dto_drift_test.go")
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import (
+	"reflect"
+	testpb "test/pkg/grpc/pb"
+	"testing"
+)
+
+// TestDTODrift fails when a pb struct has a field its dto does not cover, i.e. when the dto need to be regenerated.
+func TestDTODrift(t *testing.T) {
+	dtoTypes := [][2]reflect.Type{
+		{reflect.TypeOf((*testpb.Address)(nil)).Elem(), reflect.TypeOf((*Address)(nil)).Elem()},
+		{reflect.TypeOf((*testpb.HelloRequest)(nil)).Elem(), reflect.TypeOf((*HelloRequest)(nil)).Elem()},
+	}
+	for _, types := range dtoTypes {
+		pbType, dtoType := types[0], types[1]
+		for i := 0; i < pbType.NumField(); i++ {
+			field := pbType.Field(i)
+			// unexported fields, e.g. the pb native fields, are not part of the dto
+			if field.PkgPath != "" {
+				continue
+			}
+			if _, ok := dtoType.FieldByName(field.Name); !ok {
+				t.Errorf("pb field %s.%s is not covered by dto %s, the dto need to be regenerated", pbType.Name(), field.Name, dtoType.Name())
+			}
+		}
+	}
+}
+`, content)
+	dto, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	typeCheckDTO(t, pbSrc, dto, content)
+}
+
+func TestGenerateDTOInt64AsString(t *testing.T) {
+	setDefaults()
+	pbSrc := `package pb
+	type HelloRequest struct {
+		Id       int64
+		Checksum uint64
+		Count    int32
+		Offsets  []int64
+		Limit    *int64
+	}`
+	g := newTestDTOGenerator(pbSrc)
+	g.options.Int64AsString = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import (
+	"fmt"
+	"strconv"
+	testpb "test/pkg/grpc/pb"
+)
+
+type HelloRequest struct {
+	Id       string   `+"`json:\"id\"`"+`
+	Checksum string   `+"`json:\"checksum\"`"+`
+	Count    int32    `+"`json:\"count\"`"+`
+	Offsets  []string `+"`json:\"offsets\"`"+`
+	Limit    *string  `+"`json:\"limit\"`"+`
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	aSlice := make([]string, 0, len(pb.Offsets))
+	for _, v := range pb.Offsets {
+		aSlice = append(aSlice, strconv.FormatInt(v, 10))
+	}
+	return &HelloRequest{
+		Checksum: strconv.FormatUint(pb.Checksum, 10),
+		Count:    pb.Count,
+		Id:       strconv.FormatInt(pb.Id, 10),
+		Limit:    optionalInt64ToString(pb.Limit),
+		Offsets:  aSlice,
+	}
+}
+
+func HelloRequestToPB(orig *HelloRequest) (*testpb.HelloRequest, error) {
+	if orig == nil {
+		return nil, nil
+	}
+
+	id, err := int64FromString(orig.Id)
+	if err != nil {
+		return nil, conversionError("HelloRequest", "Id", err)
+	}
+	checksum, err := uint64FromString(orig.Checksum)
+	if err != nil {
+		return nil, conversionError("HelloRequest", "Checksum", err)
+	}
+	aSlice := make([]int64, 0, len(orig.Offsets))
+	for _, v := range orig.Offsets {
+		e, err := int64FromString(v)
+		if err != nil {
+			return nil, conversionError("HelloRequest", "Offsets", err)
+		}
+		aSlice = append(aSlice, e)
+	}
+	limit, err := optionalInt64FromString(orig.Limit)
+	if err != nil {
+		return nil, conversionError("HelloRequest", "Limit", err)
+	}
+	return &testpb.HelloRequest{
+		Checksum: checksum,
+		Count:    orig.Count,
+		Id:       id,
+		Limit:    limit,
+		Offsets:  aSlice,
+	}, nil
+}
+
+// int64FromString parses the int64 of a dto field, the empty string is zero.
+func int64FromString(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+// optionalInt64ToString converts an optional pb int64 to dto, nil stays nil.
+func optionalInt64ToString(v *int64) *string {
+	if v == nil {
+		return nil
+	}
+	s := strconv.FormatInt(*v, 10)
+	return &s
+}
+
+// optionalInt64FromString parses an optional int64 of a dto field, nil stays nil.
+func optionalInt64FromString(s *string) (*int64, error) {
+	if s == nil {
+		return nil, nil
+	}
+	v, err := int64FromString(*s)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// uint64FromString parses the uint64 of a dto field, the empty string is zero.
+func uint64FromString(s string) (uint64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
+
+// ConversionError is the error of a binding failing to convert a field, Field being the path of the field in the Type dto, e.g. Address.Location.
+type ConversionError struct {
+	Field string
+	Type  string
+	Err   error
+}
+
+func (e *ConversionError) Error() string {
+	return fmt.Sprintf("converting %s.%s: %v", e.Type, e.Field, e.Err)
+}
+
+func (e *ConversionError) Unwrap() error {
+	return e.Err
+}
+
+// conversionError wraps the error converting a field of a dto, the field of a nested dto being prefixed with the field holding it.
+func conversionError(typeName string, field string, err error) error {
+	if nested, ok := err.(*ConversionError); ok {
+		return &ConversionError{
+			Err:   nested.Err,
+			Field: field + "." + nested.Field,
+			Type:  typeName,
+		}
+	}
+	return &ConversionError{
+		Err:   err,
+		Field: field,
+		Type:  typeName,
+	}
+}
+`, content)
+	typeCheckDTO(t, pbSrc, content)
+
+	// an optional uint64 alone still generates the parser of its values
+	g = newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		Cursor *uint64
+	}`)
+	g.options.Int64AsString = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ = g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Contains(t, content, "Cursor *string `json:\"cursor\"`")
+	assert.Contains(t, content, "func uint64FromString(s string) (uint64, error) {")
+	assert.Contains(t, content, `func optionalUint64ToString(v *uint64) *string {
+	if v == nil {
+		return nil
+	}
+	s := strconv.FormatUint(*v, 10)
+	return &s
+}`)
+	assert.NotContains(t, content, "int64FromString(s string) (int64, error)")
+	typeCheckDTO(t, `package pb
+	type HelloRequest struct {
+		Cursor *uint64
+	}`, content)
+}
+
+func TestGenerateDTOJSONDirective(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		// Name of the user
+		// @json:user_name,omitempty
+		Name string
+		// @json:
+		Age int32
+		Email string // @json:mail
+	}`)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import testpb "test/pkg/grpc/pb"
+
+type HelloRequest struct {
+	Name  string `+"`json:\"user_name,omitempty\"`"+`
+	Age   int32  `+"`json:\"age\"`"+`
+	Email string `+"`json:\"mail\"`"+`
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	return &HelloRequest{
+		Age:   pb.Age,
+		Email: pb.Email,
+		Name:  pb.Name,
+	}
+}
+
+func HelloRequestToPB(orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.HelloRequest{
+		Age:   orig.Age,
+		Email: orig.Email,
+		Name:  orig.Name,
+	}
+}
+`, content)
+}
+
+func TestGenerateDTOSkipsUnchangedFiles(t *testing.T) {
+	setDefaults()
+	pbSrc := `package pb
+	type HelloRequest struct {
+		Name string
+	}`
+	g := newTestDTOGenerator(pbSrc)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	info, _ := g.fs.Fs.Stat(g.dtoFileFullPath)
+	modTime := info.ModTime()
+
+	// regenerate from the same pb.go with a new generator, as kit g dto --watch does
+	regenerated := newTestDTOGenerator(pbSrc)
+	regenerated.fs = g.fs
+	if err := regenerated.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	info, _ = g.fs.Fs.Stat(g.dtoFileFullPath)
+	assert.Equal(t, modTime, info.ModTime())
+}
+
+func TestGenerateDTOSameNameAcrossPackages(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	import (
+		commonpb "example.com/common/pb"
+		userpb "example.com/user/pb"
+	)
+	type Metadata struct {
+		Version string
+	}
+	type HelloRequest struct {
+		Common   *commonpb.Metadata
+		User     *userpb.Metadata
+		Metadata *Metadata
+	}`)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import (
+	commonpb "example.com/common/pb"
+	userpb "example.com/user/pb"
+	testpb "test/pkg/grpc/pb"
+)
+
+type Metadata struct {
+	Version string `+"`json:\"version\"`"+`
+}
+
+func MetadataFromPB(pb *testpb.Metadata) *Metadata {
+	if pb == nil {
+		return nil
+	}
+
+	return &Metadata{Version: pb.Version}
+}
+
+func MetadataToPB(orig *Metadata) *testpb.Metadata {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.Metadata{Version: orig.Version}
+}
+
+type HelloRequest struct {
+	Common   *commonpb.Metadata `+"`json:\"common\"`"+`
+	User     *userpb.Metadata   `+"`json:\"user\"`"+`
+	Metadata *Metadata          `+"`json:\"metadata\"`"+`
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	return &HelloRequest{
+		Common:   pb.Common,
+		Metadata: MetadataFromPB(pb.Metadata),
+		User:     pb.User,
+	}
+}
+
+func HelloRequestToPB(orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.HelloRequest{
+		Common:   orig.Common,
+		Metadata: MetadataToPB(orig.Metadata),
+		User:     orig.User,
+	}
+}
+`, content)
+}
+
+func TestGenerateDTOFollowImports(t *testing.T) {
+	setDefaults()
+	pbSrc := `package pb
+	import (
+		commonpb "common/pkg/grpc/pb"
+		userpb "user/pkg/grpc/pb"
+	)
+	type Metadata struct {
+		Version string
+	}
+	type HelloRequest struct {
+		Common   *commonpb.Metadata
+		User     *userpb.Metadata
+		Metadata *Metadata
+		Tags     []*commonpb.Tag
+	}`
+	commonSrc := `package pb
+	type Kind int32
+	type Tag struct {
+		Name string
+	}
+	type Metadata struct {
+		Kind  Kind
+		Tags  map[string]*Tag
+		Owner *User
+	}
+	type User struct {
+		Name string
+	}`
+	userSrc := `package pb
+	import (
+		commonpb "common/pkg/grpc/pb"
+		labelpb "label/pkg/grpc/pb"
+	)
+	type Metadata struct {
+		Owner  string
+		Tag    *commonpb.Tag
+		Labels []*labelpb.Label
+	}`
+	labelSrc := `package pb
+	type Label struct {
+		Key string
+	}`
+	g := newTestDTOGenerator(pbSrc)
+	g.fs.WriteFile("common/pkg/grpc/pb/z_common.pb.go", commonSrc, true)
+	g.fs.WriteFile("user/pkg/grpc/pb/z_user.pb.go", userSrc, true)
+	g.fs.WriteFile("label/pkg/grpc/pb/z_label.pb.go", labelSrc, true)
+	g.options.FollowImports = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+
+	// the Metadata of pb.go keeps its name, the imported ones are named after their service
+	for _, decl := range []string{
+		"type Metadata struct",
+		"func MetadataFromPB(pb *testpb.Metadata) *Metadata",
+		"type CommonMetadata struct",
+		"func CommonMetadataFromPB(pb *commonpb.Metadata) *CommonMetadata",
+		"func CommonMetadataToPB(orig *CommonMetadata) *commonpb.Metadata",
+		"type UserMetadata struct",
+		"func UserMetadataFromPB(pb *userpb.Metadata) *UserMetadata",
+		"func UserMetadataToPB(orig *UserMetadata) *userpb.Metadata",
+		"type Tag struct",
+		"func TagFromPB(pb *commonpb.Tag) *Tag",
+		"type Label struct",
+		"func LabelFromPB(pb *labelpb.Label) *Label",
+	} {
+		assert.Equal(t, 1, strings.Count(content, decl), decl)
+	}
+	assert.Contains(t, content, `type HelloRequest struct {
+	Common   *CommonMetadata `+"`json:\"common\"`"+`
+	User     *UserMetadata   `+"`json:\"user\"`"+`
+	Metadata *Metadata       `+"`json:\"metadata\"`"+`
+	Tags     []*Tag          `+"`json:\"tags\"`"+`
+}`)
+	assert.Contains(t, content, `type CommonMetadata struct {
+	Kind  commonpb.Kind   `+"`json:\"kind\"`"+`
+	Tags  map[string]*Tag `+"`json:\"tags\"`"+`
+	Owner *User           `+"`json:\"owner\"`"+`
+}`)
+	assert.Contains(t, content, `	return &HelloRequest{
+		Common:   CommonMetadataFromPB(pb.Common),
+		Metadata: MetadataFromPB(pb.Metadata),
+		Tags:     aSlice,
+		User:     UserMetadataFromPB(pb.User),
+	}`)
+	typeCheckDTOPackages(t, map[string]string{
+		"test/pkg/grpc/pb":   pbSrc,
+		"common/pkg/grpc/pb": commonSrc,
+		"user/pkg/grpc/pb":   userSrc,
+		"label/pkg/grpc/pb":  labelSrc,
+	}, content)
+}
+
+func TestGenerateDTOFollowImportsCollision(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	import commonpb "common/pkg/grpc/pb"
+	type CommonMetadata struct {
+		Version string
+	}
+	type Metadata struct {
+		Version string
+	}
+	type HelloRequest struct {
+		Common   *commonpb.Metadata
+		Metadata *Metadata
+		Other    *CommonMetadata
+	}`)
+	g.fs.WriteFile("common/pkg/grpc/pb/z_common.pb.go", `package pb
+	type Metadata struct {
+		Version string
+	}`, true)
+	g.options.FollowImports = true
+	err := g.Generate()
+	assert.EqualError(t, err, "cannot name the dto of CommonMetadata and commonpb.Metadata apart, both are named CommonMetadata")
+}
+
+func TestGenerateDTOWithBenchmarks(t *testing.T) {
+	setDefaults()
+	pbSrc := `package pb
+	type Address struct {
+		City string
+	}
+	type HelloRequest struct {
+		Name    string
+		Address *Address
+	}`
+	g := newTestDTOGenerator(pbSrc)
+	g.options.WithBenchmarks = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile("test/pkg/test/dto/z_test_dto_bench_test.go")
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import (
+	testpb "test/pkg/grpc/pb"
+	"testing"
+)
+
+func BenchmarkAddressFromPB(b *testing.B) {
+	sample := &testpb.Address{}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		AddressFromPB(sample)
+	}
+}
+
+func BenchmarkAddressToPB(b *testing.B) {
+	sample := &Address{}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		AddressToPB(sample)
+	}
+}
+
+func BenchmarkHelloRequestFromPB(b *testing.B) {
+	sample := &testpb.HelloRequest{}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		HelloRequestFromPB(sample)
+	}
+}
+
+func BenchmarkHelloRequestToPB(b *testing.B) {
+	sample := &HelloRequest{}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		HelloRequestToPB(sample)
+	}
+}
+`, content)
+	dto, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	typeCheckDTO(t, pbSrc, dto, content)
+}
+
+func TestGenerateDTODuration(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	import durationpb "google.golang.org/protobuf/types/known/durationpb"
+	type HelloRequest struct {
+		Timeout  *durationpb.Duration
+		Backoffs []*durationpb.Duration
+	}`)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import (
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	testpb "test/pkg/grpc/pb"
+	"time"
+)
+
+type HelloRequest struct {
+	Timeout  time.Duration   `+"`json:\"timeout\"`"+`
+	Backoffs []time.Duration `+"`json:\"backoffs\"`"+`
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	aSlice := make([]time.Duration, 0, len(pb.Backoffs))
+	for _, v := range pb.Backoffs {
+		aSlice = append(aSlice, v.AsDuration())
+	}
+	return &HelloRequest{
+		Backoffs: aSlice,
+		Timeout:  pb.Timeout.AsDuration(),
+	}
+}
+
+func HelloRequestToPB(orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	aSlice := make([]*durationpb.Duration, 0, len(orig.Backoffs))
+	for _, v := range orig.Backoffs {
+		aSlice = append(aSlice, durationpb.New(v))
+	}
+	return &testpb.HelloRequest{
+		Backoffs: aSlice,
+		Timeout:  durationpb.New(orig.Timeout),
+	}
+}
+`, content)
+}
+
+func TestGenerateDTOWrappers(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	import wrapperspb "google.golang.org/protobuf/types/known/wrapperspb"
+	type HelloRequest struct {
+		Name   *wrapperspb.StringValue
+		Active *wrapperspb.BoolValue
+	}`)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import (
+	wrapperspb "google.golang.org/protobuf/types/known/wrapperspb"
+	testpb "test/pkg/grpc/pb"
+)
+
+type HelloRequest struct {
+	Name   *string `+"`json:\"name\"`"+`
+	Active *bool   `+"`json:\"active\"`"+`
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	return &HelloRequest{
+		Active: boolValueFromPB(pb.Active),
+		Name:   stringValueFromPB(pb.Name),
+	}
+}
+
+func HelloRequestToPB(orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.HelloRequest{
+		Active: boolValueToPB(orig.Active),
+		Name:   stringValueToPB(orig.Name),
+	}
+}
+
+// boolValueFromPB converts an optional pb bool to dto, nil stays nil.
+func boolValueFromPB(v *wrapperspb.BoolValue) *bool {
+	if v == nil {
+		return nil
+	}
+	value := v.GetValue()
+	return &value
+}
+
+// boolValueToPB converts an optional dto bool to pb, nil stays nil.
+func boolValueToPB(v *bool) *wrapperspb.BoolValue {
+	if v == nil {
+		return nil
+	}
+	return wrapperspb.Bool(*v)
+}
+
+// stringValueFromPB converts an optional pb string to dto, nil stays nil.
+func stringValueFromPB(v *wrapperspb.StringValue) *string {
+	if v == nil {
+		return nil
+	}
+	value := v.GetValue()
+	return &value
+}
+
+// stringValueToPB converts an optional dto string to pb, nil stays nil.
+func stringValueToPB(v *string) *wrapperspb.StringValue {
+	if v == nil {
+		return nil
+	}
+	return wrapperspb.String(*v)
+}
+`, content)
+}
+
+func TestGenerateDTOFormatter(t *testing.T) {
+	setDefaults()
+	pbSrc := `package pb
+	type HelloRequest struct {
+		Name string
+	}`
+	unformatted := newTestDTOGenerator(pbSrc)
+	if err := unformatted.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	expected, _ := unformatted.fs.ReadFile(unformatted.dtoFileFullPath)
+
+	// the formatter is given the source on stdin, cat leaves it as is
+	g := newTestDTOGenerator(pbSrc)
+	g.options.Formatter = "cat"
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, expected, content)
+
+	// a formatter not found on PATH falls back to gofmt
+	g = newTestDTOGenerator(pbSrc)
+	g.options.Formatter = "kit-missing-formatter -w"
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ = g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, expected, content)
+
+	g = newTestDTOGenerator(pbSrc)
+	g.options.Formatter = "false"
+	assert.Error(t, g.Generate())
+}
+
+func TestGenerateDTOJSONSchema(t *testing.T) {
+	setDefaults()
+	pbSrc := `package pb
+	type Address struct {
+		City string
+	}
+	type HelloRequest struct {
+		Name   string
+		Avatar []byte
+		Home   *Address
+		Others []*Address
+		Scores map[string]float64
+	}`
+	g := newTestDTOGenerator(strings.Replace(pbSrc, "Scores map[string]float64", "", 1))
+	g.options.JSONSchema = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+
+	// the generated schema is overwritten once pb.go changes
+	g.fs.WriteFile(g.protoGoFileFullPath, pbSrc, true)
+	regenerated := newTestDTOGenerator(pbSrc)
+	regenerated.fs = g.fs
+	regenerated.options.JSONSchema = true
+	if err := regenerated.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile("test/pkg/test/dto/z_test_dto.schema.json")
+	assert.Equal(t, `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "$comment": "Code generated by kit g dto. DO NOT EDIT.",
+  "definitions": {
+    "Address": {
+      "type": "object",
+      "properties": {
+        "city": {
+          "type": "string"
+        }
+      },
+      "required": [
+        "city"
+      ]
+    },
+    "HelloRequest": {
+      "type": "object",
+      "properties": {
+        "avatar": {
+          "type": [
+            "string",
+            "null"
+          ],
+          "contentEncoding": "base64"
+        },
+        "home": {
+          "anyOf": [
+            {
+              "$ref": "#/definitions/Address"
+            },
+            {
+              "type": "null"
+            }
+          ]
+        },
+        "name": {
+          "type": "string"
+        },
+        "others": {
+          "type": [
+            "array",
+            "null"
+          ],
+          "items": {
+            "$ref": "#/definitions/Address"
+          }
+        },
+        "scores": {
+          "type": [
+            "object",
+            "null"
+          ],
+          "additionalProperties": {
+            "type": "number",
+            "format": "double"
+          }
+        }
+      },
+      "required": [
+        "name"
+      ]
+    }
+  }
+}
+`, content)
+}
+
+func TestGenerateDTOJSONSchemaValidatesNilFields(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type Address struct {
+		City string
+	}
+	type HelloRequest struct {
+		Name     string
+		Nickname *string
+		Avatar   []byte
+		Home     *Address
+		Others   []*Address
+		Scores   map[string]float64
+	}`)
+	g.options.JSONSchema = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile("test/pkg/test/dto/z_test_dto.schema.json")
+	var schema map[string]interface{}
+	if err := json.Unmarshal([]byte(content), &schema); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	// the fields and json tags of the generated dto
+	type Address struct {
+		City string `json:"city"`
+	}
+	type HelloRequest struct {
+		Name     string             `json:"name"`
+		Nickname *string            `json:"nickname"`
+		Avatar   []byte             `json:"avatar"`
+		Home     *Address           `json:"home"`
+		Others   []*Address         `json:"others"`
+		Scores   map[string]float64 `json:"scores"`
+	}
+	nickname := "bob"
+	for _, dto := range []HelloRequest{
+		{Name: "alice"},
+		{
+			Name:     "bob",
+			Nickname: &nickname,
+			Avatar:   []byte{1},
+			Home:     &Address{City: "Paris"},
+			Others:   []*Address{{City: "Rome"}},
+			Scores:   map[string]float64{"math": 1},
+		},
+	} {
+		marshalled, _ := json.Marshal(dto)
+		var value interface{}
+		if err := json.Unmarshal(marshalled, &value); err != nil {
+			t.Fatalf("json.Unmarshal() error = %v", err)
+		}
+		helloRequest := schema["definitions"].(map[string]interface{})["HelloRequest"].(map[string]interface{})
+		assert.NoError(t, validateJSONSchema(schema, helloRequest, value), string(marshalled))
+	}
+}
+
+// validateJSONSchema validates a json value against the subset of json schema written by genJSONSchema
+func validateJSONSchema(root, schema map[string]interface{}, value interface{}) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		definition := root["definitions"].(map[string]interface{})[strings.TrimPrefix(ref, "#/definitions/")]
+		return validateJSONSchema(root, definition.(map[string]interface{}), value)
+	}
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok {
+		for _, alternative := range anyOf {
+			if validateJSONSchema(root, alternative.(map[string]interface{}), value) == nil {
+				return nil
+			}
+		}
+		return fmt.Errorf("%v matches none of %v", value, anyOf)
+	}
+	var valueType string
+	switch value.(type) {
+	case nil:
+		valueType = "null"
+	case bool:
+		valueType = "boolean"
+	case float64:
+		valueType = "number"
+	case string:
+		valueType = "string"
+	case []interface{}:
+		valueType = "array"
+	case map[string]interface{}:
+		valueType = "object"
+	}
+	if schemaType, ok := schema["type"]; ok {
+		types, ok := schemaType.([]interface{})
+		if !ok {
+			types = []interface{}{schemaType}
+		}
+		matched := false
+		for _, t := range types {
+			matched = matched || t == valueType || t == "integer" && valueType == "number"
+		}
+		if !matched {
+			return fmt.Errorf("%v is not of type %v", value, schemaType)
+		}
+	}
+	switch value := value.(type) {
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for _, item := range value {
+				if err := validateJSONSchema(root, items, item); err != nil {
+					return err
+				}
+			}
+		}
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		additional, _ := schema["additionalProperties"].(map[string]interface{})
+		for name, property := range value {
+			propertySchema, ok := properties[name].(map[string]interface{})
+			if !ok {
+				propertySchema = additional
+			}
+			if propertySchema == nil {
+				continue
+			}
+			if err := validateJSONSchema(root, propertySchema, property); err != nil {
+				return fmt.Errorf("%s: %v", name, err)
+			}
+		}
+		required, _ := schema["required"].([]interface{})
+		for _, name := range required {
+			if _, ok := value[name.(string)]; !ok {
+				return fmt.Errorf("%s is required", name)
+			}
+		}
+	}
+	return nil
+}
+
+func TestGenerateDTOFlattenWrappers(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type NameWrapper struct {
+		Value string
+	}
+	type Tags struct {
+		Values []string
+	}
+	type SharedWrapper struct {
+		Value int32
+	}
+	type HelloRequest struct {
+		Name   *NameWrapper
+		Tags   *Tags
+		Shared *SharedWrapper
+	}
+	type HelloResponse struct {
+		Shared *SharedWrapper
+	}`)
+	g.options.FlattenWrappers = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import testpb "test/pkg/grpc/pb"
+
+type SharedWrapper struct {
+	Value int32 `+"`json:\"value\"`"+`
+}
+
+func SharedWrapperFromPB(pb *testpb.SharedWrapper) *SharedWrapper {
+	if pb == nil {
+		return nil
+	}
+
+	return &SharedWrapper{Value: pb.Value}
+}
+
+func SharedWrapperToPB(orig *SharedWrapper) *testpb.SharedWrapper {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.SharedWrapper{Value: orig.Value}
+}
+
+type HelloRequest struct {
+	Name   string         `+"`json:\"name\"`"+` // flattened from NameWrapper.Value (--flatten-wrappers), ToPB always sets NameWrapper
+	Tags   []string       `+"`json:\"tags\"`"+` // flattened from Tags.Values (--flatten-wrappers)
+	Shared *SharedWrapper `+"`json:\"shared\"`"+`
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	var name string
+	if pb.Name != nil {
+		name = pb.Name.Value
+	}
+	var tags []string
+	if pb.Tags != nil {
+		tags = pb.Tags.Values
+	}
+	return &HelloRequest{
+		Name:   name,
+		Shared: SharedWrapperFromPB(pb.Shared),
+		Tags:   tags,
+	}
+}
+
+func HelloRequestToPB(orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	var tags *testpb.Tags
+	if orig.Tags != nil {
+		tags = &testpb.Tags{Values: orig.Tags}
+	}
+	return &testpb.HelloRequest{
+		Name:   &testpb.NameWrapper{Value: orig.Name},
+		Shared: SharedWrapperToPB(orig.Shared),
+		Tags:   tags,
+	}
+}
+
+type HelloResponse struct {
+	Shared *SharedWrapper `+"`json:\"shared\"`"+`
+}
+
+func HelloResponseFromPB(pb *testpb.HelloResponse) *HelloResponse {
+	if pb == nil {
+		return nil
+	}
+
+	return &HelloResponse{Shared: SharedWrapperFromPB(pb.Shared)}
+}
+
+func HelloResponseToPB(orig *HelloResponse) *testpb.HelloResponse {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.HelloResponse{Shared: SharedWrapperToPB(orig.Shared)}
+}
+`, content)
+}
+
+func TestGenerateDTOFlattenWrappersNilRoundTrip(t *testing.T) {
+	setDefaults()
+	pbSrc := `package pb
+	type NameWrapper struct {
+		Value string
+	}
+	type Tags struct {
+		Values []string
+	}
+	type Labels struct {
+		Entries map[string]string
+	}
+	type HelloRequest struct {
+		Name   *NameWrapper
+		Tags   *Tags
+		Labels *Labels
+	}`
+	g := newTestDTOGenerator(pbSrc)
+	g.options.FlattenWrappers = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	runDTOTest(t, pbSrc, `package dto
+
+import (
+	"reflect"
+	"testing"
+
+	testpb "test/pkg/grpc/pb"
+)
+
+func TestNilWrapperRoundTrip(t *testing.T) {
+	for _, sample := range []*testpb.HelloRequest{
+		{Name: &testpb.NameWrapper{}},
+		{
+			Name:   &testpb.NameWrapper{Value: "bob"},
+			Tags:   &testpb.Tags{Values: []string{"a"}},
+			Labels: &testpb.Labels{Entries: map[string]string{"k": "v"}},
+		},
+	} {
+		if got := HelloRequestToPB(HelloRequestFromPB(sample)); !reflect.DeepEqual(got, sample) {
+			t.Errorf("HelloRequest does not round trip, got %+v, want %+v", got, sample)
+		}
+	}
+	// the wrapper of a scalar is always set
+	if got := HelloRequestToPB(HelloRequestFromPB(&testpb.HelloRequest{})); got.Name == nil || got.Name.Value != "" {
+		t.Errorf("HelloRequestToPB() Name = %+v, want an empty NameWrapper", got.Name)
+	}
+}
+`, content)
+}
+
+func TestGenerateDTOUniqueLocalNames(t *testing.T) {
+	setDefaults()
+	// the locals of the bindings collide neither with each other, nor with the fields, e.g. V, nor with go keywords
+	g := newTestDTOGenerator(`package pb
+	type Address struct {
+		City string
+	}
+	type HelloRequest struct {
+		V      []*Address
+		Others []*Address
+		M      *Address
+		Labels map[string]*Address
+		Type   *Address
+	}`)
+	g.options.FallibleBindings = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import (
+	"fmt"
+	testpb "test/pkg/grpc/pb"
+)
+
+type Address struct {
+	City string `+"`json:\"city\"`"+`
+}
+
+func AddressFromPB(pb *testpb.Address) (*Address, error) {
+	if pb == nil {
+		return nil, nil
+	}
+
+	return &Address{City: pb.City}, nil
+}
+
+func AddressToPB(orig *Address) (*testpb.Address, error) {
+	if orig == nil {
+		return nil, nil
+	}
+
+	return &testpb.Address{City: orig.City}, nil
+}
+
+type HelloRequest struct {
+	V      []*Address          `+"`json:\"v\"`"+`
+	Others []*Address          `+"`json:\"others\"`"+`
+	M      *Address            `+"`json:\"m\"`"+`
+	Labels map[string]*Address `+"`json:\"labels\"`"+`
+	Type   *Address            `+"`json:\"type\"`"+`
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) (*HelloRequest, error) {
+	if pb == nil {
+		return nil, nil
+	}
+
+	aSlice := make([]*Address, 0, len(pb.V))
+	for _, v := range pb.V {
+		e, err := AddressFromPB(v)
+		if err != nil {
+			return nil, conversionError("HelloRequest", "V", err)
+		}
+		aSlice = append(aSlice, e)
+	}
+	aSlice1 := make([]*Address, 0, len(pb.Others))
+	for _, v := range pb.Others {
+		e, err := AddressFromPB(v)
+		if err != nil {
+			return nil, conversionError("HelloRequest", "Others", err)
+		}
+		aSlice1 = append(aSlice1, e)
+	}
+	m, err := AddressFromPB(pb.M)
+	if err != nil {
+		return nil, conversionError("HelloRequest", "M", err)
+	}
+	m1 := make(map[string]*Address, len(pb.Labels))
+	for k, v := range pb.Labels {
+		e, err := AddressFromPB(v)
+		if err != nil {
+			return nil, conversionError("HelloRequest", "Labels", err)
+		}
+		m1[k] = e
+	}
+	type1, err := AddressFromPB(pb.Type)
+	if err != nil {
+		return nil, conversionError("HelloRequest", "Type", err)
+	}
+	return &HelloRequest{
+		Labels: m1,
+		M:      m,
+		Others: aSlice1,
+		Type:   type1,
+		V:      aSlice,
+	}, nil
+}
+
+func HelloRequestToPB(orig *HelloRequest) (*testpb.HelloRequest, error) {
+	if orig == nil {
+		return nil, nil
+	}
+
+	aSlice := make([]*testpb.Address, 0, len(orig.V))
+	for _, v := range orig.V {
+		e, err := AddressToPB(v)
+		if err != nil {
+			return nil, conversionError("HelloRequest", "V", err)
+		}
+		aSlice = append(aSlice, e)
+	}
+	aSlice1 := make([]*testpb.Address, 0, len(orig.Others))
+	for _, v := range orig.Others {
+		e, err := AddressToPB(v)
+		if err != nil {
+			return nil, conversionError("HelloRequest", "Others", err)
+		}
+		aSlice1 = append(aSlice1, e)
+	}
+	m, err := AddressToPB(orig.M)
+	if err != nil {
+		return nil, conversionError("HelloRequest", "M", err)
+	}
+	m1 := make(map[string]*testpb.Address, len(orig.Labels))
+	for k, v := range orig.Labels {
+		e, err := AddressToPB(v)
+		if err != nil {
+			return nil, conversionError("HelloRequest", "Labels", err)
+		}
+		m1[k] = e
+	}
+	type1, err := AddressToPB(orig.Type)
+	if err != nil {
+		return nil, conversionError("HelloRequest", "Type", err)
+	}
+	return &testpb.HelloRequest{
+		Labels: m1,
+		M:      m,
+		Others: aSlice1,
+		Type:   type1,
+		V:      aSlice,
+	}, nil
+}
+
+// ConversionError is the error of a binding failing to convert a field, Field being the path of the field in the Type dto, e.g. Address.Location.
+type ConversionError struct {
+	Field string
+	Type  string
+	Err   error
+}
+
+func (e *ConversionError) Error() string {
+	return fmt.Sprintf("converting %s.%s: %v", e.Type, e.Field, e.Err)
+}
+
+func (e *ConversionError) Unwrap() error {
+	return e.Err
+}
+
+// conversionError wraps the error converting a field of a dto, the field of a nested dto being prefixed with the field holding it.
+func conversionError(typeName string, field string, err error) error {
+	if nested, ok := err.(*ConversionError); ok {
+		return &ConversionError{
+			Err:   nested.Err,
+			Field: field + "." + nested.Field,
+			Type:  typeName,
+		}
+	}
+	return &ConversionError{
+		Err:   err,
+		Field: field,
+		Type:  typeName,
+	}
+}
+`, content)
+}
+
+func TestGenerateDTOInPBPackage(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	import durationpb "google.golang.org/protobuf/types/known/durationpb"
+	type Address struct {
+		City string
+	}
+	type HelloRequest struct {
+		Name    string
+		Home    *Address
+		Others  []*Address
+		Timeout *durationpb.Duration
+	}`)
+	// the dto are generated next to pb.go
+	g.srcFile = jen.NewFilePath("test/pkg/grpc/pb")
+	g.InitPg()
+	g.dtoPackagePath, g.dtoImportPath = "test/pkg/grpc/pb", "test/pkg/grpc/pb"
+	g.dtoFileFullPath = "test/pkg/grpc/pb/z_test_dto.go"
+	g.options.TypeSuffix = "DTO"
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package pb
+
+import (
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	"time"
+)
+
+type AddressDTO struct {
+	City string `+"`json:\"city\"`"+`
+}
+
+func AddressDTOFromPB(pb *Address) *AddressDTO {
+	if pb == nil {
+		return nil
+	}
+
+	return &AddressDTO{City: pb.City}
+}
+
+func AddressDTOToPB(orig *AddressDTO) *Address {
+	if orig == nil {
+		return nil
+	}
+
+	return &Address{City: orig.City}
+}
+
+type HelloRequestDTO struct {
+	Name    string        `+"`json:\"name\"`"+`
+	Home    *AddressDTO   `+"`json:\"home\"`"+`
+	Others  []*AddressDTO `+"`json:\"others\"`"+`
+	Timeout time.Duration `+"`json:\"timeout\"`"+`
+}
+
+func HelloRequestDTOFromPB(pb *HelloRequest) *HelloRequestDTO {
+	if pb == nil {
+		return nil
+	}
+
+	aSlice := make([]*AddressDTO, 0, len(pb.Others))
+	for _, v := range pb.Others {
+		aSlice = append(aSlice, AddressDTOFromPB(v))
+	}
+	return &HelloRequestDTO{
+		Home:    AddressDTOFromPB(pb.Home),
+		Name:    pb.Name,
+		Others:  aSlice,
+		Timeout: pb.Timeout.AsDuration(),
+	}
+}
+
+func HelloRequestDTOToPB(orig *HelloRequestDTO) *HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	aSlice := make([]*Address, 0, len(orig.Others))
+	for _, v := range orig.Others {
+		aSlice = append(aSlice, AddressDTOToPB(v))
+	}
+	return &HelloRequest{
+		Home:    AddressDTOToPB(orig.Home),
+		Name:    orig.Name,
+		Others:  aSlice,
+		Timeout: durationpb.New(orig.Timeout),
+	}
+}
+`, content)
+}
+
+func TestGenerateDTOInPBPackageNeedsTypeAffix(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		Name string
+	}`)
+	g.dtoPackagePath, g.dtoImportPath = "test/pkg/grpc/pb", "test/pkg/grpc/pb"
+	assert.Error(t, g.Generate())
+}
+
+func TestGenerateDTOSkipDirective(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type Audit struct {
+		By string
+	}
+	type HelloRequest struct {
+		Name string
+		// @dto:skip
+		Audit *Audit
+		UpdatedBy string // @dto:skip
+	}`)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import testpb "test/pkg/grpc/pb"
+
+type HelloRequest struct {
+	Name string `+"`json:\"name\"`"+`
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	return &HelloRequest{Name: pb.Name}
+}
+
+func HelloRequestToPB(orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.HelloRequest{Name: orig.Name}
+}
+`, content)
+}
+
+func TestGenerateDTOMapperInterface(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		Name string
+	}`)
+	g.options.MapperInterface = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import testpb "test/pkg/grpc/pb"
+
+type HelloRequest struct {
+	Name string `+"`json:\"name\"`"+`
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	return &HelloRequest{Name: pb.Name}
+}
+
+func HelloRequestToPB(orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.HelloRequest{Name: orig.Name}
+}
+
+// HelloRequestMapper converts HelloRequest from / to pb, it can be injected instead of calling the bindings.
+type HelloRequestMapper interface {
+	FromPB(pb *testpb.HelloRequest) *HelloRequest
+	ToPB(orig *HelloRequest) *testpb.HelloRequest
+}
+
+// DefaultHelloRequestMapper is the HelloRequestMapper calling HelloRequestFromPB and HelloRequestToPB.
+type DefaultHelloRequestMapper struct{}
+
+var _ HelloRequestMapper = DefaultHelloRequestMapper{}
+
+// FromPB calls HelloRequestFromPB.
+func (DefaultHelloRequestMapper) FromPB(pb *testpb.HelloRequest) *HelloRequest {
+	return HelloRequestFromPB(pb)
+}
+
+// ToPB calls HelloRequestToPB.
+func (DefaultHelloRequestMapper) ToPB(orig *HelloRequest) *testpb.HelloRequest {
+	return HelloRequestToPB(orig)
+}
+`, content)
+}
+
+func TestGenerateDTOWithRoundTripTests(t *testing.T) {
+	setDefaults()
+	pbSrc := `package pb
+	type Status int32
+	var Status_name = map[int32]string{0: "UNKNOWN", 1: "OK"}
+	type Address struct {
+		City string
+	}
+	type HelloRequest struct {
+		Name   string
+		Status Status
+		Home   *Address
+		Tags   []string
+		Homes  map[string]*Address
+	}`
+	g := newTestDTOGenerator(pbSrc)
+	g.options.WithRoundTripTests = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile("test/pkg/test/dto/z_test_dto_roundtrip_test.go")
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import (
+	"reflect"
+	testpb "test/pkg/grpc/pb"
+	"testing"
+)
+
+// TestDTORoundTrip converts a sample of every pb struct having a dto to its dto and back, and fails when the result
+// differs from the sample, i.e. when the FromPB / ToPB bindings are not symmetric.
+func TestDTORoundTrip(t *testing.T) {
+	t.Run("Address", func(t *testing.T) {
+		sample := &testpb.Address{City: "a"}
+		converted := AddressFromPB(sample)
+		got := AddressToPB(converted)
+		if !reflect.DeepEqual(got, sample) {
+			t.Errorf("Address does not round trip, got %+v, want %+v", got, sample)
+		}
+	})
+	t.Run("HelloRequest", func(t *testing.T) {
+		sample := &testpb.HelloRequest{
+			Home:   &testpb.Address{City: "a"},
+			Homes:  map[string]*testpb.Address{"a": &testpb.Address{City: "a"}},
+			Name:   "a",
+			Status: testpb.Status(1),
+			Tags:   []string{"a"},
+		}
+		converted := HelloRequestFromPB(sample)
+		got := HelloRequestToPB(converted)
+		if !reflect.DeepEqual(got, sample) {
+			t.Errorf("HelloRequest does not round trip, got %+v, want %+v", got, sample)
+		}
+	})
+}
+`, content)
+	dto, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	typeCheckDTO(t, pbSrc, dto, content)
+}
+
+func TestGenerateDTOWithRoundTripTestsOptionalFields(t *testing.T) {
+	setDefaults()
+	pbSrc := `package pb
+	type Status int32
+	var Status_name = map[int32]string{0: "UNKNOWN", 1: "OK"}
+	type HelloRequest struct {
+		OptionalBool   *bool
+		OptionalInt32  *int32
+		OptionalString *string
+		OptionalStatus *Status
+		Retries        *int32
+	}`
+	g := newTestDTOGenerator(pbSrc)
+	g.options.WithRoundTripTests = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	roundTrip, _ := g.fs.ReadFile("test/pkg/test/dto/z_test_dto_roundtrip_test.go")
+	assert.Contains(t, roundTrip, `		sample := &testpb.HelloRequest{
+			OptionalBool:   roundTripPtrBool(true),
+			OptionalInt32:  roundTripPtrInt32(1),
+			OptionalStatus: roundTripPtrStatus(testpb.Status(1)),
+			OptionalString: roundTripPtrString("a"),
+			Retries:        roundTripPtrInt32(1),
+		}
+`)
+	assert.Contains(t, roundTrip, `
+func roundTripPtrBool(v bool) *bool {
+	return &v
+}
+
+func roundTripPtrInt32(v int32) *int32 {
+	return &v
+}
+
+func roundTripPtrStatus(v testpb.Status) *testpb.Status {
+	return &v
+}
+
+func roundTripPtrString(v string) *string {
+	return &v
+}
+`)
+	typeCheckDTO(t, pbSrc, content, roundTrip)
+}
+
+func TestGenerateDTOUsesPBGetters(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type Address struct {
+		City string
+	}
+	type HelloRequest struct {
+		Name     string
+		Nickname *string
+		Home     *Address
+		Tags     []string
+	}
+	func (x *HelloRequest) GetName() string {
+		if x != nil {
+			return x.Name
+		}
+		return ""
+	}
+	func (x *HelloRequest) GetNickname() string {
+		if x != nil && x.Nickname != nil {
+			return *x.Nickname
+		}
+		return ""
+	}
+	func (x *HelloRequest) GetHome() *Address {
+		if x != nil {
+			return x.Home
+		}
+		return nil
+	}`)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import testpb "test/pkg/grpc/pb"
+
+type Address struct {
+	City string `+"`json:\"city\"`"+`
+}
+
+func AddressFromPB(pb *testpb.Address) *Address {
+	if pb == nil {
+		return nil
+	}
+
+	return &Address{City: pb.City}
+}
+
+func AddressToPB(orig *Address) *testpb.Address {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.Address{City: orig.City}
+}
+
+type HelloRequest struct {
+	Name     string   `+"`json:\"name\"`"+`
+	Nickname *string  `+"`json:\"nickname\"`"+`
+	Home     *Address `+"`json:\"home\"`"+`
+	Tags     []string `+"`json:\"tags\"`"+`
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	return &HelloRequest{
+		Home:     AddressFromPB(pb.GetHome()),
+		Name:     pb.GetName(),
+		Nickname: pb.Nickname,
+		Tags:     pb.Tags,
+	}
+}
+
+func HelloRequestToPB(orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.HelloRequest{
+		Home:     AddressToPB(orig.Home),
+		Name:     orig.Name,
+		Nickname: orig.Nickname,
+		Tags:     orig.Tags,
+	}
+}
+`, content)
+}
+
+func TestGenerateDTOAnnotatePBTypes(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	import (
+		durationpb "google.golang.org/protobuf/types/known/durationpb"
+		wrapperspb "google.golang.org/protobuf/types/known/wrapperspb"
+	)
+	type HelloRequest_LabelsEntry struct {
+		Key   string
+		Value string
+	}
+	type HelloRequest struct {
+		Name    string
+		Timeout *durationpb.Duration
+		Nick    *wrapperspb.StringValue
+		Labels  []*HelloRequest_LabelsEntry
+	}`)
+	g.options.AnnotatePBTypes = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import (
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	wrapperspb "google.golang.org/protobuf/types/known/wrapperspb"
+	testpb "test/pkg/grpc/pb"
+	"time"
+)
+
+type HelloRequest struct {
+	Name    string            `+"`json:\"name\"`"+`
+	Timeout time.Duration     `+"`json:\"timeout\"`"+` // pb: *durationpb.Duration
+	Nick    *string           `+"`json:\"nick\"`"+`    // pb: *wrapperspb.StringValue
+	Labels  map[string]string `+"`json:\"labels\"`"+`  // pb: []*HelloRequest_LabelsEntry
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	m := make(map[string]string, len(pb.Labels))
+	for _, entry := range pb.Labels {
+		m[entry.Key] = entry.Value
+	}
+	return &HelloRequest{
+		Labels:  m,
+		Name:    pb.Name,
+		Nick:    stringValueFromPB(pb.Nick),
+		Timeout: pb.Timeout.AsDuration(),
+	}
+}
+
+func HelloRequestToPB(orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	entries := make([]*testpb.HelloRequest_LabelsEntry, 0, len(orig.Labels))
+	for k, v := range orig.Labels {
+		entries = append(entries, &testpb.HelloRequest_LabelsEntry{
+			Key:   k,
+			Value: v,
+		})
+	}
+	return &testpb.HelloRequest{
+		Labels:  entries,
+		Name:    orig.Name,
+		Nick:    stringValueToPB(orig.Nick),
+		Timeout: durationpb.New(orig.Timeout),
+	}
+}
+
+// stringValueFromPB converts an optional pb string to dto, nil stays nil.
+func stringValueFromPB(v *wrapperspb.StringValue) *string {
+	if v == nil {
+		return nil
+	}
+	value := v.GetValue()
+	return &value
+}
+
+// stringValueToPB converts an optional dto string to pb, nil stays nil.
+func stringValueToPB(v *string) *wrapperspb.StringValue {
+	if v == nil {
+		return nil
+	}
+	return wrapperspb.String(*v)
+}
+`, content)
+}
+
+func TestGenerateDTOResult(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		Name string
+	}`)
+	g.options.Formatter = "kit-missing-formatter"
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	assert.Equal(t, DTOResult{
+		Files:    []string{"test/pkg/test/dto/z_test_dto.go"},
+		Structs:  []string{"HelloRequest"},
+		Warnings: []string{"formatter kit-missing-formatter not found, falling back to gofmt"},
+	}, g.Result())
+
+	// unchanged files are not rewritten, so not reported
+	generatedFs := g.fs
+	g = newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		Name string
+	}`)
+	g.fs = generatedFs
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	assert.Equal(t, []string{}, g.Result().Files)
+}
+
+// TestGenerateDTOEmptyStruct guards the rendering of the dto of a pb struct having no exported field,
+// the empty literals of the bindings must render as &HelloRequest{}
+func TestGenerateDTOEmptyStruct(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		state         protoimpl.MessageState
+		sizeCache     protoimpl.SizeCache
+		unknownFields protoimpl.UnknownFields
+	}`)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import testpb "test/pkg/grpc/pb"
+
+type HelloRequest struct{}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	return &HelloRequest{}
+}
+
+func HelloRequestToPB(orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.HelloRequest{}
+}
+`, content)
+
+	// the fields skipped by a directive leave the struct empty too
+	g = newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		// @dto:skip
+		Name string
+	}`)
+	g.options.FallibleBindings = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ = g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Contains(t, content, "type HelloRequest struct{}")
+	assert.Contains(t, content, "return &HelloRequest{}, nil")
+	assert.Contains(t, content, "return &testpb.HelloRequest{}, nil")
+}
+
+func TestGenerateDTOCtxBindings(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type Address struct {
+		City string
+	}
+	type HelloRequest struct {
+		Home  *Address
+		Homes []*Address
+	}`)
+	g.options.CtxBindings = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import (
+	"context"
+	testpb "test/pkg/grpc/pb"
+)
+
+type Address struct {
+	City string `+"`json:\"city\"`"+`
+}
+
+func AddressFromPB(ctx context.Context, pb *testpb.Address) *Address {
+	if pb == nil {
+		return nil
+	}
+
+	return &Address{City: pb.City}
+}
+
+func AddressToPB(ctx context.Context, orig *Address) *testpb.Address {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.Address{City: orig.City}
+}
+
+type HelloRequest struct {
+	Home  *Address   `+"`json:\"home\"`"+`
+	Homes []*Address `+"`json:\"homes\"`"+`
+}
+
+func HelloRequestFromPB(ctx context.Context, pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	aSlice := make([]*Address, 0, len(pb.Homes))
+	for _, v := range pb.Homes {
+		aSlice = append(aSlice, AddressFromPB(ctx, v))
+	}
+	return &HelloRequest{
+		Home:  AddressFromPB(ctx, pb.Home),
+		Homes: aSlice,
+	}
+}
+
+func HelloRequestToPB(ctx context.Context, orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	aSlice := make([]*testpb.Address, 0, len(orig.Homes))
+	for _, v := range orig.Homes {
+		aSlice = append(aSlice, AddressToPB(ctx, v))
+	}
+	return &testpb.HelloRequest{
+		Home:  AddressToPB(ctx, orig.Home),
+		Homes: aSlice,
+	}
+}
+`, content)
+}
+
+func TestGenerateDTOArrays(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type Address struct {
+		City string
+	}
+	type HelloRequest struct {
+		Digest [16]byte
+		Homes  [4]*Address
+	}`)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import testpb "test/pkg/grpc/pb"
+
+type Address struct {
+	City string `+"`json:\"city\"`"+`
+}
+
+func AddressFromPB(pb *testpb.Address) *Address {
+	if pb == nil {
+		return nil
+	}
+
+	return &Address{City: pb.City}
+}
+
+func AddressToPB(orig *Address) *testpb.Address {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.Address{City: orig.City}
+}
+
+type HelloRequest struct {
+	Digest [16]byte    `+"`json:\"digest\"`"+`
+	Homes  [4]*Address `+"`json:\"homes\"`"+`
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	var arr [4]*Address
+	for i, v := range pb.Homes {
+		arr[i] = AddressFromPB(v)
+	}
+	return &HelloRequest{
+		Digest: pb.Digest,
+		Homes:  arr,
+	}
+}
+
+func HelloRequestToPB(orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	var arr [4]*testpb.Address
+	for i, v := range orig.Homes {
+		arr[i] = AddressToPB(v)
+	}
+	return &testpb.HelloRequest{
+		Digest: orig.Digest,
+		Homes:  arr,
+	}
+}
+`, content)
+}
+
+func TestGenerateDTOStdout(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		Name string
+	}`)
+	g.options.Stdout = true
+	stdout := &bytes.Buffer{}
+	g.stdout = stdout
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import testpb "test/pkg/grpc/pb"
+
+type HelloRequest struct {
+	Name string `+"`json:\"name\"`"+`
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	return &HelloRequest{Name: pb.Name}
+}
+
+func HelloRequestToPB(orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.HelloRequest{Name: orig.Name}
+}
+`, stdout.String())
+	exists, _ := g.fs.Exists(g.dtoFileFullPath)
+	assert.False(t, exists)
+	exists, _ = g.fs.Exists(g.dtoPackagePath)
+	assert.False(t, exists)
+}
+
+func TestGenerateDTOUnknownTypePolicy(t *testing.T) {
+	setDefaults()
+	pbSrc := `package pb
+	type Status int32
+	type HelloRequest struct {
+		Name   string
+		Tags   map[string][]string
+		Status Status
+		Value  isHelloRequest_Value
+	}`
+
+	g := newTestDTOGenerator(pbSrc)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Contains(t, content, "Value  isHelloRequest_Value")
+
+	g = newTestDTOGenerator(pbSrc)
+	g.options.UnknownTypePolicy = UnknownTypeWarnAndSkip
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ = g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Contains(t, content, "Tags map[string][]string")
+	assert.NotContains(t, content, "Status")
+	assert.NotContains(t, content, "Value")
+	assert.Equal(t, []string{
+		"skipping field of unknown type: HelloRequest.Status (Status) at test/pkg/grpc/pb/z_test.pb.go:6:3",
+		"skipping field of unknown type: HelloRequest.Value (isHelloRequest_Value) at test/pkg/grpc/pb/z_test.pb.go:7:3",
+	}, g.Result().Warnings)
+
+	g = newTestDTOGenerator(pbSrc)
+	g.options.UnknownTypePolicy = UnknownTypeError
+	err := g.Generate()
+	assert.EqualError(t, err, "fields of unknown type: HelloRequest.Status (Status) at test/pkg/grpc/pb/z_test.pb.go:6:3, "+
+		"HelloRequest.Value (isHelloRequest_Value) at test/pkg/grpc/pb/z_test.pb.go:7:3, see --unknown-type-policy")
+	exists, _ := g.fs.Exists(g.dtoFileFullPath)
+	assert.False(t, exists)
+
+	g = newTestDTOGenerator(pbSrc)
+	g.options.UnknownTypePolicy = "ignore"
+	assert.EqualError(t, g.Generate(), "unknown type policy ignore, expected one of pass-through, warn-and-skip, error")
+}
+
+func TestGenerateDTOPreservesUserMethods(t *testing.T) {
+	setDefaults()
+	src := `package pb
+	type HelloRequest struct {
+		Name string
+	}`
+	g := newTestDTOGenerator(src)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	userMethod := `
+// Validate validates the request.
+func (x *HelloRequest) Validate() error {
+	// the name is required
+	if strings.TrimSpace(x.Name) == "" {
+		return errors.New("name is required")
+	}
+	return nil
+}
+`
+	content = strings.Replace(content, "import testpb \"test/pkg/grpc/pb\"", "import (\n\t\"errors\"\n\t\"strings\"\n\ttestpb \"test/pkg/grpc/pb\"\n)", 1)
+	if err := g.fs.WriteFile(g.dtoFileFullPath, content+userMethod, true); err != nil {
+		t.Fatal(err)
+	}
+
+	generatedFs := g.fs
+	generatedFs.WriteFile(g.protoGoFileFullPath, `package pb
+	type HelloRequest struct {
+		Name string
+		Age  int32
+	}`, true)
+	g = newTestDTOGenerator(src)
+	g.fs = generatedFs
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ = g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Contains(t, content, "Age  int32")
+	assert.Contains(t, content, "\"errors\"")
+	assert.Contains(t, content, "\"strings\"")
+	assert.Contains(t, content, userMethod)
+	_, err := format.Source([]byte(content))
+	assert.NoError(t, err)
+}
+
+func TestGenerateDTOForce(t *testing.T) {
+	setDefaults()
+	src := `package pb
+	type HelloRequest struct {
+		Name string
+	}`
+	g := newTestDTOGenerator(src)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	generated, _ := g.fs.ReadFile(g.dtoFileFullPath)
+
+	// the methods added to the dto types are dropped
+	generatedFs := g.fs
+	generatedFs.WriteFile(g.dtoFileFullPath, generated+"\nfunc (x *HelloRequest) Validate() error { return nil }\n", true)
+	g = newTestDTOGenerator(src)
+	g.fs = generatedFs
+	g.options.Force = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, generated, content)
+
+	// a file which is not a generated file is overwritten
+	g = newTestDTOGenerator(src)
+	g.fs.WriteFile(g.dtoFileFullPath, "package dto\n", true)
+	g.options.Force = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ = g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, generated, content)
+	assert.Equal(t, []string{"test/pkg/test/dto/z_test_dto.go"}, g.Result().Files)
+}
+
+func TestGenerateDTONoMerge(t *testing.T) {
+	setDefaults()
+	src := `package pb
+	type HelloRequest struct {
+		Name string
+	}`
+	g := newTestDTOGenerator(src)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	generated, _ := g.fs.ReadFile(g.dtoFileFullPath)
+
+	// the methods added to the dto types are dropped
+	generatedFs := g.fs
+	generatedFs.WriteFile(g.dtoFileFullPath, generated+"\nfunc (x *HelloRequest) Validate() error { return nil }\n", true)
+	g = newTestDTOGenerator(src)
+	g.fs = generatedFs
+	g.options.NoMerge = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, generated, content)
+
+	// but a file which is not a generated file is not overwritten, unlike with Force
+	g = newTestDTOGenerator(src)
+	g.fs.WriteFile(g.dtoFileFullPath, "package dto\n", true)
+	g.options.NoMerge = true
+	assert.EqualError(t, g.Generate(), "refusing to overwrite test/pkg/test/dto/z_test_dto.go, it is not a generated file")
+}
+
+func TestGenerateDTOReflectFallback(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		Greeting *Greeting
+	}
+	type Greeting struct {
+		Name  string
+		Count int64
+		Value isGreeting_Value
+	}
+	type Address struct {
+		Id int64
+	}
+	type isGreeting_Value interface {
+		isGreeting_Value()
+	}
+	type Greeting_Text struct {
+		Text string
+	}
+	type Greeting_Address struct {
+		Address *Address
+	}
+	func (*Greeting_Text) isGreeting_Value() {}
+	func (*Greeting_Address) isGreeting_Value() {}`)
+	g.options.ReflectFallback = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Contains(t, content, `type Greeting struct {
+	Name    string          `+"`json:\"name\"`"+`
+	Count   int64           `+"`json:\"count,string\"`"+`
+	Text    *string         `+"`json:\"text,omitempty\"`"+`    // member of oneof Value, converted through json (--reflect-fallback)
+	Address json.RawMessage `+"`json:\"address,omitempty\"`"+` // member of oneof Value, converted through json (--reflect-fallback)
+}`)
+	assert.Contains(t, content, `func GreetingFromPB(pb *testpb.Greeting) (*Greeting, error) {
+	if pb == nil {
+		return nil, nil
+	}
+
+	// Greeting has fields of unknown type, it is converted through json (--reflect-fallback)
+	data, err := protojson.MarshalOptions{
+		AllowPartial:   true,
+		UseEnumNumbers: true,
+	}.Marshal(pb)
+	if err != nil {
+		return nil, err
+	}
+	res := &Greeting{}
+	if err = json.Unmarshal(data, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}`)
+	assert.Contains(t, content, `func GreetingToPB(orig *Greeting) (*testpb.Greeting, error) {`)
+	// the bindings nesting a struct converted through json return its error
+	assert.Contains(t, content, `func HelloRequestFromPB(pb *testpb.HelloRequest) (*HelloRequest, error) {`)
+	assert.Contains(t, content, `func HelloRequestToPB(orig *HelloRequest) (*testpb.HelloRequest, error) {`)
+	_, err := format.Source([]byte(content))
+	assert.NoError(t, err)
+
+	// without the fallback, the oneof is passed through as is
+	g = newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		Count int64
+		Value isHelloRequest_Value
+	}`)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ = g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Contains(t, content, "Count int64                `json:\"count\"`")
+	assert.Contains(t, content, "func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {")
+}
+
+func TestGenerateDTOPackageName(t *testing.T) {
+	setDefaults()
+	pbSrc := `package pb
+	type HelloRequest struct {
+		Name string
+	}`
+	g := newTestDTOGenerator(pbSrc)
+	g.options.DTOPackageName = "models"
+	g.options.Split = true
+	g.srcFile = g.newDTOFile()
+	g.InitPg()
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Contains(t, content, "package models\n")
+	// the dto types are not qualified by the package of their own file
+	bindings, _ := g.fs.ReadFile(g.dtoBindingsFileFullPath())
+	assert.Contains(t, bindings, "package models\n")
+	assert.Contains(t, bindings, "func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {")
+	assert.NotContains(t, bindings, "test/pkg/test/dto")
+
+	g = newTestDTOGenerator(pbSrc)
+	g.options.DTOPackageName = "dto-models"
+	assert.EqualError(t, g.Generate(), "invalid dto package name dto-models, expected a go identifier")
+
+	g = newTestDTOGenerator(pbSrc)
+	g.dtoImportPath, g.dtoPackagePath = g.pbPackagePath, g.pbPackagePath
+	g.options.TypeSuffix = "DTO"
+	g.options.DTOPackageName = "models"
+	assert.EqualError(t, g.Generate(), "dto generated into the pb package must be named pb, not models")
+}
+
+func TestGenerateDTORecursiveCollections(t *testing.T) {
+	setDefaults()
+	// Node is reachable from its own map and slice fields, its bindings call themselves in the loops over the collections
+	g := newTestDTOGenerator(`package pb
+	type GraphRequest struct {
+		Root *Node
+	}
+	type Node struct {
+		Name      string
+		Neighbors map[string]*Node
+		Children  []*Node
+	}`)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import testpb "test/pkg/grpc/pb"
+
+type Node struct {
+	Name      string           `+"`json:\"name\"`"+`
+	Neighbors map[string]*Node `+"`json:\"neighbors\"`"+`
+	Children  []*Node          `+"`json:\"children\"`"+`
+}
+
+func NodeFromPB(pb *testpb.Node) *Node {
+	if pb == nil {
+		return nil
+	}
+
+	m := make(map[string]*Node, len(pb.Neighbors))
+	for k, v := range pb.Neighbors {
+		m[k] = NodeFromPB(v)
+	}
+	aSlice := make([]*Node, 0, len(pb.Children))
+	for _, v := range pb.Children {
+		aSlice = append(aSlice, NodeFromPB(v))
+	}
+	return &Node{
+		Children:  aSlice,
+		Name:      pb.Name,
+		Neighbors: m,
+	}
+}
+
+func NodeToPB(orig *Node) *testpb.Node {
+	if orig == nil {
+		return nil
+	}
+
+	m := make(map[string]*testpb.Node, len(orig.Neighbors))
+	for k, v := range orig.Neighbors {
+		m[k] = NodeToPB(v)
+	}
+	aSlice := make([]*testpb.Node, 0, len(orig.Children))
+	for _, v := range orig.Children {
+		aSlice = append(aSlice, NodeToPB(v))
+	}
+	return &testpb.Node{
+		Children:  aSlice,
+		Name:      orig.Name,
+		Neighbors: m,
+	}
+}
+
+type GraphRequest struct {
+	Root *Node `+"`json:\"root\"`"+`
+}
+
+func GraphRequestFromPB(pb *testpb.GraphRequest) *GraphRequest {
+	if pb == nil {
+		return nil
+	}
+
+	return &GraphRequest{Root: NodeFromPB(pb.Root)}
+}
+
+func GraphRequestToPB(orig *GraphRequest) *testpb.GraphRequest {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.GraphRequest{Root: NodeToPB(orig.Root)}
+}
+`, content)
+}
+
+func TestGenerateDTOWithBuilder(t *testing.T) {
+	setDefaults()
+	pbSrc := `package pb
+	type Status int32
+	var Status_name = map[int32]string{0: "UNKNOWN"}
+	type HelloRequest struct {
+		Name      string
+		Type      Status
+		Tags      []string
+		Labels    map[string]string
+		Addresses []*Address
+		Digest    []byte
+	}
+	type Address struct {
+		City string
+	}`
+	g := newTestDTOGenerator(pbSrc)
+	g.options.WithBuilder = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import testpb "test/pkg/grpc/pb"
+
+type Address struct {
+	City string `+"`json:\"city\"`"+`
+}
+
+// AddressBuilder builds the Address dto with chainable setters, e.g. in tests.
+type AddressBuilder struct {
+	dto Address
+}
+
+func NewAddressBuilder() *AddressBuilder {
+	return &AddressBuilder{}
+}
+
+func (a *AddressBuilder) WithCity(v string) *AddressBuilder {
+	a.dto.City = v
+	return a
+}
+
+func (a *AddressBuilder) Build() *Address {
+	dto := a.dto
+	return &dto
+}
+
+func AddressFromPB(pb *testpb.Address) *Address {
+	if pb == nil {
+		return nil
+	}
+
+	return &Address{City: pb.City}
+}
+
+func AddressToPB(orig *Address) *testpb.Address {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.Address{City: orig.City}
+}
+
+type Status int32
+
+type HelloRequest struct {
+	Name      string            `+"`json:\"name\"`"+`
+	Type      Status            `+"`json:\"type\"`"+`
+	Tags      []string          `+"`json:\"tags\"`"+`
+	Labels    map[string]string `+"`json:\"labels\"`"+`
+	Addresses []*Address        `+"`json:\"addresses\"`"+`
+	Digest    []byte            `+"`json:\"digest\"`"+`
+}
+
+// HelloRequestBuilder builds the HelloRequest dto with chainable setters, e.g. in tests.
+type HelloRequestBuilder struct {
+	dto HelloRequest
+}
+
+func NewHelloRequestBuilder() *HelloRequestBuilder {
+	return &HelloRequestBuilder{}
+}
+
+func (h *HelloRequestBuilder) WithName(v string) *HelloRequestBuilder {
+	h.dto.Name = v
+	return h
+}
+
+func (h *HelloRequestBuilder) WithType(v Status) *HelloRequestBuilder {
+	h.dto.Type = v
+	return h
+}
+
+func (h *HelloRequestBuilder) WithTags(v ...string) *HelloRequestBuilder {
+	h.dto.Tags = append(h.dto.Tags[:len(h.dto.Tags):len(h.dto.Tags)], v...)
+	return h
+}
+
+func (h *HelloRequestBuilder) WithLabels(v map[string]string) *HelloRequestBuilder {
+	merged := make(map[string]string, len(h.dto.Labels)+len(v))
+	for key, value := range h.dto.Labels {
+		merged[key] = value
+	}
+	for key, value := range v {
+		merged[key] = value
+	}
+	h.dto.Labels = merged
+	return h
+}
+
+func (h *HelloRequestBuilder) WithAddresses(v ...*Address) *HelloRequestBuilder {
+	h.dto.Addresses = append(h.dto.Addresses[:len(h.dto.Addresses):len(h.dto.Addresses)], v...)
+	return h
+}
+
+func (h *HelloRequestBuilder) WithDigest(v []byte) *HelloRequestBuilder {
+	h.dto.Digest = v
+	return h
+}
+
+func (h *HelloRequestBuilder) Build() *HelloRequest {
+	dto := h.dto
+	return &dto
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	aSlice := make([]*Address, 0, len(pb.Addresses))
+	for _, v := range pb.Addresses {
+		aSlice = append(aSlice, AddressFromPB(v))
+	}
+	return &HelloRequest{
+		Addresses: aSlice,
+		Digest:    pb.Digest,
+		Labels:    pb.Labels,
+		Name:      pb.Name,
+		Tags:      pb.Tags,
+		Type:      Status(pb.Type),
+	}
+}
+
+func HelloRequestToPB(orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	aSlice := make([]*testpb.Address, 0, len(orig.Addresses))
+	for _, v := range orig.Addresses {
+		aSlice = append(aSlice, AddressToPB(v))
+	}
+	return &testpb.HelloRequest{
+		Addresses: aSlice,
+		Digest:    orig.Digest,
+		Labels:    orig.Labels,
+		Name:      orig.Name,
+		Tags:      orig.Tags,
+		Type:      testpb.Status(orig.Type),
+	}
+}
+`, content)
+	typeCheckDTO(t, pbSrc, content)
+}
+
+func TestGenerateDTOEmpty(t *testing.T) {
+	setDefaults()
+	// the dto types do not refer to emptypb, the loops over the collections of empties use their values through the helpers
+	g := newTestDTOGenerator(`package pb
+	import emptypb "google.golang.org/protobuf/types/known/emptypb"
+	type PingResponse struct {
+		Ack    *emptypb.Empty
+		Acks   []*emptypb.Empty
+		ByName map[string]*emptypb.Empty
+	}`)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import (
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	testpb "test/pkg/grpc/pb"
+)
+
+type PingResponse struct {
+	Ack    struct{}            `+"`json:\"ack\"`"+`
+	Acks   []struct{}          `+"`json:\"acks\"`"+`
+	ByName map[string]struct{} `+"`json:\"byName\"`"+`
+}
+
+func PingResponseFromPB(pb *testpb.PingResponse) *PingResponse {
+	if pb == nil {
+		return nil
+	}
+
+	aSlice := make([]struct{}, 0, len(pb.Acks))
+	for _, v := range pb.Acks {
+		aSlice = append(aSlice, emptyFromPB(v))
+	}
+	m := make(map[string]struct{}, len(pb.ByName))
+	for k, v := range pb.ByName {
+		m[k] = emptyFromPB(v)
+	}
+	return &PingResponse{
+		Ack:    emptyFromPB(pb.Ack),
+		Acks:   aSlice,
+		ByName: m,
+	}
+}
+
+func PingResponseToPB(orig *PingResponse) *testpb.PingResponse {
+	if orig == nil {
+		return nil
+	}
+
+	aSlice := make([]*emptypb.Empty, 0, len(orig.Acks))
+	for _, v := range orig.Acks {
+		aSlice = append(aSlice, emptyToPB(v))
+	}
+	m := make(map[string]*emptypb.Empty, len(orig.ByName))
+	for k, v := range orig.ByName {
+		m[k] = emptyToPB(v)
+	}
+	return &testpb.PingResponse{
+		Ack:    emptyToPB(orig.Ack),
+		Acks:   aSlice,
+		ByName: m,
+	}
+}
+
+// emptyFromPB converts a pb empty to dto, nil included.
+func emptyFromPB(_ *emptypb.Empty) struct{} {
+	return struct{}{}
+}
+
+// emptyToPB converts a dto empty to pb.
+func emptyToPB(_ struct{}) *emptypb.Empty {
+	return &emptypb.Empty{}
+}
+`, content)
+}
+
+func TestGenerateDTOFieldMask(t *testing.T) {
+	setDefaults()
+	// no path converts back to a nil mask, as a nil mask converts to no path
+	g := newTestDTOGenerator(`package pb
+	import fieldmaskpb "google.golang.org/protobuf/types/known/fieldmaskpb"
+	type UpdateHelloRequest struct {
+		Name       string
+		UpdateMask *fieldmaskpb.FieldMask
+	}`)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import (
+	fieldmaskpb "google.golang.org/protobuf/types/known/fieldmaskpb"
+	testpb "test/pkg/grpc/pb"
+)
+
+type UpdateHelloRequest struct {
+	Name       string   `+"`json:\"name\"`"+`
+	UpdateMask []string `+"`json:\"updateMask\"`"+`
+}
+
+func UpdateHelloRequestFromPB(pb *testpb.UpdateHelloRequest) *UpdateHelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	return &UpdateHelloRequest{
+		Name:       pb.Name,
+		UpdateMask: pb.UpdateMask.GetPaths(),
+	}
+}
+
+func UpdateHelloRequestToPB(orig *UpdateHelloRequest) *testpb.UpdateHelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.UpdateHelloRequest{
+		Name:       orig.Name,
+		UpdateMask: fieldMaskToPB(orig.UpdateMask),
+	}
+}
+
+// fieldMaskToPB converts the paths of a dto field mask to pb, no path converts to nil.
+func fieldMaskToPB(paths []string) *fieldmaskpb.FieldMask {
+	if len(paths) == 0 {
+		return nil
+	}
+	return &fieldmaskpb.FieldMask{Paths: paths}
+}
+`, content)
+}
+
+func TestGenerateDTOWithStringer(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		Name    string
+		Age     int32
+		Tags    []string
+		Address *Address
+	}
+	type Address struct {
+		City string
+	}
+	type Ping struct {
+	}`)
+	g.options.WithStringer = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import (
+	"fmt"
+	testpb "test/pkg/grpc/pb"
+)
+
+type Address struct {
+	City string `+"`json:\"city\"`"+`
+}
+
+func (a *Address) String() string {
+	if a == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("Address{City:%q}", a.City)
+}
+
+func AddressFromPB(pb *testpb.Address) *Address {
+	if pb == nil {
+		return nil
+	}
+
+	return &Address{City: pb.City}
+}
+
+func AddressToPB(orig *Address) *testpb.Address {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.Address{City: orig.City}
+}
+
+type HelloRequest struct {
+	Name    string   `+"`json:\"name\"`"+`
+	Age     int32    `+"`json:\"age\"`"+`
+	Tags    []string `+"`json:\"tags\"`"+`
+	Address *Address `+"`json:\"address\"`"+`
+}
+
+func (h *HelloRequest) String() string {
+	if h == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("HelloRequest{Name:%q Age:%v Tags:%v Address:%v}", h.Name, h.Age, h.Tags, h.Address)
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	return &HelloRequest{
+		Address: AddressFromPB(pb.Address),
+		Age:     pb.Age,
+		Name:    pb.Name,
+		Tags:    pb.Tags,
+	}
+}
+
+func HelloRequestToPB(orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.HelloRequest{
+		Address: AddressToPB(orig.Address),
+		Age:     orig.Age,
+		Name:    orig.Name,
+		Tags:    orig.Tags,
+	}
+}
+`, content)
+}
+
+func TestGenerateDTORedact(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type LoginRequest struct {
+		Username string
+		// @dto:redact
+		Password string
+		Pin      int32 // @dto:redact
+	}`)
+	g.options.WithStringer = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import (
+	"encoding/json"
+	"fmt"
+	testpb "test/pkg/grpc/pb"
+)
+
+type LoginRequest struct {
+	Username string `+"`json:\"username\"`"+`
+	Password string `+"`json:\"password\"`"+`
+	Pin      int32  `+"`json:\"pin\"`"+`
+}
+
+func (l *LoginRequest) String() string {
+	if l == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("LoginRequest{Username:%q Password:[REDACTED] Pin:[REDACTED]}", l.Username)
+}
+
+// MarshalJSON masks the fields of LoginRequest annotated with @dto:redact.
+func (l LoginRequest) MarshalJSON() ([]byte, error) {
+	type plain LoginRequest
+	return json.Marshal(struct {
+		plain
+		Password string `+"`json:\"password\"`"+`
+		Pin      string `+"`json:\"pin\"`"+`
+	}{
+		Password: "[REDACTED]",
+		Pin:      "[REDACTED]",
+		plain:    plain(l),
+	})
+}
+
+func LoginRequestFromPB(pb *testpb.LoginRequest) *LoginRequest {
+	if pb == nil {
+		return nil
+	}
+
+	return &LoginRequest{
+		Password: pb.Password,
+		Pin:      pb.Pin,
+		Username: pb.Username,
+	}
+}
+
+func LoginRequestToPB(orig *LoginRequest) *testpb.LoginRequest {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.LoginRequest{
+		Password: orig.Password,
+		Pin:      orig.Pin,
+		Username: orig.Username,
+	}
+}
+`, content)
+}
+
+func TestGenerateDTOProtoJSONName(t *testing.T) {
+	setDefaults()
+	// the json name declared in proto, e.g. with json_name = "uid", prevails over the one derived from the go name
+	g := newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		UserId      string ` + "`protobuf:\"bytes,1,opt,name=user_id,json=uid,proto3\" json:\"user_id,omitempty\"`" + `
+		DisplayName string ` + "`protobuf:\"bytes,2,opt,name=display_name,json=displayName,proto3\" json:\"display_name,omitempty\"`" + `
+		Name        string ` + "`protobuf:\"bytes,3,opt,name=name,proto3\" json:\"name,omitempty\"`" + `
+	}`)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import testpb "test/pkg/grpc/pb"
+
+type HelloRequest struct {
+	UserId      string `+"`json:\"uid\"`"+`
+	DisplayName string `+"`json:\"displayName\"`"+`
+	Name        string `+"`json:\"name\"`"+`
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	return &HelloRequest{
+		DisplayName: pb.DisplayName,
+		Name:        pb.Name,
+		UserId:      pb.UserId,
+	}
+}
+
+func HelloRequestToPB(orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.HelloRequest{
+		DisplayName: orig.DisplayName,
+		Name:        orig.Name,
+		UserId:      orig.UserId,
+	}
+}
+`, content)
+}
+
+func TestGenerateDTOBuildTags(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		Name string
+	}`)
+	g.options.BuildTags = "dto && !lite"
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `//go:build dto && !lite
+
+// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import testpb "test/pkg/grpc/pb"
+
+type HelloRequest struct {
+	Name string `+"`json:\"name\"`"+`
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	return &HelloRequest{Name: pb.Name}
+}
+
+func HelloRequestToPB(orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.HelloRequest{Name: orig.Name}
+}
+`, content)
+
+	g = newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		Name string
+	}`)
+	g.options.BuildTags = "dto &&"
+	assert.EqualError(t, g.Generate(), "invalid build tags dto &&: unexpected end of expression")
+}
+
+func TestGenerateDTOImportsOnlyReferencedPackages(t *testing.T) {
+	setDefaults()
+	// the dto struct does not reference pb once the duration is mapped, the imports of pb.go unused by the bindings are not imported
+	g := newTestDTOGenerator(`package pb
+	import (
+		protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+		durationpb "google.golang.org/protobuf/types/known/durationpb"
+		emptypb "google.golang.org/protobuf/types/known/emptypb"
+	)
+	type HelloRequest struct {
+		state   protoimpl.MessageState
+		Timeout *durationpb.Duration
+	}`)
+	g.options.Split = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import "time"
+
+type HelloRequest struct {
+	Timeout time.Duration `+"`json:\"timeout\"`"+`
+}
+`, content)
+
+	bindings, _ := g.fs.ReadFile(g.dtoBindingsFileFullPath())
+	assert.Contains(t, bindings, `import (
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	testpb "test/pkg/grpc/pb"
+)`)
+	assert.NotContains(t, bindings, "protoimpl")
+	assert.NotContains(t, bindings, "emptypb")
+}
+
+func TestGenerateDTOBindingNameTemplates(t *testing.T) {
+	setDefaults()
+	// the bindings of the nested structs are called by their templated names too
+	g := newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		Address   *Address
+		Addresses []*Address
+		ByName    map[string]*Address
+	}
+	type Address struct {
+		City string
+	}`)
+	g.options.FromPBTemplate = "FromProto{{.Name}}"
+	g.options.ToPBTemplate = "{{.Name}}ToProtobuf"
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import testpb "test/pkg/grpc/pb"
+
+type Address struct {
+	City string `+"`json:\"city\"`"+`
+}
+
+func FromProtoAddress(pb *testpb.Address) *Address {
+	if pb == nil {
+		return nil
+	}
+
+	return &Address{City: pb.City}
+}
+
+func AddressToProtobuf(orig *Address) *testpb.Address {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.Address{City: orig.City}
+}
+
+type HelloRequest struct {
+	Address   *Address            `+"`json:\"address\"`"+`
+	Addresses []*Address          `+"`json:\"addresses\"`"+`
+	ByName    map[string]*Address `+"`json:\"byName\"`"+`
+}
+
+func FromProtoHelloRequest(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	aSlice := make([]*Address, 0, len(pb.Addresses))
+	for _, v := range pb.Addresses {
+		aSlice = append(aSlice, FromProtoAddress(v))
+	}
+	m := make(map[string]*Address, len(pb.ByName))
+	for k, v := range pb.ByName {
+		m[k] = FromProtoAddress(v)
+	}
+	return &HelloRequest{
+		Address:   FromProtoAddress(pb.Address),
+		Addresses: aSlice,
+		ByName:    m,
+	}
+}
+
+func HelloRequestToProtobuf(orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	aSlice := make([]*testpb.Address, 0, len(orig.Addresses))
+	for _, v := range orig.Addresses {
+		aSlice = append(aSlice, AddressToProtobuf(v))
+	}
+	m := make(map[string]*testpb.Address, len(orig.ByName))
+	for k, v := range orig.ByName {
+		m[k] = AddressToProtobuf(v)
+	}
+	return &testpb.HelloRequest{
+		Address:   AddressToProtobuf(orig.Address),
+		Addresses: aSlice,
+		ByName:    m,
+	}
+}
+`, content)
+
+	for tmpl, expectedErr := range map[string]string{
+		"FromProto{{.Name":   "invalid from pb template FromProto{{.Name: template: from pb:1:",
+		"FromProto{{.Type}}": "invalid from pb template FromProto{{.Type}}: template: from pb:1:",
+		"FromProto":          "invalid from pb template FromProto, expected an exported go identifier made of {{.Name}}, e.g. FromProto{{.Name}}",
+		"fromProto{{.Name}}": "invalid from pb template fromProto{{.Name}}, expected an exported go identifier made of {{.Name}}, e.g. FromProto{{.Name}}",
+	} {
+		g = newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		Name string
+	}`)
+		g.options.FromPBTemplate = tmpl
+		if err := g.Generate(); assert.Error(t, err) {
+			assert.True(t, strings.HasPrefix(err.Error(), expectedErr), err.Error())
+		}
+	}
+
+	g = newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		Name string
+	}`)
+	g.options.FromPBTemplate = "{{.Name}}ToPB"
+	assert.EqualError(t, g.Generate(), "the from pb and to pb templates give the same binding name AToPB")
+}
+
+func TestGenerateDTOPointerToCollection(t *testing.T) {
+	setDefaults()
+	// the dto holds the collections themselves, a nil pointer converting to a nil collection
+	g := newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		Tags      *[]string
+		Addresses *[]*Address
+		ByName    *map[string]*Address
+	}
+	type Address struct {
+		City string
+	}`)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import testpb "test/pkg/grpc/pb"
+
+type Address struct {
+	City string `+"`json:\"city\"`"+`
+}
+
+func AddressFromPB(pb *testpb.Address) *Address {
+	if pb == nil {
+		return nil
+	}
+
+	return &Address{City: pb.City}
+}
+
+func AddressToPB(orig *Address) *testpb.Address {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.Address{City: orig.City}
+}
+
+type HelloRequest struct {
+	Tags      []string            `+"`json:\"tags\"`"+`
+	Addresses []*Address          `+"`json:\"addresses\"`"+`
+	ByName    map[string]*Address `+"`json:\"byName\"`"+`
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	var tags []string
+	if pb.Tags != nil {
+		tags = *pb.Tags
+	}
+	var addresses []*testpb.Address
+	if pb.Addresses != nil {
+		addresses = *pb.Addresses
+	}
+	aSlice := make([]*Address, 0, len(addresses))
+	for _, v := range addresses {
+		aSlice = append(aSlice, AddressFromPB(v))
+	}
+	var byName map[string]*testpb.Address
+	if pb.ByName != nil {
+		byName = *pb.ByName
+	}
+	m := make(map[string]*Address, len(byName))
+	for k, v := range byName {
+		m[k] = AddressFromPB(v)
+	}
+	return &HelloRequest{
+		Addresses: aSlice,
+		ByName:    m,
+		Tags:      tags,
+	}
+}
+
+func HelloRequestToPB(orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	var tags *[]string
+	if orig.Tags != nil {
+		v := orig.Tags
+		tags = &v
+	}
+	aSlice := make([]*testpb.Address, 0, len(orig.Addresses))
+	for _, v := range orig.Addresses {
+		aSlice = append(aSlice, AddressToPB(v))
+	}
+	var addresses *[]*testpb.Address
+	if orig.Addresses != nil {
+		v := aSlice
+		addresses = &v
+	}
+	m := make(map[string]*testpb.Address, len(orig.ByName))
+	for k, v := range orig.ByName {
+		m[k] = AddressToPB(v)
+	}
+	var byName *map[string]*testpb.Address
+	if orig.ByName != nil {
+		v := m
+		byName = &v
+	}
+	return &testpb.HelloRequest{
+		Addresses: addresses,
+		ByName:    byName,
+		Tags:      tags,
+	}
+}
+`, content)
+}
+
+func TestGenerateDTOWithJSONHelpers(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		Name string
+	}`)
+	g.options.WithJSONHelpers = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import (
+	"encoding/json"
+	testpb "test/pkg/grpc/pb"
+)
+
+type HelloRequest struct {
+	Name string `+"`json:\"name\"`"+`
+}
+
+// HelloRequestFromJSON unmarshals a HelloRequest dto from json.
+func HelloRequestFromJSON(data []byte) (*HelloRequest, error) {
+	res := &HelloRequest{}
+	if err := json.Unmarshal(data, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ToJSON marshals the HelloRequest dto to json.
+func (h *HelloRequest) ToJSON() ([]byte, error) {
+	return json.Marshal(h)
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	return &HelloRequest{Name: pb.Name}
+}
+
+func HelloRequestToPB(orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.HelloRequest{Name: orig.Name}
+}
+`, content)
+}
+
+func TestGenerateDTOConversionError(t *testing.T) {
+	setDefaults()
+	// the error of a nested binding is wrapped by the binding calling it, e.g. HelloRequest.Meta.Attributes
+	g := newTestDTOGenerator(`package pb
+	import structpb "google.golang.org/protobuf/types/known/structpb"
+	type HelloRequest struct {
+		Meta *Meta
+	}
+	type Meta struct {
+		Attributes *structpb.Struct
+	}`)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Contains(t, content, `	attributes, err := structpb.NewStruct(orig.Attributes)
+	if err != nil {
+		return nil, conversionError("Meta", "Attributes", err)
+	}`)
+	assert.Contains(t, content, `	meta, err := MetaToPB(orig.Meta)
+	if err != nil {
+		return nil, conversionError("HelloRequest", "Meta", err)
+	}`)
+	assert.Equal(t, 1, strings.Count(content, "type ConversionError struct"))
+
+	// the bindings which cannot fail have no error to wrap
+	g = newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		Name string
+	}`)
+	g.options.FallibleBindings = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ = g.fs.ReadFile(g.dtoFileFullPath)
+	assert.NotContains(t, content, "ConversionError")
+}
+
+func TestGenerateDTOMigrateFrom(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type Status int32
+	var Status_name = map[int32]string{0: "UNKNOWN"}
+	type HelloRequest struct {
+		Name     string
+		Age      int32
+		Status   Status
+		Address  *Address
+		Tags     []*Tag
+		Labels   map[string]Status
+		Nickname string
+	}
+	type Address struct {
+		Street string
+	}
+	type Tag struct {
+		Key string
+	}`)
+	g.options.MigrateFrom = "testV1"
+	g.fs.MkdirAll("testV1/pkg/grpc/pb")
+	g.fs.WriteFile("testV1/pkg/grpc/pb/z_testV1.pb.go", `package pb
+	type Status int32
+	var Status_name = map[int32]string{0: "UNKNOWN"}
+	type HelloRequest struct {
+		Name    string
+		Age     int64
+		Status  Status
+		Address *Address
+		Tags    []*Tag
+		Labels  map[string]Status
+		Email   string
+	}
+	type Address struct {
+		City string
+	}
+	type Tag struct {
+		Key string
+	}`, true)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile("test/pkg/test/dto/z_test_migrate.go")
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import v1dto "testV1/pkg/testV1/dto"
+
+func AddressV1ToV2(v1 *v1dto.Address) *Address {
+	if v1 == nil {
+		return nil
+	}
+
+	res := &Address{}
+	return res
+}
+
+func AddressV2ToV1(v2 *Address) *v1dto.Address {
+	if v2 == nil {
+		return nil
+	}
+
+	res := &v1dto.Address{}
+	return res
+}
+
+func TagV1ToV2(v1 *v1dto.Tag) *Tag {
+	if v1 == nil {
+		return nil
+	}
+
+	res := &Tag{}
+	res.Key = v1.Key
+	return res
+}
+
+func TagV2ToV1(v2 *Tag) *v1dto.Tag {
+	if v2 == nil {
+		return nil
+	}
+
+	res := &v1dto.Tag{}
+	res.Key = v2.Key
+	return res
+}
+
+func HelloRequestV1ToV2(v1 *v1dto.HelloRequest) *HelloRequest {
+	if v1 == nil {
+		return nil
+	}
+
+	res := &HelloRequest{}
+	res.Name = v1.Name
+	res.Status = Status(v1.Status)
+	res.Address = AddressV1ToV2(v1.Address)
+	if v1.Tags != nil {
+		res.Tags = make([]*Tag, len(v1.Tags))
+		for key, value := range v1.Tags {
+			res.Tags[key] = TagV1ToV2(value)
+		}
+	}
+	if v1.Labels != nil {
+		res.Labels = make(map[string]Status, len(v1.Labels))
+		for key, value := range v1.Labels {
+			res.Labels[key] = Status(value)
+		}
+	}
+	return res
+}
+
+func HelloRequestV2ToV1(v2 *HelloRequest) *v1dto.HelloRequest {
+	if v2 == nil {
+		return nil
+	}
+
+	res := &v1dto.HelloRequest{}
+	res.Name = v2.Name
+	res.Status = v1dto.Status(v2.Status)
+	res.Address = AddressV2ToV1(v2.Address)
+	if v2.Tags != nil {
+		res.Tags = make([]*v1dto.Tag, len(v2.Tags))
+		for key, value := range v2.Tags {
+			res.Tags[key] = TagV2ToV1(value)
+		}
+	}
+	if v2.Labels != nil {
+		res.Labels = make(map[string]v1dto.Status, len(v2.Labels))
+		for key, value := range v2.Labels {
+			res.Labels[key] = v1dto.Status(value)
+		}
+	}
+	return res
+}
+`, content)
+	assert.Empty(t, g.Result().Warnings)
+
+	g = newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		Name string
+	}`)
+	g.options.MigrateFrom = "testV0"
+	assert.EqualError(t, g.Generate(), "err reading pb go file to migrate from at: testV0/pkg/grpc/pb/z_testV0.pb.go, err: open testV0/pkg/grpc/pb/z_testV0.pb.go: file does not exist")
+}
+
+func TestGenerateDTOKitignore(t *testing.T) {
+	setDefaults()
+	pbSrc := `package pb
+	type HelloRequest struct {
+		Name     string
+		Password string
+		Address  *Address
+	}
+	type AuditInternalRequest struct {
+		Name string
+	}
+	type Address struct {
+		Street string
+		Secret string
+	}`
+
+	g := newTestDTOGenerator(pbSrc)
+	g.fs.WriteFile(".kitignore", `# internal messages are not exposed
+*Internal*
+
+HelloRequest.Password
+*.Secret # masked everywhere
+`, true)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assertGeneratedInOrder(t, content, "Address", "HelloRequest")
+	assert.NotContains(t, content, "type AuditInternalRequest struct")
+	assert.NotContains(t, content, "Password")
+	assert.NotContains(t, content, "Secret")
+	assert.Equal(t, []string{"Address.Secret", "HelloRequest.Password"}, g.skippedFields)
+
+	g = newTestDTOGenerator(pbSrc)
+	g.fs.WriteFile(".kitignore", "Address\nHello[Request.Name\n", true)
+	assert.EqualError(t, g.Generate(), "invalid pattern Hello[Request.Name at .kitignore:2")
+}
+
+func TestGenerateDTOKitignoreStatError(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		Name string
+	}`)
+	g.fs.Fs = statErrFs{Fs: g.fs.Fs, path: ".kitignore"}
+	assert.EqualError(t, g.Generate(), "err checking .kitignore, err: permission denied")
+}
+
+// statErrFs fails to stat a path, e.g. an unreadable folder
+type statErrFs struct {
+	afero.Fs
+	path string
+}
+
+func (f statErrFs) Stat(name string) (os.FileInfo, error) {
+	if name == f.path {
+		return nil, os.ErrPermission
+	}
+	return f.Fs.Stat(name)
+}
+
+func TestGenerateDTOWithGetters(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	import wrapperspb "google.golang.org/protobuf/types/known/wrapperspb"
+	type HelloRequest struct {
+		Name     string
+		Nickname *string
+		Age      *wrapperspb.Int32Value
+		Tags     []string
+		Address  *Address
+	}
+	type Address struct {
+		Street string
+	}`)
+	g.options.WithGetters = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import (
+	wrapperspb "google.golang.org/protobuf/types/known/wrapperspb"
+	testpb "test/pkg/grpc/pb"
+)
+
+type Address struct {
+	Street string `+"`json:\"street\"`"+`
+}
+
+func (a *Address) GetStreet() (v string) {
+	if a != nil {
+		v = a.Street
+	}
+	return v
+}
+
+func AddressFromPB(pb *testpb.Address) *Address {
+	if pb == nil {
+		return nil
+	}
+
+	return &Address{Street: pb.Street}
+}
+
+func AddressToPB(orig *Address) *testpb.Address {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.Address{Street: orig.Street}
+}
+
+type HelloRequest struct {
+	Name     string   `+"`json:\"name\"`"+`
+	Nickname *string  `+"`json:\"nickname\"`"+`
+	Age      *int32   `+"`json:\"age\"`"+`
+	Tags     []string `+"`json:\"tags\"`"+`
+	Address  *Address `+"`json:\"address\"`"+`
+}
+
+func (h *HelloRequest) GetName() (v string) {
+	if h != nil {
+		v = h.Name
+	}
+	return v
+}
+
+func (h *HelloRequest) GetNickname() (v string) {
+	if h != nil && h.Nickname != nil {
+		v = *h.Nickname
+	}
+	return v
+}
+
+func (h *HelloRequest) GetAge() (v int32) {
+	if h != nil && h.Age != nil {
+		v = *h.Age
+	}
+	return v
+}
+
+func (h *HelloRequest) GetTags() (v []string) {
+	if h != nil {
+		v = h.Tags
+	}
+	return v
+}
+
+func (h *HelloRequest) GetAddress() (v *Address) {
+	if h != nil {
+		v = h.Address
+	}
+	return v
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	return &HelloRequest{
+		Address:  AddressFromPB(pb.Address),
+		Age:      int32ValueFromPB(pb.Age),
+		Name:     pb.Name,
+		Nickname: pb.Nickname,
+		Tags:     pb.Tags,
+	}
+}
+
+func HelloRequestToPB(orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.HelloRequest{
+		Address:  AddressToPB(orig.Address),
+		Age:      int32ValueToPB(orig.Age),
+		Name:     orig.Name,
+		Nickname: orig.Nickname,
+		Tags:     orig.Tags,
+	}
+}
+
+// int32ValueFromPB converts an optional pb int32 to dto, nil stays nil.
+func int32ValueFromPB(v *wrapperspb.Int32Value) *int32 {
+	if v == nil {
+		return nil
+	}
+	value := v.GetValue()
+	return &value
+}
+
+// int32ValueToPB converts an optional dto int32 to pb, nil stays nil.
+func int32ValueToPB(v *int32) *wrapperspb.Int32Value {
+	if v == nil {
+		return nil
+	}
+	return wrapperspb.Int32(*v)
+}
+`, content)
+}
+
+func TestGenerateDTOJSONFloatMode(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		Name    string
+		Score   float64
+		Ratio   *float32
+		Weights []float64
+		// @dto:redact
+		Password string
+	}`)
+	g.options.JSONFloatMode = JSONFloatNull
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import (
+	"encoding/json"
+	"math"
+	testpb "test/pkg/grpc/pb"
+)
+
+type HelloRequest struct {
+	Name     string    `+"`json:\"name\"`"+`
+	Score    float64   `+"`json:\"score\"`"+`
+	Ratio    *float32  `+"`json:\"ratio\"`"+`
+	Weights  []float64 `+"`json:\"weights\"`"+`
+	Password string    `+"`json:\"password\"`"+`
+}
+
+// MarshalJSON masks the fields of HelloRequest annotated with @dto:redact.
+// MarshalJSON marshals the NaN and infinite floats of HelloRequest as null.
+func (h HelloRequest) MarshalJSON() ([]byte, error) {
+	type plain HelloRequest
+	return json.Marshal(struct {
+		plain
+		Password string      `+"`json:\"password\"`"+`
+		Score    interface{} `+"`json:\"score\"`"+`
+		Ratio    interface{} `+"`json:\"ratio\"`"+`
+	}{
+		Password: "[REDACTED]",
+		Ratio:    jsonFloat(h.Ratio),
+		Score:    jsonFloat(h.Score),
+		plain:    plain(h),
+	})
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	return &HelloRequest{
+		Name:     pb.Name,
+		Password: pb.Password,
+		Ratio:    pb.Ratio,
+		Score:    pb.Score,
+		Weights:  pb.Weights,
+	}
+}
+
+func HelloRequestToPB(orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.HelloRequest{
+		Name:     orig.Name,
+		Password: orig.Password,
+		Ratio:    orig.Ratio,
+		Score:    orig.Score,
+		Weights:  orig.Weights,
+	}
+}
+
+// jsonFloat returns the value of a float field to marshal to json, nil for the NaN and infinite floats.
+func jsonFloat(v interface{}) interface{} {
+	if f, ok := floatValue(v); ok && (math.IsNaN(f) || math.IsInf(f, 0)) {
+		return nil
+	}
+	return v
+}
+
+// floatValue returns the value of a float field, ok is false for a nil optional float.
+func floatValue(v interface{}) (f float64, ok bool) {
+	switch v := v.(type) {
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case *float32:
+		if v != nil {
+			return float64(*v), true
+		}
+	case *float64:
+		if v != nil {
+			return *v, true
+		}
+	}
+	return 0, false
+}
+`, content)
+
+	g = newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		Score float64
+	}`)
+	g.options.JSONFloatMode = JSONFloatError
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ = g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Contains(t, content, `// MarshalJSON fails when a float of HelloRequest is NaN or infinite.
+func (h HelloRequest) MarshalJSON() ([]byte, error) {
+	if err := checkJSONFloat("HelloRequest.Score", h.Score); err != nil {
+		return nil, err
+	}
+	type plain HelloRequest
+	return json.Marshal(plain(h))
+}`)
+
+	g = newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		Score float64
+	}`)
+	g.options.JSONFloatMode = JSONFloatString
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ = g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Contains(t, content, `		case math.IsNaN(f):
+			return "NaN"`)
+
+	// without float fields, no MarshalJSON is generated
+	g = newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		Name string
+	}`)
+	g.options.JSONFloatMode = JSONFloatNull
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ = g.fs.ReadFile(g.dtoFileFullPath)
+	assert.NotContains(t, content, "MarshalJSON")
+	assert.NotContains(t, content, "jsonFloat")
+
+	g = newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		Score float64
+	}`)
+	g.options.JSONFloatMode = "zero"
+	assert.EqualError(t, g.Generate(), "unknown json float mode zero, expected one of null, error, string")
+}
+
+func TestGenerateDTOSkipsGenericStructs(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type Box[T any] struct {
+		Value T
+	}
+	type HelloRequest struct {
+		Name string
+		Box  *Box[string]
+	}`)
+	g.options.UnknownTypePolicy = UnknownTypeWarnAndSkip
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assertGeneratedInOrder(t, content, "HelloRequest")
+	assert.NotContains(t, content, "Box")
+	assert.Equal(t, []string{
+		"skipping generic struct Box at test/pkg/grpc/pb/z_test.pb.go:2:7, generic types are not supported",
+		"skipping field of unknown type: HelloRequest.Box (*Box[string]) at test/pkg/grpc/pb/z_test.pb.go:7:3",
+	}, g.Result().Warnings)
+}
+
+func TestGenerateDTOManifest(t *testing.T) {
+	setDefaults()
+	pbSrc := `package pb
+	type Status int32
+	var Status_name = map[int32]string{0: "UNKNOWN"}
+	type Address struct {
+		City string
+	}
+	type HelloRequest struct {
+		Name   string
+		Status Status
+		Home   *Address
+	}`
+	g := newTestDTOGenerator(pbSrc)
+	g.options.Manifest = true
+	g.options.WithStringer = true
+	g.options.JSONSchema = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+
+	// the user methods preserved in the dto file are not listed
+	dtoContent, _ := g.fs.ReadFile("test/pkg/test/dto/z_test_dto.go")
+	g.fs.WriteFile("test/pkg/test/dto/z_test_dto.go", dtoContent+`
+func (h *HelloRequest) Greeting() string {
+	return "hello " + h.Name
+}
+`, true)
+	regenerated := newTestDTOGenerator(pbSrc)
+	regenerated.fs = g.fs
+	regenerated.options = g.options
+	if err := regenerated.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile("test/pkg/test/dto/z_test_dto.manifest.json")
+	assert.Equal(t, `{
+  "$comment": "Code generated by kit g dto. DO NOT EDIT.",
+  "source": "test/pkg/grpc/pb/z_test.pb.go",
+  "files": [
+    "test/pkg/test/dto/z_test_dto.go",
+    "test/pkg/test/dto/z_test_dto.schema.json"
+  ],
+  "symbols": [
+    {
+      "name": "Address",
+      "kind": "type",
+      "file": "test/pkg/test/dto/z_test_dto.go",
+      "source": "Address"
+    },
+    {
+      "name": "Address.String",
+      "kind": "method",
+      "file": "test/pkg/test/dto/z_test_dto.go",
+      "source": "Address"
+    },
+    {
+      "name": "AddressFromPB",
+      "kind": "func",
+      "file": "test/pkg/test/dto/z_test_dto.go",
+      "source": "Address"
+    },
+    {
+      "name": "AddressToPB",
+      "kind": "func",
+      "file": "test/pkg/test/dto/z_test_dto.go",
+      "source": "Address"
+    },
+    {
+      "name": "Status",
+      "kind": "type",
+      "file": "test/pkg/test/dto/z_test_dto.go",
+      "source": "Status"
+    },
+    {
+      "name": "HelloRequest",
+      "kind": "type",
+      "file": "test/pkg/test/dto/z_test_dto.go",
+      "source": "HelloRequest"
+    },
+    {
+      "name": "HelloRequest.String",
+      "kind": "method",
+      "file": "test/pkg/test/dto/z_test_dto.go",
+      "source": "HelloRequest"
+    },
+    {
+      "name": "HelloRequestFromPB",
+      "kind": "func",
+      "file": "test/pkg/test/dto/z_test_dto.go",
+      "source": "HelloRequest"
+    },
+    {
+      "name": "HelloRequestToPB",
+      "kind": "func",
+      "file": "test/pkg/test/dto/z_test_dto.go",
+      "source": "HelloRequest"
+    }
+  ]
+}
+`, content)
+}
+
+func TestGenerateDTOMappingSpecStdImports(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	import (
+		durationpb "google.golang.org/protobuf/types/known/durationpb"
+		timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	)
+	type HelloRequest struct {
+		CreatedAt *timestamppb.Timestamp
+		UpdatedAt *timestamppb.Timestamp
+		Timeout   *durationpb.Duration
+	}`)
+	// the standard packages need no import in the spec
+	g.options.MappingSpec = "test/dto_mapping.yaml"
+	g.fs.WriteFile(g.options.MappingSpec, `
+imports:
+  timestamppb: google.golang.org/protobuf/types/known/timestamppb
+fields:
+  HelloRequest.CreatedAt:
+    type: time.Time
+    from_pb: $.AsTime()
+    to_pb: timestamppb.New($)
+  HelloRequest.UpdatedAt:
+    type: time.Time
+    from_pb: $.AsTime()
+    to_pb: timestamppb.New($)
+`, true)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import (
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	testpb "test/pkg/grpc/pb"
+	"time"
+)
+
+type HelloRequest struct {
+	CreatedAt time.Time     `+"`json:\"createdAt\"`"+`
+	UpdatedAt time.Time     `+"`json:\"updatedAt\"`"+`
+	Timeout   time.Duration `+"`json:\"timeout\"`"+`
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	return &HelloRequest{
+		CreatedAt: pb.CreatedAt.AsTime(),
+		Timeout:   pb.Timeout.AsDuration(),
+		UpdatedAt: pb.UpdatedAt.AsTime(),
+	}
+}
+
+func HelloRequestToPB(orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.HelloRequest{
+		CreatedAt: timestamppb.New(orig.CreatedAt),
+		Timeout:   durationpb.New(orig.Timeout),
+		UpdatedAt: timestamppb.New(orig.UpdatedAt),
+	}
+}
+`, content)
+}
+
+func TestGenerateDTOStdImportCollision(t *testing.T) {
+	setDefaults()
+	pbSrc := `package pb
+	import (
+		durationpb "google.golang.org/protobuf/types/known/durationpb"
+		timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	)
+	type HelloRequest struct {
+		CreatedAt *timestamppb.Timestamp
+		UpdatedAt *timestamppb.Timestamp
+		Timeout   *durationpb.Duration
+		At        *Time
+	}
+	type Time struct {
+		Hour int32
+	}`
+	g := newTestDTOGenerator(pbSrc)
+	g.options.WithRoundTripTests = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	roundTrip, _ := g.fs.ReadFile("test/pkg/test/dto/z_test_dto_roundtrip_test.go")
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import (
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	testpb "test/pkg/grpc/pb"
+	"time"
+)
+
+type Time struct {
+	Hour int32 `+"`json:\"hour\"`"+`
+}
+
+func TimeFromPB(pb *testpb.Time) *Time {
+	if pb == nil {
+		return nil
+	}
+
+	return &Time{Hour: pb.Hour}
+}
+
+func TimeToPB(orig *Time) *testpb.Time {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.Time{Hour: orig.Hour}
+}
+
+type HelloRequest struct {
+	CreatedAt time.Time     `+"`json:\"createdAt\"`"+`
+	UpdatedAt time.Time     `+"`json:\"updatedAt\"`"+`
+	Timeout   time.Duration `+"`json:\"timeout\"`"+`
+	At        *Time         `+"`json:\"at\"`"+`
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	return &HelloRequest{
+		At:        TimeFromPB(pb.At),
+		CreatedAt: timestampFromPB(pb.CreatedAt),
+		Timeout:   pb.Timeout.AsDuration(),
+		UpdatedAt: timestampFromPB(pb.UpdatedAt),
+	}
+}
+
+func HelloRequestToPB(orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.HelloRequest{
+		At:        TimeToPB(orig.At),
+		CreatedAt: timestampToPB(orig.CreatedAt),
+		Timeout:   durationpb.New(orig.Timeout),
+		UpdatedAt: timestampToPB(orig.UpdatedAt),
+	}
+}
+
+// timestampFromPB converts a pb timestamp to dto, nil converts to the zero time.
+func timestampFromPB(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+// timestampToPB converts a dto time to pb, the zero time converts to nil.
+func timestampToPB(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+`, content)
+	assert.Contains(t, roundTrip, "CreatedAt: &timestamppb.Timestamp{Seconds: 1},")
+	typeCheckDTOPackages(t, wellKnownStubs(pbSrc), content, roundTrip)
+
+	// the time package is imported by a dto package named time as well
+	g = newTestDTOGenerator(pbSrc)
+	g.options.DTOPackageName = "time"
+	g.srcFile = g.newDTOFile()
+	g.InitPg()
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ = g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Contains(t, content, "package time\n")
+	assert.Equal(t, 1, strings.Count(content, `"time"`))
+	typeCheckDTOPackages(t, wellKnownStubs(pbSrc), content)
+}
+
+// wellKnownStubs returns the sources of the pb package and of stubs of the well known types it may import, which
+// declare the part of their api the bindings use, for typeCheckDTOPackages
+func wellKnownStubs(pbSrc string) map[string]string {
+	return map[string]string{
+		"test/pkg/grpc/pb": pbSrc,
+		timestamppbImportPath: `package timestamppb
+		import "time"
+		type Timestamp struct {
+			Seconds int64
+			Nanos   int32
+		}
+		func New(t time.Time) *Timestamp {
+			return &Timestamp{Seconds: t.Unix(), Nanos: int32(t.Nanosecond())}
+		}
+		func (x *Timestamp) AsTime() time.Time {
+			return time.Unix(x.Seconds, int64(x.Nanos)).UTC()
+		}`,
+		durationpbImportPath: `package durationpb
+		import "time"
+		type Duration struct {
+			Seconds int64
+			Nanos   int32
+		}
+		func New(d time.Duration) *Duration {
+			return &Duration{Seconds: int64(d / time.Second), Nanos: int32(d % time.Second)}
+		}
+		func (x *Duration) AsDuration() time.Duration {
+			return time.Duration(x.Seconds)*time.Second + time.Duration(x.Nanos)
+		}`,
+		structpbImportPath: `package structpb
+		type Struct struct {
+			Fields map[string]*Value
+		}
+		type Value struct {
+			Kind interface{}
+		}
+		func NewStruct(v map[string]interface{}) (*Struct, error) {
+			return &Struct{}, nil
+		}
+		func (x *Struct) AsMap() map[string]interface{} {
+			return map[string]interface{}{}
+		}
+		func NewValue(v interface{}) (*Value, error) {
+			return &Value{Kind: v}, nil
+		}
+		func NewStringValue(v string) *Value {
+			return &Value{Kind: v}
+		}
+		func (x *Value) AsInterface() interface{} {
+			return x.Kind
+		}`,
+	}
+}
+
+func TestGenerateDTOEmbedBase(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	import timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	type HelloRequest struct {
+		Name      string
+		CreatedAt *timestamppb.Timestamp // @dto:skip
+		Address   *Address
+	}
+	type Address struct {
+		Street string
+	}`)
+	g.options.EmbedBase = "example.com/common/model.BaseModel"
+	// the base of HelloRequest is mapped, the one of Address is skipped by the bindings
+	g.options.MappingSpec = "test/dto_mapping.yaml"
+	g.fs.WriteFile(g.options.MappingSpec, `
+imports:
+  model: example.com/common/model
+  timestamppb: google.golang.org/protobuf/types/known/timestamppb
+fields:
+  HelloRequest.BaseModel:
+    from_pb: "model.BaseModel{CreatedAt: $.CreatedAt.AsTime()}"
+    to_pb: $.CreatedAt = timestamppb.New(orig.CreatedAt)
+`, true)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import (
+	model "example.com/common/model"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	testpb "test/pkg/grpc/pb"
+)
+
+type Address struct {
+	model.BaseModel
+	Street string `+"`json:\"street\"`"+`
+}
+
+func AddressFromPB(pb *testpb.Address) *Address {
+	if pb == nil {
+		return nil
+	}
+
+	return &Address{Street: pb.Street}
+}
+
+func AddressToPB(orig *Address) *testpb.Address {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.Address{Street: orig.Street}
+}
+
+type HelloRequest struct {
+	model.BaseModel
+	Name    string   `+"`json:\"name\"`"+`
+	Address *Address `+"`json:\"address\"`"+`
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	return &HelloRequest{
+		Address:   AddressFromPB(pb.Address),
+		BaseModel: model.BaseModel{CreatedAt: pb.CreatedAt.AsTime()},
+		Name:      pb.Name,
+	}
+}
+
+func HelloRequestToPB(orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	res := &testpb.HelloRequest{
+		Address: AddressToPB(orig.Address),
+		Name:    orig.Name,
+	}
+	res.CreatedAt = timestamppb.New(orig.CreatedAt)
+	return res
+}
+`, content)
+}
+
+func TestGenerateDTOEmbedBaseInvalid(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		Name string
+	}`)
+	g.options.EmbedBase = "example.com/common/model.baseModel"
+
+	assert.EqualError(t, g.Generate(), "invalid embedded base example.com/common/model.baseModel, expected <import path>.<exported type>, e.g. example.com/common/model.BaseModel")
+}
+
+func TestGenerateDTOOpenAPI(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type Status int32
+	var Status_name = map[int32]string{
+		0: "UNKNOWN",
+		2: "DISABLED",
+		1: "OK",
+	}
+	type Address struct {
+		City string
+	}
+	type HelloRequest struct {
+		Name     string
+		Avatar   []byte
+		Status   Status
+		History  []Status
+		Home     *Address
+		Others   []*Address
+		Scores   map[string]float64
+		Nickname *string
+		Mood     *Status
+	}`)
+	g.options.OpenAPI = true
+	g.options.EnumAsString = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	// the generated document is overwritten, unlike a hand-written one
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile("test/pkg/test/dto/z_test_dto.openapi.yaml")
+	assert.Equal(t, `# Code generated by kit g dto. DO NOT EDIT.
+openapi: 3.0.3
+info:
+  title: test dto
+  version: 1.0.0
+paths: {}
+components:
+  schemas:
+    Address:
+      type: object
+      properties:
+        city:
+          type: string
+      required:
+      - city
+    HelloRequest:
+      type: object
+      properties:
+        avatar:
+          type: string
+          format: byte
+          nullable: true
+        history:
+          type: array
+          nullable: true
+          items:
+            $ref: '#/components/schemas/Status'
+        home:
+          nullable: true
+          allOf:
+          - $ref: '#/components/schemas/Address'
+        mood:
+          nullable: true
+          allOf:
+          - $ref: '#/components/schemas/Status'
+        name:
+          type: string
+        nickname:
+          type: string
+          nullable: true
+        others:
+          type: array
+          nullable: true
+          items:
+            $ref: '#/components/schemas/Address'
+        scores:
+          type: object
+          nullable: true
+          additionalProperties:
+            type: number
+            format: double
+        status:
+          $ref: '#/components/schemas/Status'
+      required:
+      - name
+      - status
+    Status:
+      type: string
+      enum:
+      - UNKNOWN
+      - OK
+      - DISABLED
+`, content)
+
+	g.fs.WriteFile("test/pkg/test/dto/z_test_dto.openapi.yaml", "openapi: 3.0.3\n", true)
+	assert.EqualError(t, g.Generate(), "refusing to overwrite test/pkg/test/dto/z_test_dto.openapi.yaml, it is not a generated file")
+}
+
+func TestGenerateDTOIfNewer(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		Name string
+	}`)
+	g.options.IfNewer = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+
+	// pb.go changed before the dto was generated, the dto is up to date
+	g.fs.WriteFile(g.protoGoFileFullPath, `package pb
+	type HelloRequest struct {
+		Name     string
+		Nickname string
+	}`, true)
+	g.fs.Chtimes(g.protoGoFileFullPath, time.Now().Add(-time.Hour))
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.NotContains(t, content, "Nickname")
+
+	// pb.go changed after the dto was generated
+	g.fs.Chtimes(g.protoGoFileFullPath, time.Now().Add(time.Hour))
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ = g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Contains(t, content, "Nickname")
+}
+
+func TestGenerateDTOWithFieldConstants(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		Name     string
+		UserId   string ` + "`protobuf:\"bytes,2,opt,name=user_id,json=userId,proto3\"`" + `
+		Internal string // @json:-
+		Other    *HelloRequestFieldName
+	}
+	type HelloRequestFieldName struct {
+		Value string
+	}`)
+	// the constant of HelloRequest.Name is numbered not to collide with the dto HelloRequestFieldName
+	g.options.WithFieldConstants = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import testpb "test/pkg/grpc/pb"
+
+type HelloRequestFieldName struct {
+	Value string `+"`json:\"value\"`"+`
+}
+
+// the json names of the fields of HelloRequestFieldName
+const (
+	HelloRequestFieldNameFieldValue = "value"
+)
+
+func HelloRequestFieldNameFromPB(pb *testpb.HelloRequestFieldName) *HelloRequestFieldName {
+	if pb == nil {
+		return nil
+	}
+
+	return &HelloRequestFieldName{Value: pb.Value}
+}
+
+func HelloRequestFieldNameToPB(orig *HelloRequestFieldName) *testpb.HelloRequestFieldName {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.HelloRequestFieldName{Value: orig.Value}
+}
+
+type HelloRequest struct {
+	Name     string                 `+"`json:\"name\"`"+`
+	UserId   string                 `+"`json:\"userId\"`"+`
+	Internal string                 `+"`json:\"-\"`"+`
+	Other    *HelloRequestFieldName `+"`json:\"other\"`"+`
+}
+
+// the json names of the fields of HelloRequest
+const (
+	HelloRequestFieldName1  = "name"
+	HelloRequestFieldUserId = "userId"
+	HelloRequestFieldOther  = "other"
+)
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	return &HelloRequest{
+		Internal: pb.Internal,
+		Name:     pb.Name,
+		Other:    HelloRequestFieldNameFromPB(pb.Other),
+		UserId:   pb.UserId,
+	}
+}
+
+func HelloRequestToPB(orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.HelloRequest{
+		Internal: orig.Internal,
+		Name:     orig.Name,
+		Other:    HelloRequestFieldNameToPB(orig.Other),
+		UserId:   orig.UserId,
+	}
+}
+`, content)
+}
+
+func TestGenerateDTOIncludeParents(t *testing.T) {
+	setDefaults()
+	pbSrc := `package pb
+	type Address struct {
+		City string
+	}
+	type Person struct {
+		Home *Address
+	}
+	type HelloRequest struct {
+		Owner  *Person
+		Others map[string]*Person
+	}
+	type Company struct {
+		Headquarters *Address
+	}
+	type ByeRequest struct {
+		Name string
+	}`
+	tests := []struct {
+		name           string
+		includeParents bool
+		exclude        []string
+		want           []string
+	}{
+		{name: "only the subtree of the target", want: []string{"Address"}},
+		{name: "the parents of the target", includeParents: true, want: []string{"Address", "Company", "Person", "HelloRequest"}},
+		{name: "the excluded parents are skipped", includeParents: true, exclude: []string{"Person"}, want: []string{"Address", "Company"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestDTOGenerator(pbSrc)
+			g.targetPBStructName = "Address"
+			g.options.IncludeParents = tt.includeParents
+			g.options.Exclude = tt.exclude
+			if err := g.Generate(); err != nil {
+				t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+			}
+			content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+			assertGeneratedInOrder(t, content, tt.want...)
+			assert.Equal(t, len(tt.want), strings.Count(content, " struct {"))
+		})
+	}
+}
+
+func TestGenerateDTOMaxStructsPerFile(t *testing.T) {
+	setDefaults()
+	pbSrc := `package pb
+	import wrapperspb "google.golang.org/protobuf/types/known/wrapperspb"
+	type A struct {
+		Name *wrapperspb.StringValue
+	}
+	type B struct {
+		A *A
+	}
+	type HelloRequest struct {
+		B *B
+	}
+	type ByeRequest struct {
+		A *A
+	}
+	type ThanksRequest struct {
+		Name string
+	}`
+	g := newTestDTOGenerator(pbSrc)
+	g.options.MaxStructsPerFile = 2
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	files := g.fs.Dump()
+	// a top level struct is grouped with the structs it references, the helpers stay in the dto file
+	assertGeneratedInOrder(t, files["test/pkg/test/dto/z_test_dto_1.go"], "A", "ByeRequest")
+	assertGeneratedInOrder(t, files["test/pkg/test/dto/z_test_dto_2.go"], "B", "HelloRequest")
+	assertGeneratedInOrder(t, files["test/pkg/test/dto/z_test_dto_3.go"], "ThanksRequest")
+	assert.Contains(t, files["test/pkg/test/dto/z_test_dto_1.go"], `import testpb "test/pkg/grpc/pb"`)
+	assert.Contains(t, files["test/pkg/test/dto/z_test_dto.go"], "func stringValueFromPB(")
+	assert.NotContains(t, files["test/pkg/test/dto/z_test_dto.go"], " struct {")
+
+	// the numbered files left over are removed, as are all of them once the structs fit into the dto file
+	regenerated := newTestDTOGenerator(pbSrc)
+	regenerated.fs = g.fs
+	regenerated.options.MaxStructsPerFile = 3
+	if err := regenerated.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	names, _ := g.fs.ReadDir("test/pkg/test/dto")
+	assert.Equal(t, []string{"z_test_dto.go", "z_test_dto_1.go", "z_test_dto_2.go"}, names)
+
+	regenerated = newTestDTOGenerator(pbSrc)
+	regenerated.fs = g.fs
+	regenerated.options.MaxStructsPerFile = 5
+	if err := regenerated.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	names, _ = g.fs.ReadDir("test/pkg/test/dto")
+	assert.Equal(t, []string{"z_test_dto.go"}, names)
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assertGeneratedInOrder(t, content, "A", "B", "ByeRequest", "HelloRequest", "ThanksRequest")
+}
+
+func TestGenerateDTOMaxStructsPerFilePreservesUserMethods(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type BRequest struct {
+		Name string
+	}
+	type CRequest struct {
+		Name string
+	}`)
+	g.options.MaxStructsPerFile = 1
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile("test/pkg/test/dto/z_test_dto_2.go")
+	assertGeneratedInOrder(t, content, "CRequest")
+	userMethod := `
+// Validate validates the request.
+func (x *CRequest) Validate() error {
+	if strings.TrimSpace(x.Name) == "" {
+		return errors.New("name is required")
+	}
+	return nil
+}
+`
+	content = strings.Replace(content, "import testpb \"test/pkg/grpc/pb\"", "import (\n\t\"errors\"\n\t\"strings\"\n\ttestpb \"test/pkg/grpc/pb\"\n)", 1)
+	g.fs.WriteFile("test/pkg/test/dto/z_test_dto_2.go", content+userMethod, true)
+
+	// a struct inserted ahead moves CRequest to another file, its method follows it
+	generatedFs := g.fs
+	pbSrc := `package pb
+	type ARequest struct {
+		Name string
+	}
+	type BRequest struct {
+		Name string
+	}
+	type CRequest struct {
+		Name string
+	}`
+	generatedFs.WriteFile(g.protoGoFileFullPath, pbSrc, true)
+	g = newTestDTOGenerator(pbSrc)
+	g.fs = generatedFs
+	g.options.MaxStructsPerFile = 1
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	files := g.fs.Dump()
+	assertGeneratedInOrder(t, files["test/pkg/test/dto/z_test_dto_2.go"], "BRequest")
+	assert.NotContains(t, files["test/pkg/test/dto/z_test_dto_2.go"], "Validate")
+	assertGeneratedInOrder(t, files["test/pkg/test/dto/z_test_dto_3.go"], "CRequest")
+	assert.Contains(t, files["test/pkg/test/dto/z_test_dto_3.go"], userMethod)
+	assert.Contains(t, files["test/pkg/test/dto/z_test_dto_3.go"], "\"strings\"")
+	assert.Contains(t, files["test/pkg/test/dto/z_test_dto_3.go"], "\"errors\"")
+	typeCheckDTO(t, pbSrc, files["test/pkg/test/dto/z_test_dto.go"], files["test/pkg/test/dto/z_test_dto_1.go"],
+		files["test/pkg/test/dto/z_test_dto_2.go"], files["test/pkg/test/dto/z_test_dto_3.go"])
+
+	// the method follows its type back into the dto file once the numbered files are removed
+	g = newTestDTOGenerator(pbSrc)
+	g.fs = generatedFs
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	names, _ := g.fs.ReadDir("test/pkg/test/dto")
+	assert.Equal(t, []string{"z_test_dto.go"}, names)
+	content, _ = g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Contains(t, content, userMethod)
+	typeCheckDTO(t, pbSrc, content)
+}
+
+func TestGenerateDTONoJSONDirective(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		Name string
+		// the cursor of the next page, computed by the service
+		// @dto:nojson
+		Cursor *string
+	}`)
+	g.options.JSONSchema = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import testpb "test/pkg/grpc/pb"
+
+type HelloRequest struct {
+	Name   string  `+"`json:\"name\"`"+`
+	Cursor *string `+"`json:\"-\"`"+`
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	return &HelloRequest{
+		Cursor: pb.Cursor,
+		Name:   pb.Name,
+	}
+}
+
+func HelloRequestToPB(orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.HelloRequest{
+		Cursor: orig.Cursor,
+		Name:   orig.Name,
+	}
+}
+`, content)
+
+	// the field is not described by the json schema either
+	schema, _ := g.fs.ReadFile("test/pkg/test/dto/z_test_dto.schema.json")
+	assert.NotContains(t, schema, `"-"`)
+}
+
+func TestGenerateDTOToPBNilCollections(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type Address struct {
+		Street string
+	}
+	type HelloRequest struct {
+		Addresses map[string]*Address
+		Homes     []*Address
+	}`)
+	g.options.ToPBNilCollections = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import testpb "test/pkg/grpc/pb"
+
+type Address struct {
+	Street string `+"`json:\"street\"`"+`
+}
+
+func AddressFromPB(pb *testpb.Address) *Address {
+	if pb == nil {
+		return nil
+	}
+
+	return &Address{Street: pb.Street}
+}
+
+func AddressToPB(orig *Address) *testpb.Address {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.Address{Street: orig.Street}
+}
+
+type HelloRequest struct {
+	Addresses map[string]*Address `+"`json:\"addresses\"`"+`
+	Homes     []*Address          `+"`json:\"homes\"`"+`
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	m := make(map[string]*Address, len(pb.Addresses))
+	for k, v := range pb.Addresses {
+		m[k] = AddressFromPB(v)
+	}
+	aSlice := make([]*Address, 0, len(pb.Homes))
+	for _, v := range pb.Homes {
+		aSlice = append(aSlice, AddressFromPB(v))
+	}
+	return &HelloRequest{
+		Addresses: m,
+		Homes:     aSlice,
+	}
+}
+
+func HelloRequestToPB(orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	var m map[string]*testpb.Address
+	if orig.Addresses != nil {
+		m = make(map[string]*testpb.Address, len(orig.Addresses))
+		for k, v := range orig.Addresses {
+			m[k] = AddressToPB(v)
+		}
+	}
+	var aSlice []*testpb.Address
+	if orig.Homes != nil {
+		aSlice = make([]*testpb.Address, 0, len(orig.Homes))
+		for _, v := range orig.Homes {
+			aSlice = append(aSlice, AddressToPB(v))
+		}
+	}
+	return &testpb.HelloRequest{
+		Addresses: m,
+		Homes:     aSlice,
+	}
+}
+`, content)
+}
+
+func TestGenerateDTOPropagateDeprecation(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type HelloRequest struct {
+		Name string
+		// the former name of the greeted
+		//
+		// Deprecated: Marked as deprecated in hello.proto.
+		Nickname string
+	}`)
+	g.options.PropagateDeprecation = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import testpb "test/pkg/grpc/pb"
+
+type HelloRequest struct {
+	Name string `+"`json:\"name\"`"+`
+	// Deprecated: Marked as deprecated in hello.proto.
+	Nickname string `+"`json:\"nickname\"`"+`
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	return &HelloRequest{
+		Name:     pb.Name,
+		Nickname: pb.Nickname,
+	}
+}
+
+func HelloRequestToPB(orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.HelloRequest{
+		Name:     orig.Name,
+		Nickname: orig.Nickname,
+	}
+}
+`, content)
+}
+
+func TestGenerateDTOUnion(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type EventCreated struct {
+		Id string
+	}
+	type EventDeleted struct {
+		Id     string
+		Reason string
+	}`)
+	g.options.MappingSpec = "test/dto_mapping.yaml"
+	g.fs.WriteFile(g.options.MappingSpec, `
+unions:
+  Event:
+    discriminator: kind
+    variants:
+      created: EventCreated
+      deleted: EventDeleted
+`, true)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import (
+	"encoding/json"
+	"fmt"
+	testpb "test/pkg/grpc/pb"
+)
+
+type EventCreated struct {
+	Id string `+"`json:\"id\"`"+`
+}
+
+func EventCreatedFromPB(pb *testpb.EventCreated) *EventCreated {
+	if pb == nil {
+		return nil
+	}
+
+	return &EventCreated{Id: pb.Id}
+}
+
+func EventCreatedToPB(orig *EventCreated) *testpb.EventCreated {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.EventCreated{Id: orig.Id}
+}
+
+type EventDeleted struct {
+	Id     string `+"`json:\"id\"`"+`
+	Reason string `+"`json:\"reason\"`"+`
+}
+
+func EventDeletedFromPB(pb *testpb.EventDeleted) *EventDeleted {
+	if pb == nil {
+		return nil
+	}
+
+	return &EventDeleted{
+		Id:     pb.Id,
+		Reason: pb.Reason,
+	}
+}
+
+func EventDeletedToPB(orig *EventDeleted) *testpb.EventDeleted {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.EventDeleted{
+		Id:     orig.Id,
+		Reason: orig.Reason,
+	}
+}
+
+// Event is the union of *EventCreated, *EventDeleted, telling its variant by its "kind" json field.
+type Event struct {
+	Value EventVariant
+}
+
+// EventVariant is implemented by the variants of Event.
+type EventVariant interface {
+	isEventVariant()
+}
+
+func (*EventCreated) isEventVariant() {}
+
+func (*EventDeleted) isEventVariant() {}
+
+// MarshalJSON marshals the variant of Event along with its "kind" discriminator.
+func (e Event) MarshalJSON() ([]byte, error) {
+	var head string
+	switch e.Value.(type) {
+	case nil:
+		return []byte("null"), nil
+	case *EventCreated:
+		head = "{\"kind\":\"created\""
+	case *EventDeleted:
+		head = "{\"kind\":\"deleted\""
+	default:
+		return nil, fmt.Errorf("unknown Event variant %T", e.Value)
+	}
+	data, err := json.Marshal(e.Value)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 2 || data[0] != '{' {
+		return nil, fmt.Errorf("the Event variant %T is not marshalled to a json object", e.Value)
+	}
+	if len(data) > 2 {
+		head += ","
+	}
+	return append([]byte(head), data[1:]...), nil
+}
+
+// UnmarshalJSON unmarshals the variant of Event its "kind" discriminator tells.
+func (e *Event) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		e.Value = nil
+		return nil
+	}
+	var discriminator struct {
+		Value string `+"`json:\"kind\"`"+`
+	}
+	if err := json.Unmarshal(data, &discriminator); err != nil {
+		return err
+	}
+	switch discriminator.Value {
+	case "created":
+		e.Value = &EventCreated{}
+	case "deleted":
+		e.Value = &EventDeleted{}
+	default:
+		return fmt.Errorf("unknown Event variant %q", discriminator.Value)
+	}
+	return json.Unmarshal(data, e.Value)
+}
+`, content)
+}
+
+func TestGenerateDTOUnionIncompatibleDiscriminators(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+		want string
+	}{
+		{
+			name: "discriminator colliding with a field",
+			spec: `
+unions:
+  Event:
+    variants:
+      created: EventCreated
+      deleted: EventDeleted
+`,
+			want: "union Event discriminator type collides with the json field of EventDeleted.Type",
+		},
+		{
+			name: "variant of unions with different discriminators",
+			spec: `
+unions:
+  Event:
+    discriminator: kind
+    variants:
+      created: EventCreated
+  Change:
+    discriminator: op
+    variants:
+      create: EventCreated
+`,
+			want: "pb struct EventCreated is a variant of the unions Change and Event with incompatible discriminators op and kind",
+		},
+		{
+			name: "variant with several discriminators",
+			spec: `
+unions:
+  Event:
+    variants:
+      created: EventCreated
+      added: EventCreated
+`,
+			want: "err parsing mapping spec at: test/dto_mapping.yaml, err: union Event has incompatible discriminators added and created for the same variant EventCreated",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setDefaults()
+			g := newTestDTOGenerator(`package pb
+	type EventCreated struct {
+		Id string
+	}
+	type EventDeleted struct {
+		Id   string
+		Type string
+	}`)
+			g.options.MappingSpec = "test/dto_mapping.yaml"
+			g.fs.WriteFile(g.options.MappingSpec, tt.spec, true)
+
+			assert.EqualError(t, g.Generate(), tt.want)
+		})
+	}
+}
+
+func TestGenerateDTOMapKeys(t *testing.T) {
+	setDefaults()
+	pbSrc := `package pb
+	type Status int32
+	const (
+		Status_UNKNOWN Status = 0
+		Status_ACTIVE  Status = 1
+	)
+	var (
+		Status_name = map[int32]string{
+			0: "UNKNOWN",
+			1: "ACTIVE",
+		}
+	)
+	type Address struct {
+		Street string
+	}
+	type HelloRequest struct {
+		Labels   map[string]string
+		ByStatus map[Status]*Address
+	}`
+	g := newTestDTOGenerator(pbSrc)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import testpb "test/pkg/grpc/pb"
+
+type Address struct {
+	Street string `+"`json:\"street\"`"+`
+}
+
+func AddressFromPB(pb *testpb.Address) *Address {
+	if pb == nil {
+		return nil
+	}
+
+	return &Address{Street: pb.Street}
+}
+
+func AddressToPB(orig *Address) *testpb.Address {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.Address{Street: orig.Street}
+}
+
+type HelloRequest struct {
+	Labels   map[string]string          `+"`json:\"labels\"`"+`
+	ByStatus map[testpb.Status]*Address `+"`json:\"byStatus\"`"+`
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	m := make(map[testpb.Status]*Address, len(pb.ByStatus))
+	for k, v := range pb.ByStatus {
+		m[k] = AddressFromPB(v)
+	}
+	return &HelloRequest{
+		ByStatus: m,
+		Labels:   pb.Labels,
+	}
+}
+
+func HelloRequestToPB(orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	m := make(map[testpb.Status]*testpb.Address, len(orig.ByStatus))
+	for k, v := range orig.ByStatus {
+		m[k] = AddressToPB(v)
+	}
+	return &testpb.HelloRequest{
+		ByStatus: m,
+		Labels:   orig.Labels,
+	}
+}
+`, content)
+	typeCheckDTO(t, pbSrc, content)
+}
+
+func TestGenerateDTOWithSamplesOptionalFields(t *testing.T) {
+	setDefaults()
+	pbSrc := `package pb
+	type Status int32
+	var Status_name = map[int32]string{0: "UNKNOWN", 1: "OK"}
+	type HelloRequest struct {
+		Name           string
+		OptionalBool   *bool
+		OptionalInt64  *int64
+		OptionalStatus *Status
+	}`
+	g := newTestDTOGenerator(pbSrc)
+	g.options.WithSamples = true
+	g.options.WithRoundTripTests = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	samples, _ := g.fs.ReadFile("test/pkg/test/dto/z_test_dto_samples.go")
+	roundTrip, _ := g.fs.ReadFile("test/pkg/test/dto/z_test_dto_roundtrip_test.go")
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import testpb "test/pkg/grpc/pb"
+
+// SampleHelloRequest returns a HelloRequest dto whose fields hold deterministic non zero sample values.
+func SampleHelloRequest() *HelloRequest {
+	return HelloRequestFromPB(&testpb.HelloRequest{
+		Name:           "a",
+		OptionalBool:   samplePtrBool(true),
+		OptionalInt64:  samplePtrInt64(1),
+		OptionalStatus: samplePtrStatus(testpb.Status(1)),
+	})
+}
+
+func samplePtrBool(v bool) *bool {
+	return &v
+}
+
+func samplePtrInt64(v int64) *int64 {
+	return &v
+}
+
+func samplePtrStatus(v testpb.Status) *testpb.Status {
+	return &v
+}
+`, samples)
+	// the helpers of the samples and of the round trip tests share the dto package
+	typeCheckDTO(t, pbSrc, content, samples, roundTrip)
+}
+
+func TestGenerateDTOMapKeyUnsupported(t *testing.T) {
+	setDefaults()
+	g := newTestDTOGenerator(`package pb
+	type Address struct {
+		Street string
+	}
+	type HelloRequest struct {
+		Labels   map[string]string
+		Visitors map[Address]int32
+	}`)
+
+	assert.EqualError(t, g.Generate(), "map fields of unsupported key type: HelloRequest.Visitors (map[Address]int32) at test/pkg/grpc/pb/z_test.pb.go:7:3, the keys must be strings, bools, numbers or enums")
+}
+
+func TestGenerateDTOWithSamples(t *testing.T) {
+	setDefaults()
+	pbSrc := `package pb
+	type Status int32
+	var Status_name = map[int32]string{0: "UNKNOWN", 1: "OK"}
+	type Address struct {
+		City string
+	}
+	type Node struct {
+		Name     string
+		Children []*Node
+	}
+	type HelloRequest struct {
+		Name   string
+		Status Status
+		Home   *Address
+		Tags   []string
+		Homes  map[string]*Address
+		Tree   *Node
+	}`
+	g := newTestDTOGenerator(pbSrc)
+	g.options.WithSamples = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile("test/pkg/test/dto/z_test_dto_samples.go")
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import testpb "test/pkg/grpc/pb"
+
+// SampleAddress returns a Address dto whose fields hold deterministic non zero sample values.
+func SampleAddress() *Address {
+	return AddressFromPB(&testpb.Address{City: "a"})
+}
+
+// SampleNode returns a Node dto whose fields hold deterministic non zero sample values.
+func SampleNode() *Node {
+	return NodeFromPB(&testpb.Node{
+		Children: []*testpb.Node{},
+		Name:     "a",
+	})
+}
+
+// SampleHelloRequest returns a HelloRequest dto whose fields hold deterministic non zero sample values.
+func SampleHelloRequest() *HelloRequest {
+	return HelloRequestFromPB(&testpb.HelloRequest{
+		Home:   &testpb.Address{City: "a"},
+		Homes:  map[string]*testpb.Address{"a": &testpb.Address{City: "a"}},
+		Name:   "a",
+		Status: testpb.Status(1),
+		Tags:   []string{"a"},
+		Tree: &testpb.Node{
+			Children: []*testpb.Node{},
+			Name:     "a",
+		},
+	})
+}
+`, content)
+	dto, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	typeCheckDTO(t, pbSrc, dto, content)
+}
+
+func TestGenerateDTOMaxDepth(t *testing.T) {
+	setDefaults()
+	// a chain of 12 nested structs, Level0Request -> Level1 -> ... -> Level11
+	src := "package pb\n"
+	for i := 0; i < 12; i++ {
+		name := fmt.Sprintf("Level%d", i)
+		if i == 0 {
+			name += "Request"
+		}
+		src += fmt.Sprintf("type %s struct {\n\tName string\n", name)
+		if i < 11 {
+			src += fmt.Sprintf("\tNext *Level%d\n", i+1)
+		}
+		src += "}\n"
+	}
+	g := newTestDTOGenerator(src)
+	g.options.MaxDepth = 10
+
+	assert.EqualError(t, g.Generate(), "nested structs Level0Request -> Level1 -> Level2 -> Level3 -> Level4 -> Level5 -> Level6 -> Level7 -> Level8 -> Level9 -> Level10 exceed the max depth of 10, see --max-depth")
+
+	g = newTestDTOGenerator(src)
+	g.options.MaxDepth = 12
+	assert.NoError(t, g.Generate())
+}
+
+func TestGenerateDTOOutFileName(t *testing.T) {
+	setDefaults()
+	pbSrc := `package pb
+	type HelloRequest struct {
+		Name string
+	}`
+	g := newTestDTOGenerator(pbSrc)
+	g.options.OutFileName = "{{.Service}}_dto.gen.go"
+	g.options.Split = true
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, err := g.fs.ReadFile("test/pkg/test/dto/test_dto.gen.go")
+	assert.NoError(t, err)
+	assert.Contains(t, content, "type HelloRequest struct")
+	content, err = g.fs.ReadFile("test/pkg/test/dto/test_dto_bindings.gen.go")
+	assert.NoError(t, err)
+	assert.Contains(t, content, "func HelloRequestFromPB(")
+	exists, _ := g.fs.Exists("test/pkg/test/dto/z_test_dto.go")
+	assert.False(t, exists)
+
+	// a hand-written file of the chosen name is not overwritten
+	g = newTestDTOGenerator(pbSrc)
+	g.options.OutFileName = "dto.go"
+	g.fs.WriteFile("test/pkg/test/dto/dto.go", "package dto\n", true)
+	assert.EqualError(t, g.Generate(), "refusing to overwrite test/pkg/test/dto/dto.go, it is not a generated file")
+
+	for _, outFileName := range []string{"{{.Service}}_dto", "../{{.Service}}_dto.go", "{{.Service}}_dto_test.go", "_dto.go"} {
+		g = newTestDTOGenerator(pbSrc)
+		g.options.OutFileName = outFileName
+		assert.EqualError(t, g.Generate(), "invalid out file name "+outFileName+", expected the name of a go file of the dto package, e.g. {{.Service}}_dto.gen.go")
+	}
+}
+
+func TestGenerateDTOMapKeyValueVariations(t *testing.T) {
+	setDefaults()
+	// the message values go through the bindings whatever the key, the primitive values, bytes included, are assigned as is
+	g := newTestDTOGenerator(`package pb
+	type Address struct {
+		Street string
+	}
+	type HelloRequest struct {
+		ByID   map[int32]*Address
+		ByName map[string]*Address
+		Labels map[string]string
+		Blobs  map[string][]byte
+	}`)
+	if err := g.Generate(); err != nil {
+		t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+	}
+	content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+	assert.Equal(t, `// Code generated by kit g dto. DO NOT EDIT.
+// source: test/pkg/grpc/pb/z_test.pb.go
+package dto
+
+import testpb "test/pkg/grpc/pb"
+
+type Address struct {
+	Street string `+"`json:\"street\"`"+`
+}
+
+func AddressFromPB(pb *testpb.Address) *Address {
+	if pb == nil {
+		return nil
+	}
+
+	return &Address{Street: pb.Street}
+}
+
+func AddressToPB(orig *Address) *testpb.Address {
+	if orig == nil {
+		return nil
+	}
+
+	return &testpb.Address{Street: orig.Street}
+}
+
+type HelloRequest struct {
+	ByID   map[int32]*Address  `+"`json:\"byID\"`"+`
+	ByName map[string]*Address `+"`json:\"byName\"`"+`
+	Labels map[string]string   `+"`json:\"labels\"`"+`
+	Blobs  map[string][]byte   `+"`json:\"blobs\"`"+`
+}
+
+func HelloRequestFromPB(pb *testpb.HelloRequest) *HelloRequest {
+	if pb == nil {
+		return nil
+	}
+
+	m := make(map[int32]*Address, len(pb.ByID))
+	for k, v := range pb.ByID {
+		m[k] = AddressFromPB(v)
+	}
+	m1 := make(map[string]*Address, len(pb.ByName))
+	for k, v := range pb.ByName {
+		m1[k] = AddressFromPB(v)
+	}
+	return &HelloRequest{
+		Blobs:  pb.Blobs,
+		ByID:   m,
+		ByName: m1,
+		Labels: pb.Labels,
+	}
+}
+
+func HelloRequestToPB(orig *HelloRequest) *testpb.HelloRequest {
+	if orig == nil {
+		return nil
+	}
+
+	m := make(map[int32]*testpb.Address, len(orig.ByID))
+	for k, v := range orig.ByID {
+		m[k] = AddressToPB(v)
+	}
+	m1 := make(map[string]*testpb.Address, len(orig.ByName))
+	for k, v := range orig.ByName {
+		m1[k] = AddressToPB(v)
+	}
+	return &testpb.HelloRequest{
+		Blobs:  orig.Blobs,
+		ByID:   m,
+		ByName: m1,
+		Labels: orig.Labels,
+	}
+}
+`, content)
+}
+
+// typeCheckDTO type checks the generated files of the dto package against the pb.go source they are generated from
+func typeCheckDTO(t *testing.T, pbSrc string, dtoSrcs ...string) {
+	typeCheckDTOPackages(t, map[string]string{"test/pkg/grpc/pb": pbSrc}, dtoSrcs...)
+}
+
+// typeCheckDTOPackages type checks the generated files of the dto package against the sources of the packages they import,
+// keyed by import path, e.g. the pb package and the pb packages it imports, the standard packages being imported from GOROOT
+func typeCheckDTOPackages(t *testing.T, pkgSrcs map[string]string, dtoSrcs ...string) {
+	fset := token.NewFileSet()
+	std := importer.ForCompiler(fset, "source", nil)
+	pkgs := map[string]*types.Package{}
+	var imp testImporter
+	imp = func(path string) (*types.Package, error) {
+		if pkg, ok := pkgs[path]; ok {
+			return pkg, nil
+		}
+		src, ok := pkgSrcs[path]
+		if !ok {
+			return std.Import(path)
+		}
+		file, err := goparser.ParseFile(fset, path+"/z.pb.go", src, 0)
+		if err != nil {
+			t.Fatalf("%s does not parse: %v", path, err)
+		}
+		pkg, err := (&types.Config{Importer: imp}).Check(path, fset, []*ast.File{file}, nil)
+		if err != nil {
+			t.Fatalf("%s does not type check: %v", path, err)
+		}
+		pkgs[path] = pkg
+		return pkg, nil
+	}
+	dtoFiles := []*ast.File{}
+	for i, dtoSrc := range dtoSrcs {
+		dtoFile, err := goparser.ParseFile(fset, fmt.Sprintf("dto%d.go", i), dtoSrc, 0)
+		if err != nil {
+			t.Fatalf("generated dto does not parse: %v", err)
+		}
+		dtoFiles = append(dtoFiles, dtoFile)
+	}
+	if _, err := (&types.Config{Importer: imp}).Check("test/pkg/test/dto", fset, dtoFiles, nil); err != nil {
+		t.Errorf("generated dto does not type check: %v", err)
+	}
+}
+
+// runDTOTest runs a test of the generated files of the dto package with go test, in a module holding them along
+// with the pb package, it is skipped in short mode
+func runDTOTest(t *testing.T, pbSrc, testSrc string, dtoSrcs ...string) {
+	if testing.Short() {
+		t.Skip("running go test is skipped in short mode")
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go is not installed")
+	}
+	dir, err := ioutil.TempDir("", "kit-dto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"go.mod":                     "module test\n\ngo 1.12\n",
+		"pkg/grpc/pb/z_test.pb.go":   pbSrc,
+		"pkg/test/dto/z_dto_test.go": testSrc,
+	}
+	for i, dtoSrc := range dtoSrcs {
+		files[fmt.Sprintf("pkg/test/dto/dto%d.go", i)] = dtoSrc
+	}
+	for name, src := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cmd := exec.Command(goBin, "test", "./pkg/test/dto")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOWORK=off")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("go test of the generated dto failed: %v\n%s", err, out)
+	}
+}
+
+// testImporter imports a package by path with a func
+type testImporter func(path string) (*types.Package, error)
+
+func (i testImporter) Import(path string) (*types.Package, error) {
+	return i(path)
+}
+
+func TestGenerateDTOContextImport(t *testing.T) {
+	pbSrc := `package pb
+	type Address struct {
+		City string
+	}
+	type HelloRequest struct {
+		Home  *Address
+		Homes []*Address
+	}`
+	for _, ctxBindings := range []bool{false, true} {
+		t.Run(fmt.Sprintf("ctx bindings %v", ctxBindings), func(t *testing.T) {
+			setDefaults()
+			g := newTestDTOGenerator(pbSrc)
+			g.options.CtxBindings = ctxBindings
+			g.options.WithRegistry = true
+			g.options.WithSamples = true
+			if err := g.Generate(); err != nil {
+				t.Fatalf("GenerateDTOFromProtoGo.Generate() error = %v", err)
+			}
+			content, _ := g.fs.ReadFile(g.dtoFileFullPath)
+			samples, _ := g.fs.ReadFile("test/pkg/test/dto/z_test_dto_samples.go")
+
+			// the samples call the bindings with context.Background() in ctx mode only
+			for _, src := range []string{content, samples} {
+				imports := 0
+				if ctxBindings {
+					imports = 1
+				}
+				assert.Equal(t, imports, strings.Count(src, `"context"`))
+			}
+			typeCheckDTO(t, pbSrc, content, samples)
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dave/jennifer/jen"
+	"github.com/kujtimiihoxha/kit/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// unionNames returns the names of the unions of the mapping spec, sorted
+func (g *GenerateDTOFromProtoGo) unionNames() []string {
+	names := []string{}
+	if g.mappingSpec == nil {
+		return names
+	}
+	for name := range g.mappingSpec.Unions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// unionValues returns the values of the discriminator of a union, sorted
+func unionValues(union *unionSpec) []string {
+	values := []string{}
+	for value := range union.Variants {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	return values
+}
+
+// validateUnions checks that the variants of the unions of the mapping spec are pb structs, that a union is not named
+// as a dto, and that a pb struct being a variant of several unions has the same discriminator in all of them,
+// its json telling a single variant
+func (g *GenerateDTOFromProtoGo) validateUnions(pbStructManifest map[string]*structState) error {
+	dtoTypeNames := map[string]string{}
+	for pbStructName := range pbStructManifest {
+		dtoTypeNames[g.dtoTypeName(pbStructName)] = pbStructName
+	}
+	discriminators := map[string]string{}
+	unionOf := map[string]string{}
+	for _, name := range g.unionNames() {
+		union := g.mappingSpec.Unions[name]
+		if pbStructName, ok := dtoTypeNames[g.dtoTypeName(name)]; ok {
+			return fmt.Errorf("union %s collides with the dto of pb struct %s", name, pbStructName)
+		}
+		for _, value := range unionValues(union) {
+			pbStructName := union.Variants[value]
+			structState, ok := pbStructManifest[pbStructName]
+			if !ok {
+				return fmt.Errorf("union %s variant %s refers to an unknown pb struct %s", name, value, pbStructName)
+			}
+			if isMapEntry(structState.Struct) {
+				return fmt.Errorf("union %s variant %s refers to the map entry %s, which has no dto", name, value, pbStructName)
+			}
+			if other, ok := unionOf[pbStructName]; ok && discriminators[pbStructName] != union.Discriminator {
+				return fmt.Errorf("pb struct %s is a variant of the unions %s and %s with incompatible discriminators %s and %s",
+					pbStructName, other, name, discriminators[pbStructName], union.Discriminator)
+			}
+			unionOf[pbStructName], discriminators[pbStructName] = name, union.Discriminator
+		}
+	}
+	return nil
+}
+
+// unionVariants returns the pb structs of the union variants which are not roots already, the variants being generated
+// as the *Request / *Response structs, the excluded ones left out
+func (g *GenerateDTOFromProtoGo) unionVariants(roots []string) []string {
+	isRoot := map[string]bool{}
+	for _, root := range roots {
+		isRoot[root] = true
+	}
+	variants := []string{}
+	for _, name := range g.unionNames() {
+		union := g.mappingSpec.Unions[name]
+		for _, value := range unionValues(union) {
+			pbStructName := union.Variants[value]
+			if isRoot[pbStructName] || g.isExcluded(pbStructName) {
+				continue
+			}
+			isRoot[pbStructName] = true
+			variants = append(variants, pbStructName)
+		}
+	}
+	return variants
+}
+
+// genUnions generates the unions of the mapping spec whose variants are all generated, a union is skipped with a warning
+// otherwise, e.g. when one is excluded, it fails when a variant has a json field named as the discriminator
+func (g *GenerateDTOFromProtoGo) genUnions(order []string) error {
+	generated := map[string]bool{}
+	for _, pbStructName := range order {
+		generated[pbStructName] = true
+	}
+	for _, name := range g.unionNames() {
+		union := g.mappingSpec.Unions[name]
+		missing := ""
+		for _, value := range unionValues(union) {
+			pbStructName := union.Variants[value]
+			if !generated[pbStructName] {
+				missing = pbStructName
+				break
+			}
+			for _, fieldState := range g.fieldManifests[pbStructName] {
+				if fieldState.JSONName == union.Discriminator {
+					return fmt.Errorf("union %s discriminator %s collides with the json field of %s.%s", name, union.Discriminator, pbStructName, fieldState.Name)
+				}
+			}
+		}
+		if missing != "" {
+			if g.targetPBStructName == "" {
+				g.warnf("skipping union %s, its variant %s is not generated", name, missing)
+			} else {
+				logrus.Info("skipping union ", name, ", its variant ", missing, " is not generated")
+			}
+			continue
+		}
+		g.genUnion(name, union)
+		g.result.Structs = append(g.result.Structs, g.dtoTypeName(name))
+	}
+	return nil
+}
+
+// genUnion generates a tagged union dto holding the dto of one of its variants, (un)marshalled to json as that dto
+// along with its discriminator, e.g. {"type": "created", "id": "1"} for an EventCreated variant, e.g.
+//
+//	type Event struct {
+//		Value EventVariant
+//	}
+//
+//	type EventVariant interface {
+//		isEventVariant()
+//	}
+//
+//	func (e Event) MarshalJSON() ([]byte, error) {...}
+//	func (e *Event) UnmarshalJSON(data []byte) error {...}
+func (g *GenerateDTOFromProtoGo) genUnion(unionName string, union *unionSpec) {
+	logrus.Info("generating union dto for: ", unionName)
+	dtoTypeName := g.dtoTypeName(unionName)
+	variantTypeName := dtoTypeName + "Variant"
+	isVariant := "is" + variantTypeName
+	values := unionValues(union)
+	variantTypeNames := []string{}
+	for _, value := range values {
+		variantTypeNames = append(variantTypeNames, "*"+g.dtoTypeName(union.Variants[value]))
+	}
+
+	g.code.appendMultilineComment([]string{fmt.Sprintf("%s is the union of %s, telling its variant by its %q json field.",
+		dtoTypeName, strings.Join(variantTypeNames, ", "), union.Discriminator)})
+	g.code.NewLine()
+	g.code.appendStruct(dtoTypeName, jen.Id("Value").Id(variantTypeName))
+	g.code.NewLine()
+	g.code.appendMultilineComment([]string{fmt.Sprintf("%s is implemented by the variants of %s.", variantTypeName, dtoTypeName)})
+	g.code.NewLine()
+	g.code.Raw().Type().Id(variantTypeName).Interface(jen.Id(isVariant).Params()).Line()
+	g.code.NewLine()
+	for _, variantType := range variantTypeNames {
+		g.code.Raw().Func().Params(jen.Id(variantType)).Id(isVariant).Params().Block().Line()
+		g.code.NewLine()
+	}
+
+	recv := utils.ReceiverName(dtoTypeName, "data", "head", "discriminator", "err")
+	marshalCases := []jen.Code{jen.Case(jen.Nil()).Block(jen.Return(jen.Index().Byte().Parens(jen.Lit("null")), jen.Nil()))}
+	unmarshalCases := []jen.Code{}
+	for i, value := range values {
+		discriminator, _ := json.Marshal(map[string]string{union.Discriminator: value})
+		marshalCases = append(marshalCases, jen.Case(jen.Id(variantTypeNames[i])).Block(
+			jen.Id("head").Op("=").Lit(strings.TrimSuffix(string(discriminator), "}")),
+		))
+		unmarshalCases = append(unmarshalCases, jen.Case(jen.Lit(value)).Block(
+			jen.Id(recv).Dot("Value").Op("=").Op("&").Id(g.dtoTypeName(union.Variants[value])).Values(),
+		))
+	}
+	marshalCases = append(marshalCases, jen.Default().Block(
+		jen.Return(jen.Nil(), jen.Qual("fmt", "Errorf").Call(jen.Lit("unknown "+dtoTypeName+" variant %T"), jen.Id(recv).Dot("Value"))),
+	))
+	unmarshalCases = append(unmarshalCases, jen.Default().Block(
+		jen.Return(jen.Qual("fmt", "Errorf").Call(jen.Lit("unknown "+dtoTypeName+" variant %q"), jen.Id("discriminator").Dot("Value"))),
+	))
+
+	g.code.appendMultilineComment([]string{fmt.Sprintf("MarshalJSON marshals the variant of %s along with its %q discriminator.", dtoTypeName, union.Discriminator)})
+	g.code.NewLine()
+	g.code.appendFunction(
+		"MarshalJSON",
+		jen.Id(recv).Id(dtoTypeName),
+		[]jen.Code{},
+		[]jen.Code{jen.Index().Byte(), jen.Error()},
+		"",
+		jen.Var().Id("head").String(),
+		jen.Switch(jen.Id(recv).Dot("Value").Assert(jen.Type())).Block(marshalCases...),
+		jen.List(jen.Id("data"), jen.Err()).Op(":=").Qual("encoding/json", "Marshal").Call(jen.Id(recv).Dot("Value")),
+		jen.If(jen.Err().Op("!=").Nil()).Block(jen.Return(jen.Nil(), jen.Err())),
+		jen.If(jen.Len(jen.Id("data")).Op("<").Lit(2).Op("||").Id("data").Index(jen.Lit(0)).Op("!=").LitRune('{')).Block(
+			jen.Return(jen.Nil(), jen.Qual("fmt", "Errorf").Call(jen.Lit("the "+dtoTypeName+" variant %T is not marshalled to a json object"), jen.Id(recv).Dot("Value"))),
+		),
+		jen.If(jen.Len(jen.Id("data")).Op(">").Lit(2)).Block(jen.Id("head").Op("+=").Lit(",")),
+		jen.Return(jen.Append(jen.Index().Byte().Parens(jen.Id("head")), jen.Id("data").Index(jen.Lit(1), jen.Empty()).Op("...")), jen.Nil()),
+	)
+	g.code.NewLine()
+	g.code.NewLine()
+
+	g.code.appendMultilineComment([]string{fmt.Sprintf("UnmarshalJSON unmarshals the variant of %s its %q discriminator tells.", dtoTypeName, union.Discriminator)})
+	g.code.NewLine()
+	g.code.appendFunction(
+		"UnmarshalJSON",
+		jen.Id(recv).Id("*"+dtoTypeName),
+		[]jen.Code{jen.Id("data").Index().Byte()},
+		[]jen.Code{},
+		"error",
+		jen.If(jen.String().Parens(jen.Id("data")).Op("==").Lit("null")).Block(
+			jen.Id(recv).Dot("Value").Op("=").Nil(),
+			jen.Return(jen.Nil()),
+		),
+		jen.Var().Id("discriminator").Struct(jen.Id("Value").String().Tag(map[string]string{"json": union.Discriminator})),
+		jen.If(jen.Err().Op(":=").Qual("encoding/json", "Unmarshal").Call(jen.Id("data"), jen.Op("&").Id("discriminator")), jen.Err().Op("!=").Nil()).
+			Block(jen.Return(jen.Err())),
+		jen.Switch(jen.Id("discriminator").Dot("Value")).Block(unmarshalCases...),
+		jen.Return(jen.Qual("encoding/json", "Unmarshal").Call(jen.Id("data"), jen.Id(recv).Dot("Value"))),
+	)
+	g.code.NewLine()
+	g.code.NewLine()
+}
@@ -1,6 +1,7 @@
 package generator
 
 import (
+	"errors"
 	"fmt"
 	"path"
 	"strings"
@@ -32,16 +33,21 @@ type GenerateService struct {
 	file                     *parser.File
 	serviceInterface         parser.Interface
 	sMiddleware, eMiddleware bool
+	strict                   bool
 }
 
 // NewGenerateService returns a initialized and ready generator.
-func NewGenerateService(name, transport, pbPath, pbImportPath string, sMiddleware, eMiddleware bool, methods []string) Gen {
+//
+// When strict is set the generation fails if a service method does not take a context.Context as its first parameter,
+// otherwise a warning is logged.
+func NewGenerateService(name, transport, pbPath, pbImportPath string, sMiddleware, eMiddleware, strict bool, methods []string) Gen {
 	i := &GenerateService{
 		name:          name,
 		interfaceName: utils.ToCamelCase(name + "Service"),
 		destPath:      fmt.Sprintf(viper.GetString("gk_service_path_format"), utils.ToLowerSnakeCase(name)),
 		sMiddleware:   sMiddleware,
 		eMiddleware:   eMiddleware,
+		strict:        strict,
 		methods:       methods,
 	}
 	i.filePath = path.Join(i.destPath, viper.GetString("gk_service_file_name"))
@@ -84,6 +90,14 @@ func (g *GenerateService) Generate() (err error) {
 	if !g.serviceFound() {
 		return
 	}
+	if problems := methodContextProblems(g.filePath, g.serviceInterface); len(problems) > 0 {
+		if g.strict {
+			return errors.New(strings.Join(problems, "\n"))
+		}
+		for _, p := range problems {
+			logrus.Warn(p)
+		}
+	}
 	g.removeBadMethods()
 	if len(g.serviceInterface.Methods) == 0 {
 		logrus.Error("The service has no suitable methods please implement the interface methods")
@@ -248,6 +262,39 @@ func (g *GenerateService) serviceFound() bool {
 	}
 	return false
 }
+
+// methodContextProblems describes every exported method of the service interface whose first parameter is not a
+// context.Context, go-kit endpoints always pass the request context as the first argument.
+func methodContextProblems(filePath string, serviceInterface parser.Interface) (problems []string) {
+	for _, v := range serviceInterface.Methods {
+		if string(v.Name[0]) == strings.ToLower(string(v.Name[0])) {
+			continue
+		}
+		if len(v.Parameters) > 0 && v.Parameters[0].Type == "context.Context" {
+			continue
+		}
+		position := fmt.Sprintf("%s:%d:%d", filePath, v.Position.Line, v.Position.Column)
+		ctxParameter := 0
+		for i, p := range v.Parameters {
+			if p.Type == "context.Context" {
+				ctxParameter = i + 1
+				break
+			}
+		}
+		if ctxParameter == 0 {
+			problems = append(problems, fmt.Sprintf(
+				"%s: the method '%s' does not have a context.Context as its first parameter", position, v.Name,
+			))
+			continue
+		}
+		problems = append(problems, fmt.Sprintf(
+			"%s: the method '%s' has a context.Context as parameter %d, it must be the first parameter",
+			position, v.Name, ctxParameter,
+		))
+	}
+	return
+}
+
 func (g *GenerateService) removeBadMethods() {
 	keepMethods := []parser.Method{}
 	for _, v := range g.serviceInterface.Methods {
@@ -259,16 +306,13 @@ func (g *GenerateService) removeBadMethods() {
 			logrus.Warnf("The method '%s' does not have any return value and will be ignored", v.Name)
 			continue
 		}
-		for n, p := range v.Parameters {
+		// the methods without a context are reported by methodContextProblems
+		for _, p := range v.Parameters {
 			if p.Type == "context.Context" {
 				keepMethods = append(keepMethods, v)
 				break
-			} else if n == len(v.Parameters)-1 {
-				logrus.Warnf("The method '%s' does not have a context and will be ignored", v.Name)
-				continue
 			}
 		}
-
 	}
 	g.serviceInterface.Methods = keepMethods
 }
@@ -1 +1,42 @@
 package generator
+
+import (
+	"testing"
+
+	"github.com/kujtimiihoxha/kit/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMethodContextProblems(t *testing.T) {
+	f, err := parser.NewFileParser().Parse([]byte(`package service
+	import "context"
+	type TestService interface {
+		Foo(ctx context.Context, s string) (r string, err error)
+		Bar(s string, ctx context.Context) (r string, err error)
+		Baz(s string) (r string, err error)
+		Qux() (err error)
+		private(s string) (err error)
+	}`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	assert.Equal(t, []string{
+		"test/pkg/service/service.go:5:3: the method 'Bar' has a context.Context as parameter 2, it must be the first parameter",
+		"test/pkg/service/service.go:6:3: the method 'Baz' does not have a context.Context as its first parameter",
+		"test/pkg/service/service.go:7:3: the method 'Qux' does not have a context.Context as its first parameter",
+	}, methodContextProblems("test/pkg/service/service.go", f.Interfaces[0]))
+}
+
+func TestGenerateServiceStrictContext(t *testing.T) {
+	setDefaults()
+	g := NewGenerateService("test", "http", "", "", false, false, true, nil).(*GenerateService)
+	g.fs.WriteFile(g.filePath, `package service
+	import "context"
+	type TestService interface {
+		Foo(ctx context.Context, s string) (r string, err error)
+		Bar(s string) (r string, err error)
+	}`, true)
+
+	err := g.Generate()
+	assert.EqualError(t, err, "test/pkg/service/service.go:5:3: the method 'Bar' does not have a context.Context as its first parameter")
+}
@@ -5,6 +5,7 @@ import (
 	"go/ast"
 	ps "go/parser"
 	"go/token"
+	"sort"
 	"strings"
 
 	"strconv"
@@ -24,6 +25,48 @@ type Gen interface {
 	Generate() error
 }
 
+// GenArgs holds the arguments a generator is created with by its Factory.
+type GenArgs struct {
+	// Name is the name of the service to generate for.
+	Name string
+	// Target narrows the generation down to a single item of the service, e.g. a pb struct for dto, empty for all.
+	Target string
+	// Options holds the options specific to the generator, e.g. DTOOptions, the defaults are used if nil.
+	Options interface{}
+}
+
+// Factory creates a generator.
+type Factory func(args GenArgs) Gen
+
+var factories = map[string]Factory{}
+
+// Register makes a generator available by name, it panics if a generator is already registered with this name.
+func Register(name string, factory Factory) {
+	if _, ok := factories[name]; ok {
+		panic(fmt.Sprintf("generator %s is already registered", name))
+	}
+	factories[name] = factory
+}
+
+// Registered returns the sorted names of the registered generators.
+func Registered() []string {
+	names := []string{}
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewGen creates the generator registered with the given name.
+func NewGen(name string, args GenArgs) (Gen, error) {
+	factory, ok := factories[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown generator %s, available generators: %s", name, strings.Join(Registered(), ", "))
+	}
+	return factory(args), nil
+}
+
 // BaseGenerator implements some basic generator functionality used by all generators.
 type BaseGenerator struct {
 	srcFile *jen.File
@@ -2,12 +2,15 @@ package generator
 
 import (
 	"path"
+	"strings"
+	"testing"
 
 	"runtime"
 
 	"github.com/kujtimiihoxha/kit/parser"
 	"github.com/kujtimiihoxha/kit/utils"
 	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
 )
 
 func setDefaults() {
@@ -68,3 +71,24 @@ func getTestServiceInterface(name string) parser.Interface {
 		),
 	})
 }
+
+func TestRegistry(t *testing.T) {
+	setDefaults()
+	assert.Contains(t, Registered(), "dto")
+
+	g, err := NewGen("dto", GenArgs{Name: "hello", Target: "HelloRequest", Options: DTOOptions{TypeSuffix: "DTO"}})
+	if assert.NoError(t, err) {
+		dto := g.(*GenerateDTOFromProtoGo)
+		assert.Equal(t, "hello", dto.serviceName)
+		assert.Equal(t, "HelloRequest", dto.targetPBStructName)
+		assert.Equal(t, "DTO", dto.options.TypeSuffix)
+	}
+
+	_, err = NewGen("unknown", GenArgs{Name: "hello"})
+	assert.EqualError(t, err, "unknown generator unknown, available generators: "+strings.Join(Registered(), ", "))
+
+	Register("test", func(args GenArgs) Gen { return nil })
+	defer delete(factories, "test")
+	assert.Contains(t, Registered(), "test")
+	assert.Panics(t, func() { Register("test", func(args GenArgs) Gen { return nil }) })
+}
@@ -9,6 +9,7 @@ require (
 	github.com/dave/jennifer v1.3.0
 	github.com/emicklei/proto v1.6.10
 	github.com/emicklei/proto-contrib v0.0.0-20190206213850-73879796f936
+	github.com/fsnotify/fsnotify v1.4.7
 	github.com/inconshreveable/mousetrap v1.0.0 // indirect
 	github.com/mattn/go-isatty v0.0.7 // indirect
 	github.com/sirupsen/logrus v1.4.0
@@ -16,7 +17,7 @@ require (
 	github.com/spf13/afero v1.2.2
 	github.com/spf13/cobra v0.0.3
 	github.com/spf13/viper v1.3.2
-	github.com/stretchr/testify v1.7.0 // indirect
+	github.com/stretchr/testify v1.7.0
 	golang.org/x/tools v0.0.0-20190401163957-4fc9f0bfa59a
 	gopkg.in/yaml.v2 v2.2.2
 )
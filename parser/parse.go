@@ -4,9 +4,16 @@ import (
 	"bytes"
 	"fmt"
 	"go/ast"
+	"go/build"
+	"go/constant"
 	"go/format"
 	"go/parser"
 	"go/token"
+	"go/types"
+	"os"
+	"path"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/kujtimiihoxha/kit/utils"
@@ -18,13 +25,79 @@ type FileParser struct{}
 
 // Parse will parse the go source.
 func (fp *FileParser) Parse(src []byte) (*File, error) {
-	f := NewFile()
 	// Create the AST by parsing src.
 	fset := token.NewFileSet() // positions are relative to fset
 	pf, err := parser.ParseFile(fset, "src.go", src, parser.ParseComments)
 	if err != nil {
 		return nil, err
 	}
+	return fp.parseFile(fset, pf, fp.resolveConsts(fset, []*ast.File{pf})), nil
+}
+
+// ParseDir will parse all the go files (excluding tests) of the package in the directory `path`
+// and merge them into a single File, so that types referenced across files of the package can be resolved.
+// The files excluded by their build constraints, e.g. a _windows.go file on linux or a file tagged //go:build ignore,
+// are left out as the go tool does, and the constants are resolved across the files.
+func (fp *FileParser) ParseDir(path string) (*File, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, path, func(fi os.FileInfo) bool {
+		if strings.HasSuffix(fi.Name(), "_test.go") {
+			return false
+		}
+		// an unreadable file fails parsing it
+		match, err := build.Default.MatchFile(path, fi.Name())
+		return match || err != nil
+	}, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected exactly one package in %s, found %d", path, len(pkgs))
+	}
+
+	merged := NewFile()
+	for name, pkg := range pkgs {
+		merged.Package = name
+
+		// parse files in a stable order so the merged declarations are deterministic
+		fileNames := []string{}
+		for fileName := range pkg.Files {
+			fileNames = append(fileNames, fileName)
+		}
+		sort.Strings(fileNames)
+		files := []*ast.File{}
+		for _, fileName := range fileNames {
+			files = append(files, pkg.Files[fileName])
+		}
+		resolved := fp.resolveConsts(fset, files)
+
+		for _, file := range files {
+			f := fp.parseFile(fset, file, resolved)
+			merged.Imports = append(merged.Imports, f.Imports...)
+			for name, importPath := range f.ImportPaths {
+				if previous, ok := merged.ImportPaths[name]; ok && previous != importPath {
+					return nil, fmt.Errorf("the files of %s import both %s and %s as %s", path, previous, importPath, name)
+				}
+				merged.ImportPaths[name] = importPath
+			}
+			merged.Constants = append(merged.Constants, f.Constants...)
+			merged.Consts = append(merged.Consts, f.Consts...)
+			merged.Vars = append(merged.Vars, f.Vars...)
+			merged.Interfaces = append(merged.Interfaces, f.Interfaces...)
+			merged.Structures = append(merged.Structures, f.Structures...)
+			merged.Methods = append(merged.Methods, f.Methods...)
+			merged.DefinedTypes = append(merged.DefinedTypes, f.DefinedTypes...)
+			if merged.FuncType.Name == "" {
+				merged.FuncType = f.FuncType
+			}
+		}
+	}
+	return &merged, nil
+}
+
+// parseFile parses the declarations of a file, resolved being the exact values of the constants of its package, see resolveConsts
+func (fp *FileParser) parseFile(fset *token.FileSet, pf *ast.File, resolved map[string]string) *File {
+	f := NewFile()
 	f.Package = pf.Name.Name
 	for _, v := range pf.Decls {
 		if dec, ok := v.(*ast.FuncDecl); ok {
@@ -58,22 +131,29 @@ func (fp *FileParser) Parse(src []byte) (*File, error) {
 		if dec, ok := v.(*ast.GenDecl); ok {
 			switch dec.Tok {
 			case token.IMPORT:
-				f.Imports = fp.parseImports(dec.Specs)
+				imports := fp.parseImports(dec.Specs)
+				f.Imports = append(f.Imports, imports...)
+				for _, imp := range imports {
+					if name, importPath, ok := ImportName(imp); ok {
+						f.ImportPaths[name] = importPath
+					}
+				}
 			case token.CONST:
 				f.Constants = append(f.Constants, fp.parseConstants(dec.Specs)...)
+				f.Consts = append(f.Consts, fp.parseConsts(dec.Specs, resolved)...)
 			case token.VAR:
-				f.Vars = append(f.Vars, fp.parseVars(dec.Specs)...)
+				f.Vars = append(f.Vars, fp.parseVars(fset, dec.Specs)...)
 			case token.TYPE:
-				fp.parseType(dec.Specs, &f)
+				fp.parseType(fset, dec.Specs, &f)
 			default:
 				logrus.Info("Skipping unknown Token Type")
 			}
 		}
 	}
 	//fmt.Println(f.String())
-	return &f, nil
+	return &f
 }
-func (fp *FileParser) parseType(ds []ast.Spec, f *File) {
+func (fp *FileParser) parseType(fset *token.FileSet, ds []ast.Spec, f *File) {
 	for _, sp := range ds {
 		tsp, ok := sp.(*ast.TypeSpec)
 		if !ok {
@@ -83,13 +163,16 @@ func (fp *FileParser) parseType(ds []ast.Spec, f *File) {
 		switch tsp.Type.(type) {
 		case *ast.InterfaceType:
 			ift := tsp.Type.(*ast.InterfaceType)
-			mth := fp.parseFieldListAsMethods(ift.Methods)
+			mth := fp.parseFieldListAsMethods(fset, ift.Methods)
 			intr := NewInterface(tsp.Name.Name, mth)
 			intr.Methods = mth
+			intr.TypeParams = fp.parseTypeParams(tsp.TypeParams)
 			f.Interfaces = append(f.Interfaces, intr)
 		case *ast.StructType:
 			st := tsp.Type.(*ast.StructType)
-			str := NewStruct(tsp.Name.Name, fp.parseFieldListAsNamedTypes(st.Fields))
+			str := NewStruct(tsp.Name.Name, fp.parseStructFields(fset, st.Fields))
+			str.Position = fset.Position(tsp.Name.Pos())
+			str.TypeParams = fp.parseTypeParams(tsp.TypeParams)
 			f.Structures = append(f.Structures, str)
 		case *ast.FuncType:
 			st := tsp.Type.(*ast.FuncType)
@@ -98,6 +181,8 @@ func (fp *FileParser) parseType(ds []ast.Spec, f *File) {
 				Parameters: fp.parseFieldListAsNamedTypes(st.Params),
 				Results:    fp.parseFieldListAsNamedTypes(st.Results),
 			}
+		case *ast.Ident, *ast.SelectorExpr:
+			f.DefinedTypes = append(f.DefinedTypes, NewNameType(tsp.Name.Name, fp.getTypeFromExp(tsp.Type)))
 		default:
 			logrus.Info("Skipping unknown type")
 		}
@@ -122,7 +207,21 @@ func (fp *FileParser) parseImports(ds []ast.Spec) []NamedTypeValue {
 	}
 	return imports
 }
-func (fp *FileParser) parseVars(ds []ast.Spec) []NamedTypeValue {
+
+// ImportName returns the name an import is referred to by and its unquoted path, e.g. commonpb and
+// github.com/acme/common/pb for commonpb "github.com/acme/common/pb", the name defaults to the last element of the path,
+// ok is false for the blank and dot imports
+func ImportName(imp NamedTypeValue) (name string, importPath string, ok bool) {
+	importPath, err := strconv.Unquote(imp.Type)
+	if err != nil || imp.Name == "_" || imp.Name == "." {
+		return "", "", false
+	}
+	if imp.Name != "" {
+		return imp.Name, importPath, true
+	}
+	return path.Base(importPath), importPath, true
+}
+func (fp *FileParser) parseVars(fset *token.FileSet, ds []ast.Spec) []NamedTypeValue {
 	vars := []NamedTypeValue{}
 	for _, sp := range ds {
 		vsp, ok := sp.(*ast.ValueSpec)
@@ -131,6 +230,7 @@ func (fp *FileParser) parseVars(ds []ast.Spec) []NamedTypeValue {
 			continue
 		}
 		tp, ok := vsp.Type.(*ast.Ident)
+		var v NamedTypeValue
 		if len(vsp.Values) > 0 {
 			fst := token.NewFileSet()
 			bt := bytes.NewBufferString("")
@@ -140,17 +240,19 @@ func (fp *FileParser) parseVars(ds []ast.Spec) []NamedTypeValue {
 				logrus.Panic(err)
 			}
 			if !ok {
-				vars = append(vars, NewNameTypeValue(vsp.Names[0].Name, "", bd))
-				continue
+				v = NewNameTypeValue(vsp.Names[0].Name, "", bd)
+			} else {
+				v = NewNameTypeValue(tp.Name, vsp.Names[0].Name, bd)
 			}
-			vars = append(vars, NewNameTypeValue(tp.Name, vsp.Names[0].Name, bd))
 		} else {
 			if !ok {
-				vars = append(vars, NewNameType(vsp.Names[0].Name, ""))
-				continue
+				v = NewNameType(vsp.Names[0].Name, "")
+			} else {
+				v = NewNameType(tp.Name, vsp.Names[0].Name)
 			}
-			vars = append(vars, NewNameType(tp.Name, vsp.Names[0].Name))
 		}
+		v.Position = fset.Position(vsp.Names[0].Pos())
+		vars = append(vars, v)
 	}
 	return vars
 }
@@ -162,6 +264,10 @@ func (fp *FileParser) parseConstants(ds []ast.Spec) []NamedTypeValue {
 			logrus.Debug("Constant spec is not ValueSpec type, odd, skipping")
 			continue
 		}
+		if len(vsp.Values) == 0 {
+			// implicitly repeated constant of an iota group, see parseConsts
+			continue
+		}
 		fst := token.NewFileSet()
 		bt := bytes.NewBufferString("")
 		err := format.Node(bt, fst, vsp.Values[0])
@@ -178,6 +284,82 @@ func (fp *FileParser) parseConstants(ds []ast.Spec) []NamedTypeValue {
 	}
 	return constants
 }
+
+// parseConsts parses the constants of a const group, repeating the type and value expressions of the last explicit spec
+// for the specs without values, as go does for iota groups
+func (fp *FileParser) parseConsts(ds []ast.Spec, resolved map[string]string) []Const {
+	consts := []Const{}
+	var typ ast.Expr
+	var values []ast.Expr
+	for i, sp := range ds {
+		vsp, ok := sp.(*ast.ValueSpec)
+		if !ok {
+			logrus.Debug("Constant spec is not ValueSpec type, odd, skipping")
+			continue
+		}
+		if len(vsp.Values) > 0 {
+			typ, values = vsp.Type, vsp.Values
+		}
+		for j, name := range vsp.Names {
+			c := Const{
+				Name:     name.Name,
+				Iota:     i,
+				Resolved: resolved[name.Name],
+			}
+			if typ != nil {
+				c.Type = fp.getTypeFromExp(typ)
+			}
+			if j < len(values) {
+				bt := bytes.NewBufferString("")
+				if err := format.Node(bt, token.NewFileSet(), values[j]); err != nil {
+					logrus.Panic(err)
+				}
+				c.Value = bt.String()
+			}
+			consts = append(consts, c)
+		}
+	}
+	return consts
+}
+
+// resolveConsts returns the exact values of the package level constants of the files of a package keyed by name,
+// type checking the files together without their imports, so constants depending on imports can not be resolved and are left out
+func (fp *FileParser) resolveConsts(fset *token.FileSet, files []*ast.File) map[string]string {
+	info := &types.Info{Defs: map[*ast.Ident]types.Object{}}
+	conf := types.Config{
+		// the errors, e.g. unresolved imports, only leave the constants depending on them unresolved
+		Error: func(error) {},
+	}
+	conf.Check(files[0].Name.Name, fset, files, info)
+
+	resolved := map[string]string{}
+	for ident, obj := range info.Defs {
+		c, ok := obj.(*types.Const)
+		if !ok || c.Parent() != c.Pkg().Scope() || c.Val().Kind() == constant.Unknown {
+			continue
+		}
+		resolved[ident.Name] = c.Val().ExactString()
+	}
+	return resolved
+}
+
+// parseStructFields parses the fields of a struct as parseFieldListAsNamedTypes, along with their positions
+func (fp *FileParser) parseStructFields(fset *token.FileSet, list *ast.FieldList) []NamedTypeValue {
+	fields := fp.parseFieldListAsNamedTypes(list)
+	i := 0
+	for _, p := range list.List {
+		if len(p.Names) == 0 {
+			fields[i].Position = fset.Position(p.Type.Pos())
+			i++
+			continue
+		}
+		for _, ident := range p.Names {
+			fields[i].Position = fset.Position(ident.Pos())
+			i++
+		}
+	}
+	return fields
+}
 func (fp *FileParser) parseFieldListAsNamedTypes(list *ast.FieldList) []NamedTypeValue {
 	ntv := []NamedTypeValue{}
 	if list != nil {
@@ -200,8 +382,15 @@ func (fp *FileParser) parseFieldListAsNamedTypes(list *ast.FieldList) []NamedTyp
 					names = append(names, utils.ToLowerFirstCamelCase(typ[:1]+fmt.Sprintf("%d", i)))
 				}
 			}
+			tag := ""
+			if p.Tag != nil {
+				tag, _ = strconv.Unquote(p.Tag.Value)
+			}
+			comment := strings.TrimSpace(p.Doc.Text() + p.Comment.Text())
 			for _, name := range names {
 				namedType := NewNameType(name, typ)
+				namedType.Tag = tag
+				namedType.Comment = comment
 				logrus.Debug(fmt.Sprintf("NamedType %+v", namedType))
 				ntv = append(ntv, namedType)
 			}
@@ -223,7 +412,12 @@ func (fp *FileParser) getTypeFromExp(e ast.Expr) string {
 		tp = "*" + starIndent
 	case *ast.ArrayType:
 		arrIndent := fp.getTypeFromExp(k.Elt)
-		tp = "[]" + arrIndent
+		// a fixed-size array keeps its length, e.g. [16]byte, which may be a constant, e.g. [Size]byte
+		length := ""
+		if k.Len != nil {
+			length = types.ExprString(k.Len)
+		}
+		tp = "[" + length + "]" + arrIndent
 	case *ast.MapType:
 		key := fp.getTypeFromExp(k.Key)
 		value := fp.getTypeFromExp(k.Value)
@@ -233,20 +427,87 @@ func (fp *FileParser) getTypeFromExp(e ast.Expr) string {
 	case *ast.Ellipsis:
 		t := fp.getTypeFromExp(k.Elt)
 		tp = "..." + t
+	case *ast.IndexExpr, *ast.IndexListExpr:
+		// an instantiated generic type, e.g. Box[int] or Pair[string, int]
+		tp = types.ExprString(k)
 	default:
 		logrus.Info("Type Expresion not supported")
 		return ""
 	}
 	return tp
 }
-func (fp *FileParser) parseFieldListAsMethods(list *ast.FieldList) []Method {
+
+// ParseType parses a type expression as written by the parser into its structure, see TypeInfo, e.g. map[string]*Foo,
+// an expression which is not a valid type, e.g. the ...int of a variadic parameter, is kept as the Name of the type
+func ParseType(typ string) TypeInfo {
+	if typ == "" {
+		return TypeInfo{}
+	}
+	expr, err := parser.ParseExpr(typ)
+	if err != nil {
+		return TypeInfo{Name: typ}
+	}
+	return typeInfoOf(expr)
+}
+
+func typeInfoOf(e ast.Expr) TypeInfo {
+	switch k := e.(type) {
+	case *ast.ParenExpr:
+		return typeInfoOf(k.X)
+	case *ast.StarExpr:
+		info := typeInfoOf(k.X)
+		info.Pointers++
+		return info
+	case *ast.ArrayType:
+		elem := typeInfoOf(k.Elt)
+		if k.Len == nil {
+			return TypeInfo{Slice: true, Elem: &elem}
+		}
+		return TypeInfo{ArrayLen: types.ExprString(k.Len), Elem: &elem}
+	case *ast.MapType:
+		key, elem := typeInfoOf(k.Key), typeInfoOf(k.Value)
+		return TypeInfo{Map: true, Key: &key, Elem: &elem}
+	}
+	return TypeInfo{Name: types.ExprString(e)}
+}
+
+// String returns the type expression of a type, e.g. *[]*pb.Foo
+func (t TypeInfo) String() string {
+	typ := strings.Repeat("*", t.Pointers)
+	switch {
+	case t.Slice:
+		return typ + "[]" + t.Elem.String()
+	case t.ArrayLen != "":
+		return typ + "[" + t.ArrayLen + "]" + t.Elem.String()
+	case t.Map:
+		return typ + "map[" + t.Key.String() + "]" + t.Elem.String()
+	}
+	return typ + t.Name
+}
+
+// parseTypeParams parses the type parameters of a generic type, the constraints being kept as written, e.g. ~int | ~string,
+// nil for a type which is not generic
+func (fp *FileParser) parseTypeParams(list *ast.FieldList) []NamedTypeValue {
+	var typeParams []NamedTypeValue
+	if list == nil {
+		return nil
+	}
+	for _, p := range list.List {
+		for _, ident := range p.Names {
+			typeParams = append(typeParams, NewNameType(ident.Name, types.ExprString(p.Type)))
+		}
+	}
+	return typeParams
+}
+func (fp *FileParser) parseFieldListAsMethods(fset *token.FileSet, list *ast.FieldList) []Method {
 	mth := []Method{}
 	if list != nil {
 		for _, p := range list.List {
 			switch t := p.Type.(type) {
 			case *ast.FuncType:
 				m := Method{
-					Name: p.Names[0].Name,
+					Name:     p.Names[0].Name,
+					Position: fset.Position(p.Names[0].Pos()),
 				}
 				m.Parameters = fp.parseFieldListAsNamedTypes(t.Params)
 				m.Results = fp.parseFieldListAsNamedTypes(t.Results)
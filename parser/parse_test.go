@@ -1,6 +1,10 @@
 package parser
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
 
 	. "github.com/smartystreets/goconvey/convey"
@@ -101,6 +105,38 @@ func TestFileParser_ParseVariablesConstants(t *testing.T) {
 	})
 }
 
+func TestFileParser_ParseConsts(t *testing.T) {
+	fp := NewFileParser()
+	f, err := fp.Parse([]byte(
+		`package main
+		import "time"
+		type Status int32
+		const (
+			Status_UNKNOWN Status = iota
+			Status_ACTIVE
+			Status_DELETED
+		)
+		const (
+			KB = 1 << (10 * (iota + 1))
+			MB
+		)
+		const name, timeout = "kit", 2 * time.Second
+		`))
+	Convey("Test if parser parses file without errors", t, func() {
+		So(err, ShouldBeNil)
+		Convey("Test if the constants of iota groups are found with their resolved values", func() {
+			So(len(f.Consts), ShouldEqual, 7)
+			So(f.Consts[0], ShouldResemble, Const{Name: "Status_UNKNOWN", Type: "Status", Value: "iota", Iota: 0, Resolved: "0"})
+			So(f.Consts[2], ShouldResemble, Const{Name: "Status_DELETED", Type: "Status", Value: "iota", Iota: 2, Resolved: "2"})
+			So(f.Consts[4], ShouldResemble, Const{Name: "MB", Value: "1 << (10 * (iota + 1))", Iota: 1, Resolved: "1048576"})
+		})
+		Convey("Test if the constants depending on imports are left unresolved", func() {
+			So(f.Consts[5], ShouldResemble, Const{Name: "name", Value: `"kit"`, Resolved: `"kit"`})
+			So(f.Consts[6], ShouldResemble, Const{Name: "timeout", Value: "2 * time.Second"})
+		})
+	})
+}
+
 func TestFileParser_ParseMiddlewareFuncType(t *testing.T) {
 	fp := NewFileParser()
 	f, err := fp.Parse([]byte(
@@ -136,3 +172,324 @@ func TestFileParser_ParseMiddlewareFuncType(t *testing.T) {
 		})
 	})
 }
+
+func TestFileParser_ParseStructFieldTags(t *testing.T) {
+	fp := NewFileParser()
+	f, err := fp.Parse([]byte("package main\n" +
+		"type Hi struct {\n" +
+		"	Name string `json:\"name\" validate:\"required\"`\n" +
+		"	Age  int\n" +
+		"}"))
+	Convey("Test if parser parses file without errors", t, func() {
+		So(err, ShouldBeNil)
+		Convey("Test if struct field tags are captured unquoted", func() {
+			So(len(f.Structures), ShouldEqual, 1)
+			So(f.Structures[0].Vars[0].Tag, ShouldEqual, `json:"name" validate:"required"`)
+			So(f.Structures[0].Vars[1].Tag, ShouldEqual, "")
+		})
+	})
+}
+
+func TestFileParser_ParseArrayTypes(t *testing.T) {
+	fp := NewFileParser()
+	f, err := fp.Parse([]byte(`package main
+type Hi struct {
+	Digest  [16]byte
+	Friends [4]*Friend
+	Keys    [Size]string
+	Names   []string
+}`))
+	Convey("Test if parser parses file without errors", t, func() {
+		So(err, ShouldBeNil)
+		Convey("Test if fixed-size arrays keep their length unlike slices", func() {
+			So(len(f.Structures), ShouldEqual, 1)
+			So(f.Structures[0].Vars[0].Type, ShouldEqual, "[16]byte")
+			So(f.Structures[0].Vars[1].Type, ShouldEqual, "[4]*Friend")
+			So(f.Structures[0].Vars[2].Type, ShouldEqual, "[Size]string")
+			So(f.Structures[0].Vars[3].Type, ShouldEqual, "[]string")
+		})
+	})
+}
+
+func TestFileParser_ParseImportPaths(t *testing.T) {
+	fp := NewFileParser()
+	f, err := fp.Parse([]byte(`package main
+import (
+	"context"
+	ts "google.golang.org/protobuf/types/known/timestamppb"
+	_ "embed"
+	. "strings"
+)
+import "google.golang.org/protobuf/types/known/durationpb"
+type Hi struct {
+	CreatedAt *ts.Timestamp
+}`))
+	Convey("Test if parser parses file without errors", t, func() {
+		So(err, ShouldBeNil)
+		Convey("Test if the imports of every import declaration are kept", func() {
+			So(len(f.Imports), ShouldEqual, 5)
+		})
+		Convey("Test if the names the imports are referred to by resolve to their paths", func() {
+			So(f.ImportPaths, ShouldResemble, map[string]string{
+				"context":    "context",
+				"ts":         "google.golang.org/protobuf/types/known/timestamppb",
+				"durationpb": "google.golang.org/protobuf/types/known/durationpb",
+			})
+		})
+	})
+}
+
+func TestFileParser_ParseStructFieldComments(t *testing.T) {
+	fp := NewFileParser()
+	f, err := fp.Parse([]byte(`package main
+type Hi struct {
+	// Name of the user
+	// @json:user_name
+	Name string
+	Age  int // in years
+	Id   int
+}`))
+	Convey("Test if parser parses file without errors", t, func() {
+		So(err, ShouldBeNil)
+		Convey("Test if struct field comments are captured", func() {
+			So(len(f.Structures), ShouldEqual, 1)
+			So(f.Structures[0].Vars[0].Comment, ShouldEqual, "Name of the user\n@json:user_name")
+			So(f.Structures[0].Vars[1].Comment, ShouldEqual, "in years")
+			So(f.Structures[0].Vars[2].Comment, ShouldEqual, "")
+		})
+	})
+}
+
+func TestFileParser_ParseDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kit-parser")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"a.go": `package pb
+		type HelloRequest struct {
+			Address *Address
+		}`,
+		"b.go": `package pb
+		type Address struct {
+			Street string
+		}
+		type Greeter interface {
+			Hello(r *HelloRequest) error
+		}`,
+		"b_test.go": `package pb_test
+		type Ignored struct{}`,
+		"c.go": `package pb
+		const B = A + 1`,
+		"d.go": `package pb
+		const A = 1`,
+		"e.go": `//go:build ignore
+
+		package pb
+		type Address struct{}`,
+	}
+	// a single file of a platform is built, whatever the platform the tests run on
+	otherGOOS := "plan9"
+	if runtime.GOOS == otherGOOS {
+		otherGOOS = "windows"
+	}
+	files["platform_"+runtime.GOOS+".go"] = `package pb
+	type Platform struct {
+		Name string
+	}`
+	files["platform_"+otherGOOS+".go"] = `package pb
+	type Platform struct {
+		Other string
+	}`
+	for name, src := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := NewFileParser().ParseDir(dir)
+	Convey("Test if parser parses directory without errors", t, func() {
+		So(err, ShouldBeNil)
+		Convey("Test if declarations of all files are merged, excluding tests", func() {
+			So(f.Package, ShouldEqual, "pb")
+			So(len(f.Structures), ShouldEqual, 3)
+			So(f.Structures[0].Name, ShouldEqual, "HelloRequest")
+			So(f.Structures[1].Name, ShouldEqual, "Address")
+			So(len(f.Interfaces), ShouldEqual, 1)
+			So(f.Interfaces[0].Name, ShouldEqual, "Greeter")
+		})
+		Convey("Test if files excluded by their build constraints are left out", func() {
+			So(f.Structures[2].Name, ShouldEqual, "Platform")
+			So(f.Structures[2].Vars[0].Name, ShouldEqual, "Name")
+			So(len(f.Structures[1].Vars), ShouldEqual, 1)
+		})
+		Convey("Test if constants are resolved across files", func() {
+			So(len(f.Consts), ShouldEqual, 2)
+			So(f.Consts[0].Name, ShouldEqual, "B")
+			So(f.Consts[0].Resolved, ShouldEqual, "2")
+			So(f.Consts[1].Name, ShouldEqual, "A")
+			So(f.Consts[1].Resolved, ShouldEqual, "1")
+		})
+	})
+}
+
+func TestFileParser_ParseDirImportConflict(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kit-parser")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"a.go": `package pb
+		import common "example.com/a/commonpb"
+		type HelloRequest struct {
+			Meta *common.Metadata
+		}`,
+		"b.go": `package pb
+		import common "example.com/b/commonpb"
+		type HelloResponse struct {
+			Meta *common.Metadata
+		}`,
+		"c.go": `package pb
+		import common "example.com/a/commonpb"
+		type ByeRequest struct {
+			Meta *common.Metadata
+		}`,
+	}
+	for name, src := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err = NewFileParser().ParseDir(dir)
+	Convey("Test if an import name referring to different packages in the files fails", t, func() {
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, "the files of "+dir+" import both example.com/a/commonpb and example.com/b/commonpb as common")
+	})
+
+	if err := os.Remove(filepath.Join(dir, "b.go")); err != nil {
+		t.Fatal(err)
+	}
+	f, err := NewFileParser().ParseDir(dir)
+	Convey("Test if an import name referring to the same package in the files is merged", t, func() {
+		So(err, ShouldBeNil)
+		So(f.ImportPaths["common"], ShouldEqual, "example.com/a/commonpb")
+	})
+}
+
+func TestFileParser_ParseDefinedTypes(t *testing.T) {
+	fp := NewFileParser()
+	f, err := fp.Parse([]byte(
+		`package main
+		type Status int32
+		type Timestamp time.Time
+		type Hi struct {}
+		`))
+	Convey("Test if parser parses file without errors", t, func() {
+		So(err, ShouldBeNil)
+		Convey("Test if types defined on other types are found", func() {
+			So(len(f.DefinedTypes), ShouldEqual, 2)
+			So(f.DefinedTypes[0].Name, ShouldEqual, "Status")
+			So(f.DefinedTypes[0].Type, ShouldEqual, "int32")
+			So(f.DefinedTypes[1].Name, ShouldEqual, "Timestamp")
+			So(f.DefinedTypes[1].Type, ShouldEqual, "time.Time")
+		})
+	})
+}
+
+func TestFileParser_ParsePositions(t *testing.T) {
+	fp := NewFileParser()
+	f, err := fp.Parse([]byte(`package main
+var timeout = 2
+type Hi struct {
+	Name, Nickname string
+	*Embedded
+}
+type Service interface {
+	Foo() error
+	Bar(a int) error
+}`))
+	Convey("Test if parser parses file without errors", t, func() {
+		So(err, ShouldBeNil)
+		Convey("Test if structs and their fields carry their positions", func() {
+			So(f.Structures[0].Position.String(), ShouldEqual, "src.go:3:6")
+			So(f.Structures[0].Vars[0].Position.String(), ShouldEqual, "src.go:4:2")
+			So(f.Structures[0].Vars[1].Position.String(), ShouldEqual, "src.go:4:8")
+			So(f.Structures[0].Vars[2].Position.String(), ShouldEqual, "src.go:5:2")
+		})
+		Convey("Test if vars carry their positions", func() {
+			So(f.Vars[0].Position.String(), ShouldEqual, "src.go:2:5")
+		})
+		Convey("Test if interface methods carry their positions", func() {
+			So(f.Interfaces[0].Methods[0].Position.String(), ShouldEqual, "src.go:8:2")
+			So(f.Interfaces[0].Methods[1].Position.String(), ShouldEqual, "src.go:9:2")
+		})
+	})
+}
+
+func TestFileParser_ParseGenerics(t *testing.T) {
+	fp := NewFileParser()
+	f, err := fp.Parse([]byte(`package main
+type Box[T any] struct {
+	Value T
+}
+type Pair[K comparable, V ~int | ~string] struct {
+	Key   K
+	Boxes []Box[V]
+	Pairs map[K]Pair[K, V]
+}
+type Getter[T any] interface {
+	Get() T
+}
+type Hi struct {
+	Box Box[int]
+}
+func (*Box[T]) Unwrap() T { var t T; return t }`))
+	Convey("Test if parser parses file without errors", t, func() {
+		So(err, ShouldBeNil)
+		Convey("Test if the type parameters of generic structs and interfaces are captured", func() {
+			So(f.Structures[0].TypeParams, ShouldResemble, []NamedTypeValue{NewNameType("T", "any")})
+			So(f.Structures[1].TypeParams, ShouldResemble, []NamedTypeValue{NewNameType("K", "comparable"), NewNameType("V", "~int | ~string")})
+			So(f.Interfaces[0].TypeParams, ShouldResemble, []NamedTypeValue{NewNameType("T", "any")})
+			So(f.Structures[2].TypeParams, ShouldBeNil)
+		})
+		Convey("Test if instantiated generic types are kept as written", func() {
+			So(f.Structures[1].Vars[1].Type, ShouldEqual, "[]Box[V]")
+			So(f.Structures[1].Vars[2].Type, ShouldEqual, "map[K]Pair[K, V]")
+			So(f.Structures[2].Vars[0].Type, ShouldEqual, "Box[int]")
+			So(f.Methods[0].Struct.Type, ShouldEqual, "*Box[T]")
+		})
+	})
+}
+
+func TestFileParser_ParseTypeInfo(t *testing.T) {
+	fp := NewFileParser()
+	f, err := fp.Parse([]byte(`package main
+type Hi struct {
+	Name    *string
+	Items   *[]*pb.Item
+	Labels  map[string]*Label
+	Digest  [Size]byte
+	Matrix  [][]float64
+	Handler func(int) int
+}`))
+	Convey("Test if parser parses file without errors", t, func() {
+		So(err, ShouldBeNil)
+		Convey("Test if the structure of the field types is exposed", func() {
+			vars := f.Structures[0].Vars
+			So(vars[0].TypeInfo, ShouldResemble, TypeInfo{Name: "string", Pointers: 1})
+			So(vars[1].TypeInfo, ShouldResemble, TypeInfo{Pointers: 1, Slice: true, Elem: &TypeInfo{Name: "pb.Item", Pointers: 1}})
+			So(vars[2].TypeInfo, ShouldResemble, TypeInfo{Map: true, Key: &TypeInfo{Name: "string"}, Elem: &TypeInfo{Name: "Label", Pointers: 1}})
+			So(vars[3].TypeInfo, ShouldResemble, TypeInfo{ArrayLen: "Size", Elem: &TypeInfo{Name: "byte"}})
+			So(vars[4].TypeInfo.Elem.Slice, ShouldBeTrue)
+			for _, v := range vars {
+				So(v.TypeInfo.String(), ShouldEqual, v.Type)
+			}
+		})
+		Convey("Test if a type which is not an expression is kept as its name", func() {
+			So(ParseType("...int"), ShouldResemble, TypeInfo{Name: "...int"})
+		})
+	})
+}
@@ -1,5 +1,7 @@
 package parser
 
+import "go/token"
+
 // File represents a go source file.
 type File struct {
 	Comment string
@@ -12,6 +14,14 @@ type File struct {
 	Interfaces []Interface
 	Structures []Struct
 	Methods    []Method
+	// DefinedTypes stores the types defined on another type ( e.x type Status int32 )
+	DefinedTypes []NamedTypeValue
+	// Consts stores every constant, including the implicit ones of iota groups, see Const
+	Consts []Const
+	// ImportPaths maps the name each import is referred to by in the file to its path,
+	// e.g. timestamppb to google.golang.org/protobuf/types/known/timestamppb, see ImportName,
+	// the blank and dot imports are left out since no qualified type refers to them
+	ImportPaths map[string]string
 }
 
 // Struct stores go struct information.
@@ -19,6 +29,25 @@ type Struct struct {
 	Name    string
 	Comment string
 	Vars    []NamedTypeValue
+	// TypeParams are the type parameters of a generic struct, their Type being their constraint,
+	// e.g. T any and K comparable for Pair[K comparable, T any]
+	TypeParams []NamedTypeValue
+	// Position is the position of the struct name in the parsed source, used to point diagnostics to it
+	Position token.Position
+}
+
+// Const stores a go constant, the type and value expression of an implicitly repeated constant
+// of a group are the ones of the last explicit spec ( e.x Status_B in const (Status_A Status = iota; Status_B) )
+type Const struct {
+	Name string
+	// Type is empty for untyped constants
+	Type string
+	// Value is the value expression, e.x iota + 1
+	Value string
+	// Iota is the index of the constant in its group
+	Iota int
+	// Resolved is the exact value of the constant when statically determinable ( e.x 2 or "a" ), empty otherwise
+	Resolved string
 }
 
 // FuncType is used to store e.x (type Middleware func(a)a) types
@@ -33,6 +62,8 @@ type Interface struct {
 	Name    string
 	Comment string
 	Methods []Method
+	// TypeParams are the type parameters of a generic interface, see Struct
+	TypeParams []NamedTypeValue
 }
 
 // Method stores go method information.
@@ -43,6 +74,8 @@ type Method struct {
 	Body       string
 	Parameters []NamedTypeValue
 	Results    []NamedTypeValue
+	// Position is the position of the name of an interface method in the parsed source, it is only set for the interface methods
+	Position token.Position
 }
 
 // NamedTypeValue  is used to store any type of name type = value ( e.x  var a = 2)
@@ -50,22 +83,53 @@ type NamedTypeValue struct {
 	Name  string
 	Type  string
 	Value string
+	// Tag is the unquoted struct tag of a struct field ( e.x  json:"a,omitempty")
+	Tag string
+	// Comment is the doc followed by the line comment of a struct field, without the comment markers
+	Comment string
+	// Position is the position of the name of a struct field or a variable in the parsed source, or of the type
+	// of an embedded field, it is only set for the struct fields and the variables
+	Position token.Position
+	// TypeInfo is the structure of Type, see ParseType
+	TypeInfo TypeInfo
+}
+
+// TypeInfo is the structure of a type expression, so that its consumers do not re-parse the * and the brackets of the type,
+// e.g. TypeInfo{Pointers: 1, Slice: true, Elem: &TypeInfo{Pointers: 1, Name: "pb.Foo"}} for *[]*pb.Foo
+type TypeInfo struct {
+	// Name is the name of the type once dereferenced, qualified by its package if any ( e.x pb.Foo or Box[int] ),
+	// empty for a slice, an array or a map
+	Name string
+	// Pointers is the pointer depth of the type ( e.x 2 for **Foo )
+	Pointers int
+	// Slice tells if the type is a slice once dereferenced
+	Slice bool
+	// ArrayLen is the length of an array once dereferenced, which may be a constant ( e.x 16 for [16]byte or Size for [Size]byte )
+	ArrayLen string
+	// Map tells if the type is a map once dereferenced
+	Map bool
+	// Key is the key type of a map
+	Key *TypeInfo
+	// Elem is the element type of a slice, an array or a map
+	Elem *TypeInfo
 }
 
 // NewNameType create a NamedTypeValue without a value.
 func NewNameType(name string, tp string) NamedTypeValue {
 	return NamedTypeValue{
-		Name: name,
-		Type: tp,
+		Name:     name,
+		Type:     tp,
+		TypeInfo: ParseType(tp),
 	}
 }
 
 // NewNameTypeValue create a NamedTypeValue with a value.
 func NewNameTypeValue(name string, tp string, vl string) NamedTypeValue {
 	return NamedTypeValue{
-		Name:  name,
-		Type:  tp,
-		Value: vl,
+		Name:     name,
+		Type:     tp,
+		Value:    vl,
+		TypeInfo: ParseType(tp),
 	}
 }
 
@@ -107,6 +171,10 @@ func NewFile() File {
 		Structures: []Struct{},
 		Vars:       []NamedTypeValue{},
 		Constants:  []NamedTypeValue{},
+		Consts:     []Const{},
 		Methods:    []Method{},
+
+		DefinedTypes: []NamedTypeValue{},
+		ImportPaths:  map[string]string{},
 	}
 }
@@ -1,10 +1,12 @@
 package utils
 
 import (
+	"go/token"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"unicode"
 
 	"fmt"
 
@@ -70,6 +72,54 @@ func ToCamelCase(s string) string {
 	return godash.ToCamelCase(s)
 }
 
+// ReceiverName returns a short receiver name for the methods of the given type, its first letter, e.g. h for HelloRequest
+// or *pb.HelloRequest, or when taken, e.g. by a parameter or a variable of the method, the initials of its words, e.g. hr,
+// or else its first letter numbered, e.g. h2.
+func ReceiverName(typeName string, taken ...string) string {
+	name := strings.TrimLeft(typeName, "*")
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	runes := []rune(name)
+	if len(runes) == 0 || !unicode.IsLetter(runes[0]) {
+		runes = []rune("x")
+	}
+
+	// a word starts after an underscore or at an uppercase letter, the letters of an acronym making a single word, e.g. HTTPRequest
+	initials := []rune{runes[0]}
+	for i := 1; i < len(runes); i++ {
+		startsWord := runes[i-1] == '_' && unicode.IsLetter(runes[i]) ||
+			unicode.IsUpper(runes[i]) && (!unicode.IsUpper(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1]))
+		if startsWord {
+			initials = append(initials, runes[i])
+		}
+	}
+	first := strings.ToLower(string(runes[0]))
+	candidates := []string{first, strings.ToLower(string(initials))}
+
+	isFree := func(candidate string) bool {
+		if token.Lookup(candidate).IsKeyword() {
+			return false
+		}
+		for _, t := range taken {
+			if t == candidate {
+				return false
+			}
+		}
+		return true
+	}
+	for _, candidate := range candidates {
+		if isFree(candidate) {
+			return candidate
+		}
+	}
+	for i := 2; ; i++ {
+		if candidate := fmt.Sprintf("%s%d", first, i); isFree(candidate) {
+			return candidate
+		}
+	}
+}
+
 // GoImportsSource is used to format and optimize imports the
 // given source.
 func GoImportsSource(path string, s string) (string, error) {
@@ -199,6 +249,26 @@ func getImportPath(name string, key string) (string, error) {
 	return importPath, nil
 }
 
+// GetModuleImportPath returns the import path of a package given its folder relative to the project folder,
+// e.g. hello/pkg/grpc/pb, prefixed by the module path read from the go.mod of the service or of the project,
+// it fails when neither declares a module path.
+func GetModuleImportPath(name, packagePath string) (string, error) {
+	modName, err := getModNameFromModFile(name)
+	if err != nil {
+		return "", err
+	} else if strings.TrimSpace(modName) == "" {
+		return "", fmt.Errorf("no module path found in %s/go.mod or go.mod", ToLowerSnakeCase(name))
+	}
+
+	packagePath = strings.Replace(packagePath, "\\", "/", -1)
+	modNameArr := strings.Split(strings.Replace(strings.TrimSpace(modName), "\\", "/", -1), "/")
+	if len(modNameArr) <= 1 {
+		return packagePath, nil
+	}
+	// the module path ends with the service folder, which packagePath starts with
+	return strings.Join(modNameArr[0:len(modNameArr)-1], "/") + "/" + packagePath, nil
+}
+
 func getModNameFromModFile(name string) (string, error) {
 	modFile := "go.mod"
 	filePath := ToLowerSnakeCase(name) + "/" + modFile
@@ -30,6 +30,19 @@ func TestToUpperFirst(t *testing.T) {
 	})
 }
 
+func TestReceiverName(t *testing.T) {
+	Convey("Test if ReceiverName works", t, func() {
+		So(ReceiverName("HelloRequest"), ShouldEqual, "h")
+		So(ReceiverName("*pb.HelloRequest"), ShouldEqual, "h")
+		So(ReceiverName("HelloRequest", "h"), ShouldEqual, "hr")
+		So(ReceiverName("HTTPRequest", "h"), ShouldEqual, "hr")
+		So(ReceiverName("HelloRequest_Text", "h"), ShouldEqual, "hrt")
+		So(ReceiverName("Hello", "h"), ShouldEqual, "h2")
+		So(ReceiverName("HelloRequest", "h", "hr", "h2"), ShouldEqual, "h3")
+		So(ReceiverName("IndexFile", "i"), ShouldEqual, "i2")
+	})
+}
+
 func TestIsExist(t *testing.T) {
 	invalidP := "/@^*(*(&%^&"
 	existedAbsoluteP := "c:"